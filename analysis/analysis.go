@@ -0,0 +1,201 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package analysis finds the projects in a set of cloned repositories, analyzing several of them at a time.
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+// An Analyzer finds the projects in a cloned repository, telling which detector claimed it.
+type Analyzer func(ctx context.Context, clone repo.Local) (unibuild.Detection, error)
+
+// Single makes an analyzer out of a function finding a single project, which it reports as claimed by detector.
+func Single(detector string, find func(ctx context.Context, clone repo.Local) (unibuild.Project, error)) Analyzer {
+	return func(ctx context.Context, clone repo.Local) (unibuild.Detection, error) {
+		p, err := find(ctx, clone)
+		if err != nil {
+			return unibuild.Detection{}, err
+		}
+		return unibuild.Detection{Detector: detector, Projects: []unibuild.Project{p}}, nil
+	}
+}
+
+// Options of an analysis.
+type Options struct {
+	// Workers limits how many repositories are analyzed at a time.
+	// Values below one mean a single one.
+	Workers int
+	// Unrecognised tells errors meaning a repository holds no project the analyzer knows about from other failures.
+	Unrecognised func(err error) bool
+}
+
+// A Failure to find a project in a repository.
+type Failure struct {
+	Clone repo.Local
+	Err   error
+	// Unrecognised is set when the repository holds no project the analyzer knows about.
+	Unrecognised bool
+}
+
+// Reason explains the failure in a single line.
+func (f Failure) Reason() string { return Reason(f.Err) }
+
+// A Claim on a repository by the detector that found the projects in it.
+type Claim struct {
+	Clone    repo.Local
+	Detector string
+	Projects []unibuild.Project
+}
+
+// A Result of analyzing a set of repositories.
+// The projects, claims and failures all follow the order the repositories were given in.
+type Result struct {
+	Projects []unibuild.Project
+	Claims   []Claim
+	Failures []Failure
+}
+
+// Unrecognised lists the repositories that hold no project the analyzer knows about.
+func (res Result) Unrecognised() []Failure { return res.filter(true) }
+
+// Failed lists the repositories holding projects that could not be analyzed.
+func (res Result) Failed() []Failure { return res.filter(false) }
+
+func (res Result) filter(unrecognised bool) []Failure {
+	var out []Failure
+	for _, f := range res.Failures {
+		if f.Unrecognised == unrecognised {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Err reports the repositories that could not be analyzed, if there are any.
+// Unrecognised ones do not count.
+func (res Result) Err() error {
+	failed := res.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(failed))
+	for _, f := range failed {
+		names = append(names, f.Clone.Name)
+	}
+	return oops.Errorf("%d projects could not be analyzed: %s", len(failed), strings.Join(names, ", "))
+}
+
+// WriteSummary writes out which repositories could not be analyzed or were not recognised, and why.
+func (res Result) WriteSummary(w io.Writer) {
+	fmt.Fprintf(w, "analyzed %d projects\n", len(res.Projects))
+	res.writeClaims(w)
+	sections := []struct {
+		title    string
+		failures []Failure
+	}{
+		{"could not be analyzed", res.Failed()},
+		{"not recognised as projects", res.Unrecognised()},
+	}
+	for _, s := range sections {
+		if len(s.failures) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%d repositories %s:\n", len(s.failures), s.title)
+		for _, f := range s.failures {
+			fmt.Fprintf(w, "  %s: %s\n", f.Clone.Name, f.Reason())
+		}
+	}
+}
+
+// writeClaims lists the repositories each detector claimed, with the detectors in the order they first claimed one.
+func (res Result) writeClaims(w io.Writer) {
+	var detectors []string
+	claimed := map[string][]string{}
+	for _, c := range res.Claims {
+		if _, seen := claimed[c.Detector]; !seen {
+			detectors = append(detectors, c.Detector)
+		}
+		claimed[c.Detector] = append(claimed[c.Detector], c.Clone.Name)
+	}
+	for _, d := range detectors {
+		fmt.Fprintf(w, "%d repositories claimed by %s: %s\n", len(claimed[d]), d, strings.Join(claimed[d], ", "))
+	}
+}
+
+// Analyze runs the analyzer on all the clones, with up to opts.Workers of them being analyzed at a time.
+// Once ctx is done, the clones not analyzed yet are reported as failures.
+func Analyze(ctx context.Context, clones []repo.Local, analyze Analyzer, opts Options) Result {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	type outcome struct {
+		detection unibuild.Detection
+		err       error
+	}
+	outcomes := make([]outcome, len(clones))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					outcomes[i].err = oops.Wrapf(err, "analysis cancelled")
+					continue
+				}
+				d, err := analyze(ctx, clones[i])
+				outcomes[i] = outcome{d, err}
+			}
+		}()
+	}
+	for i := range clones {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var res Result
+	for i, o := range outcomes {
+		if o.err == nil {
+			res.Projects = append(res.Projects, o.detection.Projects...)
+			res.Claims = append(res.Claims, Claim{Clone: clones[i], Detector: o.detection.Detector, Projects: o.detection.Projects})
+			continue
+		}
+		unrecognised := opts.Unrecognised != nil && opts.Unrecognised(o.err)
+		res.Failures = append(res.Failures, Failure{Clone: clones[i], Err: o.err, Unrecognised: unrecognised})
+	}
+	return res
+}
+
+// Reason puts the explanations attached to an error on a single line, without the stack trace.
+func Reason(err error) string {
+	// The frames start with the innermost one, while the outermost reason reads best first.
+	reasons := []string{oops.Cause(err).Error()}
+	for _, stack := range oops.Frames(err) {
+		for _, frame := range stack {
+			if frame.Reason != "" {
+				reasons = append(reasons, frame.Reason)
+			}
+		}
+	}
+	for i, j := 0, len(reasons)-1; i < j; i, j = i+1, j-1 {
+		reasons[i], reasons[j] = reasons[j], reasons[i]
+	}
+	return strings.Join(reasons, ": ")
+}
@@ -0,0 +1,165 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package analysis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/analysis"
+	"github.com/szabba/unibuild/repo"
+)
+
+var errUnknown = errors.New("unknown kind of repository")
+
+type project struct{ name string }
+
+func (p project) Info() unibuild.ProjectInfo                   { return unibuild.ProjectInfo{Name: p.name} }
+func (p project) Uses() []unibuild.Requirement                 { return nil }
+func (p project) Builds() []unibuild.RequirementVersion        { return nil }
+func (p project) Build(ctx context.Context, _ io.Writer) error { return nil }
+
+func clones(names ...string) []repo.Local {
+	out := make([]repo.Local, 0, len(names))
+	for _, n := range names {
+		out = append(out, repo.Local{Remote: repo.Remote{Name: n}, Path: n})
+	}
+	return out
+}
+
+func TestAnalyzeLimitsConcurrency(t *testing.T) {
+	// given
+	var (
+		lock          sync.Mutex
+		running, peak int
+	)
+	analyze := func(ctx context.Context, cln repo.Local) (unibuild.Project, error) {
+		lock.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return project{cln.Name}, nil
+	}
+
+	// when
+	res := analysis.Analyze(context.Background(), clones("a", "b", "c", "d", "e", "f"), analysis.Single("test", analyze), analysis.Options{Workers: 2})
+
+	// then
+	assert.That(len(res.Projects) == 6, t.Errorf, "got %d projects, want 6", len(res.Projects))
+	assert.That(peak == 2, t.Errorf, "got %d analyses running at a time, want 2", peak)
+	assert.That(res.Err() == nil, t.Errorf, "unexpected error: %s", res.Err())
+}
+
+func TestAnalyzeCollectsFailures(t *testing.T) {
+	// given
+	analyze := func(ctx context.Context, cln repo.Local) (unibuild.Project, error) {
+		switch cln.Name {
+		case "docs":
+			return nil, oops.Wrapf(errUnknown, "nothing to build in %s", cln.Path)
+		case "broken":
+			return nil, oops.Errorf("bad POM")
+		}
+		return project{cln.Name}, nil
+	}
+	opts := analysis.Options{
+		Workers:      3,
+		Unrecognised: func(err error) bool { return oops.Cause(err) == errUnknown },
+	}
+
+	// when
+	res := analysis.Analyze(context.Background(), clones("app", "docs", "broken", "lib"), analysis.Single("test", analyze), opts)
+
+	// then
+	names := []string{}
+	for _, p := range res.Projects {
+		names = append(names, p.Info().Name)
+	}
+	assert.That(strings.Join(names, ",") == "app,lib", t.Errorf, "got projects %q, want app and lib in order", names)
+
+	unrecognised, failed := res.Unrecognised(), res.Failed()
+	assert.That(len(unrecognised) == 1 && unrecognised[0].Clone.Name == "docs", t.Errorf, "got unrecognised %v", unrecognised)
+	assert.That(len(failed) == 1 && failed[0].Clone.Name == "broken", t.Errorf, "got failed %v", failed)
+	assert.That(res.Err() != nil && strings.Contains(res.Err().Error(), "broken"), t.Errorf, "got error %v", res.Err())
+
+	summary := new(strings.Builder)
+	res.WriteSummary(summary)
+	assert.That(strings.Contains(summary.String(), "  docs: nothing to build in docs: unknown kind of repository\n"), t.Errorf,
+		"unrecognised repository not explained in summary:\n%s", summary)
+}
+
+func TestAnalyzeReportsWhichDetectorClaimedEachRepository(t *testing.T) {
+	// given
+	analyze := func(ctx context.Context, cln repo.Local) (unibuild.Detection, error) {
+		if cln.Name == "site" {
+			return unibuild.Detection{Detector: "npm", Projects: []unibuild.Project{project{"site/web"}, project{"site/docs"}}}, nil
+		}
+		return unibuild.Detection{Detector: "maven", Projects: []unibuild.Project{project{cln.Name}}}, nil
+	}
+
+	// when
+	res := analysis.Analyze(context.Background(), clones("app", "site", "lib"), analyze, analysis.Options{Workers: 2})
+
+	// then
+	assert.That(len(res.Projects) == 4, t.Errorf, "got %d projects, want 4", len(res.Projects))
+	assert.That(len(res.Claims) == 3 && res.Claims[1].Detector == "npm", t.Errorf, "got claims %v", res.Claims)
+
+	summary := new(strings.Builder)
+	res.WriteSummary(summary)
+	assert.That(strings.Contains(summary.String(), "2 repositories claimed by maven: app, lib\n"), t.Errorf,
+		"claims not listed in summary:\n%s", summary)
+}
+
+func TestAnalyzeStopsWhenCancelled(t *testing.T) {
+	// given
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	analyzed := 0
+	analyze := func(ctx context.Context, cln repo.Local) (unibuild.Project, error) {
+		analyzed++
+		return project{cln.Name}, nil
+	}
+
+	// when
+	res := analysis.Analyze(ctx, clones("a", "b"), analysis.Single("test", analyze), analysis.Options{})
+
+	// then
+	assert.That(analyzed == 0, t.Errorf, "analyzed %d repositories after cancellation", analyzed)
+	assert.That(len(res.Failed()) == 2, t.Fatalf, "got %d failures, want 2", len(res.Failed()))
+	err := res.Failed()[0].Err
+	assert.That(oops.Cause(err) == context.Canceled, t.Errorf, "got error %v, want %v", err, context.Canceled)
+}
+
+func TestReason(t *testing.T) {
+	// given
+	inner := func() error { return oops.Wrapf(errUnknown, "inner %d", 1) }
+	err := oops.Wrapf(inner(), "outer")
+
+	// when
+	reason := analysis.Reason(err)
+
+	// then
+	want := "outer: inner 1: unknown kind of repository"
+	assert.That(reason == want, t.Errorf, "got %q, want %q", reason, want)
+	plain := fmt.Errorf("plain")
+	assert.That(analysis.Reason(plain) == "plain", t.Errorf, "got %q for a plain error", analysis.Reason(plain))
+}
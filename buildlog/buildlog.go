@@ -0,0 +1,94 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package buildlog keeps the full output of each project built during a run in a file of its own.
+package buildlog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// A Run is the directory holding the logs of a single unibuild run.
+type Run struct {
+	id  string
+	dir string
+}
+
+// NewRunID derives a run identifier from the time the run started.
+func NewRunID(start time.Time) string {
+	return start.UTC().Format("20060102-150405")
+}
+
+// Create creates the directory for the logs of the run with the given id inside baseDir.
+// When another run already has the id, a numeric suffix is added to it, so that the runs keep their logs apart.
+func Create(baseDir, id string) (*Run, error) {
+	err := os.MkdirAll(baseDir, 0755)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot create log directory %s", baseDir)
+	}
+	unique := id
+	for n := 2; ; n++ {
+		dir := filepath.Join(baseDir, unique)
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return &Run{id: unique, dir: dir}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, oops.Wrapf(err, "cannot create log directory for run %s", unique)
+		}
+		unique = id + "-" + strconv.Itoa(n)
+	}
+}
+
+// ID of the run.
+func (run *Run) ID() string { return run.id }
+
+// Dir containing the logs of the run.
+func (run *Run) Dir() string { return run.dir }
+
+// Open creates the log of the named project.
+// Everything written to the log also goes to console and the last tailLen lines are kept in memory.
+func (run *Run) Open(project string, console io.Writer, tailLen int) (*Log, error) {
+	path := filepath.Join(run.dir, project+".log")
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot create log of project %s", project)
+	}
+	tail := NewTail(tailLen)
+	return &Log{
+		path: path,
+		file: f,
+		tail: tail,
+		out:  io.MultiWriter(f, tail, console),
+	}, nil
+}
+
+// A Log of a single project.
+type Log struct {
+	path string
+	file *os.File
+	tail *Tail
+	out  io.Writer
+}
+
+var _ io.Writer = new(Log)
+
+func (l *Log) Write(p []byte) (int, error) { return l.out.Write(p) }
+
+// Path of the file holding the full log.
+func (l *Log) Path() string { return l.path }
+
+// Tail returns the last lines written to the log.
+func (l *Log) Tail() []string { return l.tail.Lines() }
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	return oops.Wrapf(l.file.Close(), "cannot close log %s", l.path)
+}
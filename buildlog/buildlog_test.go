@@ -0,0 +1,66 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package buildlog_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/buildlog"
+)
+
+func TestLogWritesEverythingToFileAndConsole(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "buildlog")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	run, err := buildlog.Create(dir, "run")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	console := new(strings.Builder)
+	plog, err := run.Open("lib", console, 1)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	io.WriteString(plog, "a\nb\n")
+	err = plog.Close()
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(plog.Path() == filepath.Join(dir, "run", "lib.log"), t.Errorf, "unexpected log path %s", plog.Path())
+
+	content, err := ioutil.ReadFile(plog.Path())
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(string(content) == "a\nb\n", t.Errorf, "file: got %q, want %q", content, "a\nb\n")
+	assert.That(console.String() == "a\nb\n", t.Errorf, "console: got %q, want %q", console.String(), "a\nb\n")
+
+	tail := plog.Tail()
+	assert.That(len(tail) == 1 && tail[0] == "b", t.Errorf, "tail: got %q, want %q", tail, []string{"b"})
+}
+
+func TestRunsStartedAtOnceGetDirectoriesOfTheirOwn(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "buildlog")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	first, err := buildlog.Create(dir, "run")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	second, err := buildlog.Create(dir, "run")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(second.ID() == "run-2", t.Errorf, "got id %q, want %q", second.ID(), "run-2")
+	assert.That(second.Dir() != first.Dir(), t.Errorf, "both runs log to %s", first.Dir())
+}
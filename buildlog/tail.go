@@ -0,0 +1,141 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package buildlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Tail remembers the last few lines written to it.
+type Tail struct {
+	mtx     sync.Mutex
+	size    int
+	lines   []string
+	next    int
+	partial []byte
+}
+
+var _ io.Writer = new(Tail)
+
+// NewTail returns a Tail that remembers up to size lines.
+func NewTail(size int) *Tail {
+	return &Tail{size: size}
+}
+
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.partial = append(t.partial, p...)
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
+		}
+		t.push(string(bytes.TrimSuffix(t.partial[:i], []byte("\r"))))
+		t.partial = t.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (t *Tail) push(line string) {
+	if t.size <= 0 {
+		return
+	}
+	if len(t.lines) < t.size {
+		t.lines = append(t.lines, line)
+		return
+	}
+	t.lines[t.next] = line
+	t.next = (t.next + 1) % t.size
+}
+
+// Lines returns the remembered lines, oldest first.
+// A trailing line without a newline is included.
+func (t *Tail) Lines() []string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	out := make([]string, 0, len(t.lines)+1)
+	out = append(out, t.lines[t.next:]...)
+	out = append(out, t.lines[:t.next]...)
+	if len(t.partial) > 0 {
+		out = append(out, string(t.partial))
+		if len(out) > t.size {
+			out = out[1:]
+		}
+	}
+	return out
+}
+
+// A Condenser passes at most one line per interval on to another writer.
+// Lines written in between are dropped, except for the latest one, which is passed on once the interval is up
+// or the Condenser is flushed.
+type Condenser struct {
+	dst      io.Writer
+	interval time.Duration
+
+	mtx     sync.Mutex
+	last    time.Time
+	pending []byte
+	partial []byte
+}
+
+var _ io.Writer = new(Condenser)
+
+// Condense returns a Condenser writing to dst.
+func Condense(dst io.Writer, interval time.Duration) *Condenser {
+	return &Condenser{dst: dst, interval: interval}
+}
+
+func (c *Condenser) Write(p []byte) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.partial = append(c.partial, p...)
+	end := bytes.LastIndexByte(c.partial, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	complete := c.partial[:end+1]
+	start := bytes.LastIndexByte(complete[:end], '\n') + 1
+	c.pending = append(c.pending[:0], complete[start:]...)
+	c.partial = append(c.partial[:0], c.partial[end+1:]...)
+
+	now := time.Now()
+	if now.Sub(c.last) < c.interval {
+		return len(p), nil
+	}
+	c.last = now
+	err := c.emit()
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush passes on the latest line, if it was held back, and any trailing partial line.
+func (c *Condenser) Flush() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if len(c.partial) > 0 {
+		c.pending = append(append(c.pending[:0], c.partial...), '\n')
+		c.partial = c.partial[:0]
+	}
+	return c.emit()
+}
+
+func (c *Condenser) emit() error {
+	if len(c.pending) == 0 {
+		return nil
+	}
+	_, err := c.dst.Write(c.pending)
+	c.pending = c.pending[:0]
+	return err
+}
@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package buildlog_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/buildlog"
+)
+
+func TestTailKeepsTheLastLines(t *testing.T) {
+	// given
+	tail := buildlog.NewTail(2)
+
+	// when
+	io.WriteString(tail, "a\nb\r\n")
+	io.WriteString(tail, "c\nd")
+
+	// then
+	want := []string{"c", "d"}
+	got := tail.Lines()
+	assert.That(reflect.DeepEqual(got, want), t.Errorf, "got %q, want %q", got, want)
+}
+
+func TestTailWithFewerLinesThanItsSize(t *testing.T) {
+	// given
+	tail := buildlog.NewTail(5)
+
+	// when
+	io.WriteString(tail, "a\nb\n")
+
+	// then
+	want := []string{"a", "b"}
+	got := tail.Lines()
+	assert.That(reflect.DeepEqual(got, want), t.Errorf, "got %q, want %q", got, want)
+}
+
+func TestCondenserWithoutIntervalPassesTheLastLineOfEachWrite(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	c := buildlog.Condense(out, 0)
+
+	// when
+	io.WriteString(c, "a\nb\n")
+	io.WriteString(c, "c\n")
+
+	// then
+	assert.That(out.String() == "b\nc\n", t.Errorf, "out: got %q, want %q", out.String(), "b\nc\n")
+}
+
+func TestCondenserHoldsBackLinesUntilFlushed(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	c := buildlog.Condense(out, time.Hour)
+
+	io.WriteString(c, "a\n")
+	io.WriteString(c, "b\n")
+	io.WriteString(c, "c\n")
+	assert.That(out.String() == "a\n", t.Fatalf, "out: got %q, want %q", out.String(), "a\n")
+
+	// when
+	err := c.Flush()
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == "a\nc\n", t.Errorf, "out: got %q, want %q", out.String(), "a\nc\n")
+}
@@ -8,18 +8,21 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
-	"sync"
 
 	"github.com/samsarahq/go/oops"
 )
 
+// A Cache keeps computed values in files under a base directory.
 type Cache struct {
 	baseDir string
 }
 
+// A Key identifies a cached value.
+// Values of the same Type with equal Properties are considered interchangeable.
 type Key struct {
 	Type       reflect.Type
 	Properties Properties
@@ -34,7 +37,10 @@ func At(dir string) *Cache {
 	return &Cache{dir}
 }
 
-func (c *Cache) Get(k Key, f func() io.Reader, into io.Writer) error {
+// Get copies the value for the key into the writer.
+// When the value is not cached yet, it is computed using f and stored first.
+// Nothing is stored when f fails.
+func (c *Cache) Get(k Key, f func() (io.Reader, error), into io.Writer) error {
 	locKey := c.locate(k)
 	return c.get(locKey, f, into)
 }
@@ -47,7 +53,7 @@ func (c *Cache) locate(k Key) locatedKey {
 	return locatedKey{k, loc}
 }
 
-func (c *Cache) get(k locatedKey, f func() io.Reader, into io.Writer) error {
+func (c *Cache) get(k locatedKey, f func() (io.Reader, error), into io.Writer) error {
 	err := c.ensurePopulated(k, f)
 	if err != nil {
 		return oops.Wrapf(err, "problem populating cache")
@@ -57,40 +63,53 @@ func (c *Cache) get(k locatedKey, f func() io.Reader, into io.Writer) error {
 	return oops.Wrapf(err, "problem loading from cache")
 }
 
-func (c *Cache) ensurePopulated(k locatedKey, f func() io.Reader) error {
+func (c *Cache) ensurePopulated(k locatedKey, f func() (io.Reader, error)) error {
 	if c.exists(k) {
 		return nil
 	}
-	return c.store(k, f())
+	r, err := f()
+	if err != nil {
+		return err
+	}
+	return c.store(k, r)
 }
 
 func (c *Cache) exists(k locatedKey) bool {
 	_, err := os.Stat(k.Location)
-	return os.IsNotExist(oops.Cause(err))
+	return err == nil
 }
 
+// store writes the value to a temporary file first and then moves it into place.
+// That way concurrent readers never see a partially written value.
 func (c *Cache) store(k locatedKey, r io.Reader) error {
-	// TODO: At a first glance this seems to handle "Close might return a write error" fine. Analyse more carefully.
-	once := new(sync.Once)
-	wrap := func(err error) error { return oops.Wrapf(err, "problem storing %#v", k.Key) }
+	wrap := func(err error) error { return oops.Wrapf(err, "problem storing %s", k.Location) }
+
+	dir := filepath.Dir(k.Location)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return wrap(err)
+	}
 
-	f, err := os.Create(k.Location)
+	f, err := ioutil.TempFile(dir, ".tmp-")
 	if err != nil {
 		return wrap(err)
 	}
-	close := func() { err = f.Close() }
-	defer once.Do(close)
+	defer os.Remove(f.Name())
 
 	_, err = io.Copy(f, r)
 	if err != nil {
+		f.Close()
 		return wrap(err)
 	}
-	once.Do(close)
-	return wrap(err)
+	err = f.Close()
+	if err != nil {
+		return wrap(err)
+	}
+	return wrap(os.Rename(f.Name(), k.Location))
 }
 
 func (c *Cache) load(k locatedKey, into io.Writer) error {
-	wrap := func(err error) error { return oops.Wrapf(err, "problem loading %#v", k.Key) }
+	wrap := func(err error) error { return oops.Wrapf(err, "problem loading %s", k.Location) }
 
 	f, err := os.Open(k.Location)
 	if err != nil {
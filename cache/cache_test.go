@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cache_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/cache"
+)
+
+var _StringKey = cache.Key{Type: reflect.TypeOf(""), Properties: cache.Properties{"name": "value"}}
+
+func TestGetComputesValueOnlyOnce(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	c := cache.At(filepath.Join(dir, "nested", "cache"))
+
+	computed := 0
+	compute := func() (io.Reader, error) {
+		computed++
+		return strings.NewReader("cached value"), nil
+	}
+
+	// when
+	first, second := new(strings.Builder), new(strings.Builder)
+	firstErr := c.Get(_StringKey, compute, first)
+	secondErr := c.Get(_StringKey, compute, second)
+
+	// then
+	assert.That(firstErr == nil, t.Fatalf, "unexpected error: %s", firstErr)
+	assert.That(secondErr == nil, t.Fatalf, "unexpected error: %s", secondErr)
+	assert.That(computed == 1, t.Errorf, "value computed %d times, want once", computed)
+	assert.That(first.String() == "cached value", t.Errorf, "got %q first", first.String())
+	assert.That(second.String() == "cached value", t.Errorf, "got %q second", second.String())
+}
+
+func TestGetDistinguishesKeys(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	c := cache.At(dir)
+	other := cache.Key{Type: _StringKey.Type, Properties: cache.Properties{"name": "other"}}
+
+	err := c.Get(_StringKey, constant("first"), ioutil.Discard)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	out := new(strings.Builder)
+	err = c.Get(other, constant("second"), out)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(out.String() == "second", t.Errorf, "got %q, want %q", out.String(), "second")
+}
+
+func TestGetDoesNotStoreFailures(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	c := cache.At(dir)
+	failure := errors.New("failure")
+
+	// when
+	err := c.Get(_StringKey, func() (io.Reader, error) { return nil, failure }, ioutil.Discard)
+
+	// then
+	assert.That(oops.Cause(err) == failure, t.Errorf, "got error %v, want %v", err, failure)
+	out := new(strings.Builder)
+	err = c.Get(_StringKey, constant("recomputed"), out)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(out.String() == "recomputed", t.Errorf, "got %q, want %q", out.String(), "recomputed")
+}
+
+func constant(value string) func() (io.Reader, error) {
+	return func() (io.Reader, error) { return strings.NewReader(value), nil }
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cache")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return dir
+}
@@ -0,0 +1,217 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cargo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild/toml"
+)
+
+const (
+	_Manifest = "Cargo.toml"
+	_Lockfile = "Cargo.lock"
+)
+
+var ErrNotCargo = errors.New("no " + _Manifest + " found")
+
+// A Manifest is what is declared in a Cargo.toml file.
+type Manifest struct {
+	// Dir the Cargo.toml is in.
+	Dir string `json:"-"`
+
+	// Package is nil for the virtual manifest of a workspace.
+	Package   *Package   `json:"package"`
+	Workspace *Workspace `json:"workspace"`
+
+	Dependencies      Dependencies `json:"dependencies"`
+	DevDependencies   Dependencies `json:"dev-dependencies"`
+	BuildDependencies Dependencies `json:"build-dependencies"`
+	// Target holds the dependencies only needed on some platforms, keyed by the platform.
+	Target map[string]Target `json:"target"`
+}
+
+// A Target holds the dependencies a crate only has on one platform.
+type Target struct {
+	Dependencies      Dependencies `json:"dependencies"`
+	DevDependencies   Dependencies `json:"dev-dependencies"`
+	BuildDependencies Dependencies `json:"build-dependencies"`
+}
+
+// A Package is the crate a manifest describes.
+type Package struct {
+	Name    string    `json:"name"`
+	Version Inherited `json:"version"`
+	Publish Publish   `json:"publish"`
+}
+
+// A Workspace groups the crates in its member directories.
+type Workspace struct {
+	Members []string `json:"members"`
+	Exclude []string `json:"exclude"`
+	// Package holds the values members can inherit with key.workspace = true.
+	Package struct {
+		Version string `json:"version"`
+	} `json:"package"`
+	Dependencies Dependencies `json:"dependencies"`
+}
+
+// Inherited is a string value that may instead be inherited from the workspace.
+type Inherited struct {
+	Value     string
+	Workspace bool
+}
+
+func (in *Inherited) UnmarshalJSON(raw []byte) error {
+	if err := json.Unmarshal(raw, &in.Value); err == nil {
+		return nil
+	}
+	var table struct {
+		Workspace bool `json:"workspace"`
+	}
+	err := json.Unmarshal(raw, &table)
+	in.Workspace = table.Workspace
+	return err
+}
+
+// Publish says where a crate may be published: anywhere (the default), nowhere, or to some registries only.
+type Publish struct {
+	Disabled   bool
+	Registries []string
+}
+
+func (p *Publish) UnmarshalJSON(raw []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(raw, &allowed); err == nil {
+		p.Disabled = !allowed
+		return nil
+	}
+	err := json.Unmarshal(raw, &p.Registries)
+	p.Disabled = err == nil && len(p.Registries) == 0
+	return err
+}
+
+// Dependencies of a crate, keyed by the name the crate refers to them with.
+type Dependencies map[string]Dependency
+
+// Names of the dependencies, sorted.
+func (deps Dependencies) Names() []string {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A Dependency of a crate, either on a registry or through a path or git repository.
+// It may be declared with just a version requirement, or with a table.
+type Dependency struct {
+	Version  string `json:"version"`
+	Registry string `json:"registry"`
+	// Package is the name of the crate depended on, when it is renamed.
+	Package   string `json:"package"`
+	Path      string `json:"path"`
+	Git       string `json:"git"`
+	Optional  bool   `json:"optional"`
+	Workspace bool   `json:"workspace"`
+}
+
+func (dep *Dependency) UnmarshalJSON(raw []byte) error {
+	if err := json.Unmarshal(raw, &dep.Version); err == nil {
+		return nil
+	}
+	type table Dependency
+	return json.Unmarshal(raw, (*table)(dep))
+}
+
+// Crate is the name of the crate depended on, whether it is renamed or not.
+func (dep Dependency) Crate(key string) string {
+	if dep.Package != "" {
+		return dep.Package
+	}
+	return key
+}
+
+// ReadManifest reads the Cargo.toml in dir.
+func ReadManifest(dir string) (Manifest, error) {
+	path := filepath.Join(dir, _Manifest)
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Manifest{}, oops.Wrapf(ErrNotCargo, "in %s", dir)
+	}
+	if err != nil {
+		return Manifest{}, oops.Wrapf(err, "cannot read %s", path)
+	}
+	m := Manifest{Dir: dir}
+	err = toml.Unmarshal(raw, &m)
+	return m, oops.Wrapf(err, "cannot parse %s", path)
+}
+
+// ReadWorkspace reads the manifest in dir along with those of its workspace members.
+// The root manifest comes first, then the members sorted by directory.
+func ReadWorkspace(dir string) ([]Manifest, error) {
+	root, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if root.Workspace == nil {
+		return []Manifest{root}, nil
+	}
+
+	dirs, err := memberDirs(dir, root.Workspace.Members, root.Workspace.Exclude)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot find the workspace members in %s", dir)
+	}
+	manifests := []Manifest{root}
+	for _, d := range dirs {
+		m, err := ReadManifest(d)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+func memberDirs(root string, members, exclude []string) ([]string, error) {
+	excluded := map[string]bool{}
+	for _, pattern := range exclude {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			excluded[m] = true
+		}
+	}
+
+	seen := map[string]bool{filepath.Clean(root): true}
+	var dirs []string
+	for _, pattern := range members {
+		pattern = strings.TrimSuffix(pattern, "/")
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, _Manifest)); err != nil || excluded[m] || seen[m] {
+				continue
+			}
+			seen[m] = true
+			dirs = append(dirs, m)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
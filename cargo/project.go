@@ -0,0 +1,297 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package cargo lets unibuild build Rust crates, including cargo workspaces, that get published to a registry.
+//
+// The crates are analyzed by reading their Cargo.toml files. Dependencies on crates of the same workspace, or through
+// a path or git repository, are not requirements; the rest are, with their version requirements kept.
+package cargo
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+// A Config controls how cargo projects are built.
+type Config struct {
+	// Registry crates get published to, unless they only allow a single other one. Empty means crates.io.
+	Registry string `json:"registry,omitempty"`
+	// Offline builds and tests without touching the network.
+	Offline bool `json:"offline,omitempty"`
+	// Commands run one after another by the shell, in the root of the repository, instead of the default ones.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// A Crate of a project.
+type Crate struct {
+	Name    string
+	Version string
+	// Registry it gets published to, or nothing when it does not get published.
+	Registry  string
+	Published bool
+}
+
+// A Project that is built using cargo.
+type Project struct {
+	name     string
+	version  string
+	clone    repo.Local
+	commands []string
+	crates   []Crate
+	uses     []unibuild.Requirement
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
+
+// NewProject attempts to create a cargo project given a locally cloned repository.
+func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds cargo projects.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("cargo", 25, ErrNotCargo, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create a cargo project given a locally cloned repository.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	manifests, err := ReadWorkspace(clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem reading cargo manifests in %s", clone.Path)
+	}
+
+	crates := cfg.findCrates(manifests)
+	uses := findUses(manifests)
+	crates = publishOrder(crates, manifests)
+	commands := cfg.Commands
+	if len(commands) == 0 {
+		_, err := os.Stat(filepath.Join(clone.Path, _Lockfile))
+		commands = cfg.defaultCommands(crates, err == nil)
+	}
+	prj := Project{
+		name:     clone.Name,
+		version:  findVersion(crates),
+		clone:    clone,
+		commands: commands,
+		crates:   crates,
+		uses:     uses,
+	}
+	return prj, nil
+}
+
+// defaultCommands build and test the whole workspace, then publish the crates that allow it, one by one.
+// When the repository has a lockfile, cargo is held to it.
+func (cfg Config) defaultCommands(crates []Crate, locked bool) []string {
+	flags := ""
+	if locked {
+		flags = " --locked"
+	}
+	if cfg.Offline {
+		flags += " --offline"
+	}
+	cmds := []string{
+		"cargo build --workspace" + flags,
+		"cargo test --workspace" + flags,
+	}
+	for _, c := range crates {
+		if !c.Published {
+			continue
+		}
+		publish := "cargo publish -p " + c.Name
+		if locked {
+			publish = "cargo publish --locked -p " + c.Name
+		}
+		if c.Registry != "" {
+			publish += " --registry " + c.Registry
+		}
+		cmds = append(cmds, publish)
+	}
+	return cmds
+}
+
+// findCrates lists the crates of the manifests, with their versions inherited from the workspace as needed.
+func (cfg Config) findCrates(manifests []Manifest) []Crate {
+	wsVersion := ""
+	if ws := manifests[0].Workspace; ws != nil {
+		wsVersion = ws.Package.Version
+	}
+	var crates []Crate
+	for _, m := range manifests {
+		if m.Package == nil {
+			continue
+		}
+		c := Crate{Name: m.Package.Name, Version: m.Package.Version.Value}
+		if m.Package.Version.Workspace {
+			c.Version = wsVersion
+		}
+		c.Registry, c.Published = cfg.publishTo(m.Package.Publish)
+		crates = append(crates, c)
+	}
+	return crates
+}
+
+// publishTo decides which registry a crate gets published to, if any.
+func (cfg Config) publishTo(p Publish) (string, bool) {
+	switch {
+	case p.Disabled:
+		return "", false
+	case len(p.Registries) == 0:
+		return cfg.Registry, true
+	case len(p.Registries) == 1:
+		return p.Registries[0], true
+	}
+	for _, r := range p.Registries {
+		if r == cfg.Registry {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+// findVersion takes the version of the first crate with one.
+func findVersion(crates []Crate) string {
+	for _, c := range crates {
+		if c.Version != "" {
+			return c.Version
+		}
+	}
+	return ""
+}
+
+// findUses collects the dependencies of all the crates, except for those on crates of the workspace.
+// A crate required more than once keeps the requirement of the strongest kind.
+func findUses(manifests []Manifest) []unibuild.Requirement {
+	own := map[string]bool{}
+	for _, m := range manifests {
+		if m.Package != nil {
+			own[m.Package.Name] = true
+		}
+	}
+	var inherited Dependencies
+	if ws := manifests[0].Workspace; ws != nil {
+		inherited = ws.Dependencies
+	}
+
+	var reqs []unibuild.Requirement
+	add := func(deps Dependencies, kind unibuild.RequirementKind) {
+		for _, key := range deps.Names() {
+			dep := deps[key]
+			if dep.Workspace {
+				optional := dep.Optional
+				dep = inherited[key]
+				dep.Optional = dep.Optional || optional
+			}
+			req, ok := NewRequirement(key, dep, kind)
+			if ok && !own[req.ID().Name] {
+				reqs = append(reqs, req)
+			}
+		}
+	}
+
+	for _, m := range manifests {
+		add(m.Dependencies, unibuild.Compile)
+		add(m.BuildDependencies, unibuild.Compile)
+		add(m.DevDependencies, unibuild.Test)
+		for _, platform := range sortedPlatforms(m.Target) {
+			target := m.Target[platform]
+			add(target.Dependencies, unibuild.Compile)
+			add(target.BuildDependencies, unibuild.Compile)
+			add(target.DevDependencies, unibuild.Test)
+		}
+	}
+	return unibuild.MergeRequirements(reqs)
+}
+
+// publishOrder puts crates after the workspace crates they depend on, as cargo publish needs those on the registry.
+func publishOrder(crates []Crate, manifests []Manifest) []Crate {
+	deps := map[string][]string{}
+	for _, m := range manifests {
+		if m.Package == nil {
+			continue
+		}
+		for _, group := range []Dependencies{m.Dependencies, m.BuildDependencies} {
+			for _, key := range group.Names() {
+				deps[m.Package.Name] = append(deps[m.Package.Name], group[key].Crate(key))
+			}
+		}
+	}
+
+	placed := map[string]bool{}
+	ordered := make([]Crate, 0, len(crates))
+	var place func(c Crate, visiting map[string]bool)
+	place = func(c Crate, visiting map[string]bool) {
+		if placed[c.Name] || visiting[c.Name] {
+			return
+		}
+		visiting[c.Name] = true
+		for _, dep := range deps[c.Name] {
+			for _, other := range crates {
+				if other.Name == dep {
+					place(other, visiting)
+				}
+			}
+		}
+		placed[c.Name] = true
+		ordered = append(ordered, c)
+	}
+	for _, c := range crates {
+		place(c, map[string]bool{})
+	}
+	return ordered
+}
+
+func sortedPlatforms(targets map[string]Target) []string {
+	platforms := make([]string, 0, len(targets))
+	for platform := range targets {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{
+		Name:    prj.name,
+		Version: prj.version,
+	}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion {
+	var builds []unibuild.RequirementVersion
+	for _, c := range prj.crates {
+		if c.Published {
+			builds = append(builds, unibuild.RequirementVersion{ID: CrateID(c.Name), Version: c.Version})
+		}
+	}
+	return builds
+}
+
+// Crates of the project, in the order they get published in.
+func (prj Project) Crates() []Crate { return prj.crates }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Plan shows the commands the project is built with.
+func (prj Project) Plan() string { return strings.Join(prj.commands, " && ") }
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	return prj.clone.RunShell(ctx, logTo, prj.commands...)
+}
@@ -0,0 +1,193 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cargo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/cargo"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestWorkspaceBuildsThePublishedCrates(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "engine"}, Path: "testdata/engine"}
+
+	// when
+	prj, err := cargo.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Info().Version == "0.9.0", t.Errorf, "got version %q", prj.Info().Version)
+
+	want := []unibuild.RequirementVersion{
+		{ID: cargo.CrateID("engine-core"), Version: "0.9.0"},
+		{ID: cargo.CrateID("engine-codec"), Version: "0.9.0"},
+	}
+	builds := prj.Builds()
+	assert.That(len(builds) == len(want), t.Fatalf, "got builds %v, want %v", builds, want)
+	for i := range want {
+		assert.That(builds[i] == want[i], t.Errorf, "build #%d: got %v, want %v", i, builds[i], want[i])
+	}
+}
+
+func TestWorkspaceUsesTheCratesFromRegistries(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "engine"}, Path: "testdata/engine"}
+
+	// when
+	prj, err := cargo.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := map[string]unibuild.RequirementKind{
+		"acme-simd":            unibuild.Compile,
+		"acme-simd-intrinsics": unibuild.Compile,
+		"serde":                unibuild.Compile,
+		"criterion":            unibuild.Test,
+		"libc":                 unibuild.Compile,
+	}
+	assert.That(len(prj.Uses()) == len(want), t.Errorf, "got %d requirements, want %d", len(prj.Uses()), len(want))
+	for _, req := range prj.Uses() {
+		kind, known := want[req.ID().Name]
+		assert.That(known, t.Errorf, "unexpected requirement %s", req.ID())
+		got := unibuild.KindOf(req)
+		assert.That(got == kind, t.Errorf, "%s: got kind %s, want %s", req.ID(), got, kind)
+	}
+}
+
+func TestInheritedAndRenamedDependenciesKeepTheirDetails(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "engine"}, Path: "testdata/engine"}
+
+	// when
+	prj, err := cargo.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	reqs := map[string]cargo.Requirement{}
+	for _, req := range prj.Uses() {
+		reqs[req.ID().Name] = req.(cargo.Requirement)
+	}
+	for _, tt := range []struct {
+		crate, registry, version string
+		accepts                  bool
+	}{
+		{"acme-simd", "acme", "0.3.7", true},
+		{"acme-simd", "acme", "0.4.0", false},
+		{"acme-simd-intrinsics", "acme", "0.2.0", false},
+		{"acme-simd-intrinsics", "acme", "0.2.4", true},
+		{"serde", "", "1.0.190", true},
+	} {
+		req := reqs[tt.crate]
+		assert.That(req.Registry() == tt.registry, t.Errorf, "%s: got registry %q, want %q", tt.crate, req.Registry(), tt.registry)
+		got := req.Accepts(tt.version)
+		assert.That(got == tt.accepts, t.Errorf, "%s accepts %s: got %v, want %v", tt.crate, tt.version, got, tt.accepts)
+	}
+}
+
+func TestOptionalDependenciesOnlyWeakenTheKind(t *testing.T) {
+	for _, tt := range []struct {
+		kind, want unibuild.RequirementKind
+	}{
+		{unibuild.Compile, unibuild.Optional},
+		{unibuild.Test, unibuild.Test},
+	} {
+		// when
+		req, ok := cargo.NewRequirement("serde", cargo.Dependency{Version: "1.0", Optional: true}, tt.kind)
+
+		// then
+		assert.That(ok, t.Fatalf, "no requirement made")
+		assert.That(req.Kind() == tt.want, t.Errorf, "optional %s dependency: got kind %s, want %s", tt.kind, req.Kind(), tt.want)
+	}
+}
+
+func TestWorkspacePublishesCratesAfterTheirDependencies(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "engine"}, Path: "testdata/engine"}
+
+	// when
+	prj, err := cargo.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "cargo build --workspace --locked && cargo test --workspace --locked && " +
+		"cargo publish --locked -p engine-core --registry acme && cargo publish --locked -p engine-codec --registry acme"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan\n%s\nwant\n%s", prj.Plan(), want)
+}
+
+func TestSingleCrateIsPublishedToTheConfiguredRegistry(t *testing.T) {
+	// given
+	cfg := cargo.Config{Registry: "acme", Offline: true}
+	clone := repo.Local{Remote: repo.Remote{Name: "hash"}, Path: "testdata/single"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "cargo build --workspace --locked --offline && cargo test --workspace --locked --offline && " +
+		"cargo publish --locked -p acme-hash --registry acme"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan\n%s\nwant\n%s", prj.Plan(), want)
+	assert.That(len(prj.Uses()) == 2, t.Errorf, "got uses %v, want acme-simd and cc", prj.Uses())
+}
+
+func TestVersionRequirementsFollowCargo(t *testing.T) {
+	for _, tt := range []struct {
+		req, version string
+		want         bool
+	}{
+		{"1.2", "1.9.0", true},
+		{"1.2", "2.0.0", false},
+		{"0.2.1", "0.2.9", true},
+		{"0.2.1", "0.3.0", false},
+		{"=1.2.3", "1.2.4", false},
+		{">=1.2, <1.5", "1.4.9", true},
+		{">=1.2, <1.5", "1.5.0", false},
+		{"~1.2", "1.2.8", true},
+		{"*", "3.1.4", true},
+	} {
+		// given
+		rng, err := cargo.ParseVersionReq(tt.req)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.req, err)
+
+		// when
+		req, _ := cargo.NewRequirement("x", cargo.Dependency{Version: tt.req}, unibuild.Compile)
+		got := req.Accepts(tt.version)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "%q (%v) accepts %s: got %v, want %v", tt.req, rng, tt.version, got, tt.want)
+	}
+}
+
+func TestDetectorDeclinesRepositoriesWithoutManifests(t *testing.T) {
+	// given
+	detector := cargo.Config{}.Detector()
+
+	// when
+	_, err := detector.Detect(context.Background(), repo.Local{Path: "testdata"})
+
+	// then
+	assert.That(oops.Cause(err) == unibuild.ErrDeclined, t.Errorf, "got error %v, want %v", err, unibuild.ErrDeclined)
+}
+
+func TestCratesWithoutALockfileAreNotBuiltLocked(t *testing.T) {
+	// given
+	cfg := cargo.Config{Registry: "acme"}
+	clone := repo.Local{Remote: repo.Remote{Name: "base32"}, Path: "testdata/unlocked"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "cargo build --workspace && cargo test --workspace && cargo publish -p acme-base32 --registry acme"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan\n%s\nwant\n%s", prj.Plan(), want)
+}
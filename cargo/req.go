@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cargo
+
+import (
+	"strings"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/semver"
+)
+
+// Ecosystem of the requirements of cargo projects: crates, whichever registry they are published to.
+const Ecosystem = "cargo"
+
+// CrateID identifies a crate as a requirement, irrespective of its version.
+func CrateID(name string) unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: name}
+}
+
+// A Requirement on a crate from a registry, in the versions a version requirement allows.
+type Requirement struct {
+	id       unibuild.RequirementIdentity
+	kind     unibuild.RequirementKind
+	registry string
+	rng      *semver.Range
+}
+
+var _ interface {
+	unibuild.KindedRequirement
+	unibuild.ConstrainedRequirement
+} = Requirement{}
+
+// NewRequirement creates a requirement out of a dependency declared under the given key.
+// Dependencies through a path or a git repository do not make requirements, since they are not taken from a registry.
+// Optional dependencies make optional requirements, unless they are only needed for tests anyway.
+func NewRequirement(key string, dep Dependency, kind unibuild.RequirementKind) (Requirement, bool) {
+	if dep.Path != "" || dep.Git != "" {
+		return Requirement{}, false
+	}
+	if dep.Optional {
+		kind = kind.Weaker(unibuild.Optional)
+	}
+	req := Requirement{id: CrateID(dep.Crate(key)), kind: kind, registry: dep.Registry}
+	if rng, err := ParseVersionReq(dep.Version); err == nil {
+		req.rng = &rng
+	}
+	return req, true
+}
+
+// ParseVersionReq parses a cargo version requirement, like 1.2 or >=1.2, <1.5.
+// Cargo uses the same semver as npm, except that the comparators are separated by commas and a bare version is a caret one.
+func ParseVersionReq(s string) (semver.Range, error) {
+	var terms []string
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term != "" && strings.IndexAny(term[:1], "0123456789") == 0 {
+			term = "^" + term
+		}
+		terms = append(terms, strings.ReplaceAll(term, " ", ""))
+	}
+	return semver.ParseRange(strings.Join(terms, " "))
+}
+
+func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return req.kind }
+
+// Registry the crate is taken from; empty for crates.io.
+func (req Requirement) Registry() string { return req.registry }
+
+// Accepts tells whether the version meets the version requirement.
+// A requirement that cannot be parsed accepts any version.
+func (req Requirement) Accepts(version string) bool {
+	if req.rng == nil {
+		return true
+	}
+	v, err := semver.ParseVersion(version)
+	return err == nil && req.rng.Contains(v)
+}
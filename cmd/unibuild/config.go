@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild/cargo"
+	"github.com/szabba/unibuild/docker"
+	"github.com/szabba/unibuild/gomod"
+	"github.com/szabba/unibuild/gradle"
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/npm"
+	"github.com/szabba/unibuild/python"
+)
+
+// A Config holds the settings read from the file passed with -config.
+type Config struct {
+	Maven  maven.Config  `json:"maven"`
+	Gradle gradle.Config `json:"gradle"`
+	NPM    npm.Config    `json:"npm"`
+	Python python.Config `json:"python"`
+	Go     gomod.Config  `json:"go"`
+	Cargo  cargo.Config  `json:"cargo"`
+	Docker docker.Config `json:"docker"`
+}
+
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, oops.Wrapf(err, "cannot read config file %s", path)
+	}
+	err = json.Unmarshal(raw, &cfg)
+	return cfg, oops.Wrapf(err, "cannot parse config file %s", path)
+}
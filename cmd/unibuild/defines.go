@@ -0,0 +1,42 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"sort"
+	"strings"
+)
+
+var ErrNotADefinition = errors.New("definitions must have the form key=value")
+
+// Defines collects key=value definitions from a repeated flag.
+type Defines struct {
+	defs map[string]string
+}
+
+var _ flag.Value = new(Defines)
+
+func (ds *Defines) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return ErrNotADefinition
+	}
+	if ds.defs == nil {
+		ds.defs = map[string]string{}
+	}
+	ds.defs[parts[0]] = parts[1]
+	return nil
+}
+
+func (ds *Defines) String() string {
+	defs := make([]string, 0, len(ds.defs))
+	for k, v := range ds.defs {
+		defs = append(defs, k+"="+v)
+	}
+	sort.Strings(defs)
+	return strings.Join(defs, ",")
+}
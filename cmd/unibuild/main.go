@@ -8,19 +8,31 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/samsarahq/go/oops"
 	gitlab "github.com/xanzy/go-gitlab"
 
 	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/analysis"
 	"github.com/szabba/unibuild/binhash"
+	"github.com/szabba/unibuild/buildlog"
+	"github.com/szabba/unibuild/cache"
 	"github.com/szabba/unibuild/filterparser"
+	"github.com/szabba/unibuild/junit"
+	"github.com/szabba/unibuild/manifest"
 	"github.com/szabba/unibuild/maven"
 	"github.com/szabba/unibuild/prefixio"
+	"github.com/szabba/unibuild/provenance"
+	"github.com/szabba/unibuild/release"
 	"github.com/szabba/unibuild/repo"
+	"github.com/szabba/unibuild/rules"
 )
 
 const (
@@ -40,11 +52,28 @@ func main() {
 	flags := new(Flags)
 	flags.Parse()
 
+	if flags.cleanIsolated {
+		cleanIsolatedRepositories(flags.isolatedDir)
+		return
+	}
+
+	rec := provenance.NewRecorder(hash, provenance.Parameters{
+		BaseURL:  flags.baseURL,
+		Group:    flags.group,
+		Branches: flags.branches.list,
+		Filter:   flag.Args(),
+	})
+
 	repos, err := getRepos(flags.baseURL, flags.authToken, flags.group)
 	if err != nil {
 		log.Fatalf("problem getting repos: %s", err)
 	}
 
+	style := flags.outputStyle()
+	style.Width = repos.LongestName()
+	out := prefixio.NewStyledMux(os.Stdout, style)
+	repos = repos.WithOutput(out)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -53,10 +82,35 @@ func main() {
 		defer cancel()
 	}
 
+	recordToolchain(ctx, rec)
+
 	start := time.Now()
-	err = runBuild(ctx, repos, flags)
+	logs, err := buildlog.Create(flags.logDir, buildlog.NewRunID(start))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("logging project output to %s", logs.Dir())
+
+	report := new(junit.Report)
+	err = runBuild(ctx, repos, flags, rec, out, logs, report)
+	if flushErr := out.Flush(); flushErr != nil {
+		log.Printf("some of the output of the build was lost: %s", flushErr)
+	}
 	log.Printf("build took %s", time.Now().Sub(start))
 
+	if flags.provenance != "" && !flags.plan {
+		provErr := rec.WriteFile(flags.provenance)
+		if provErr != nil {
+			log.Printf("problem writing provenance: %s", provErr)
+		}
+	}
+	if flags.junit != "" && !flags.plan {
+		junitErr := report.WriteFile(flags.junit)
+		if junitErr != nil {
+			log.Printf("problem writing test results: %s", junitErr)
+		}
+	}
+
 	if err != nil {
 		log.Fatalf("build failed: %s", err)
 	}
@@ -64,12 +118,50 @@ func main() {
 }
 
 type Flags struct {
-	baseURL   string
-	timeout   time.Duration
-	branches  CommaList
-	authToken string
-	group     string
-	filters   []unibuild.Filter
+	baseURL      string
+	timeout      time.Duration
+	branches     CommaList
+	authToken    string
+	group        string
+	provenance   string
+	junit        string
+	timestamps   bool
+	elapsed      bool
+	color        string
+	logDir       string
+	tailLines    int
+	console      string
+	testDeps     string
+	optionalDeps string
+	configFile   string
+	plan         bool
+
+	mavenGoals    CommaList
+	mavenProfiles CommaList
+	mavenDefines  Defines
+	mavenThreads  string
+	mavenSettings string
+	jdks          Defines
+
+	isolate       bool
+	isolatedDir   string
+	seedRepo      string
+	cleanIsolated bool
+	offline       bool
+	cacheDir      string
+
+	analysisWorkers int
+	onlyDetectors   CommaList
+	skipDetectors   CommaList
+	goWorkspace     bool
+	rulesFile       string
+	graphFile       string
+
+	release     string
+	releasePart release.Part
+	push        bool
+
+	filters []unibuild.Filter
 }
 
 func (fs *Flags) Parse() {
@@ -78,10 +170,46 @@ func (fs *Flags) Parse() {
 	flag.StringVar(&fs.authToken, "auth-token", "", "gitlab API authentication token (required)")
 	flag.StringVar(&fs.group, "group", "", "gitlab group to clone repositories from (required)")
 	flag.Var(&fs.branches, "branches", "comma-separated list of branches to try checking out")
+	flag.StringVar(&fs.provenance, "provenance", "provenance.json", "file to write the build provenance to (disabled if empty)")
+	flag.StringVar(&fs.junit, "junit", "junit.xml", "file to write the test results of all projects to, in JUnit XML format (disabled if empty)")
 	fs.branches.Set("master")
+	flag.BoolVar(&fs.timestamps, "timestamps", false, "prefix output lines with the time they were written at")
+	flag.BoolVar(&fs.elapsed, "elapsed", false, "prefix output lines with the time elapsed since the project started")
+	flag.StringVar(&fs.color, "color", "auto", "colour project names in output: auto, always or never")
+	flag.StringVar(&fs.logDir, "log-dir", "logs", "directory to keep per-run project build logs in")
+	flag.IntVar(&fs.tailLines, "tail-lines", 30, "number of log lines to show when a project fails to build")
+	flag.StringVar(&fs.console, "console", "tail", "build output shown on the console: tail (condensed) or full")
+	flag.StringVar(&fs.testDeps, "test-deps", "hard", "whether test-only dependencies constrain the build order: hard or soft (only safe when tests are skipped)")
+	flag.StringVar(&fs.optionalDeps, "optional-deps", "hard", "whether optional dependencies constrain the build order: hard or soft (only safe when nothing compiles against them)")
+	flag.StringVar(&fs.configFile, "config", "", "JSON file with further settings, like per-project maven options")
+	flag.BoolVar(&fs.plan, "plan", false, "only show the build order and how each project would be built")
+	flag.StringVar(&fs.rulesFile, "rules", "", "TOML file with rules for the edges between projects no ecosystem can see (disabled if empty)")
+	flag.StringVar(&fs.graphFile, "graph", "", "file to write the dependency graph to, in Graphviz DOT format (disabled if empty)")
+	flag.Var(&fs.mavenGoals, "mvn-goals", "comma-separated list of maven goals to run (default clean,deploy)")
+	flag.Var(&fs.mavenProfiles, "mvn-profiles", "comma-separated list of maven profiles to activate")
+	flag.Var(&fs.mavenDefines, "mvn-define", "a key=value maven property definition (can be repeated)")
+	flag.StringVar(&fs.mavenThreads, "mvn-threads", "", "maven reactor parallelism, as for mvn -T")
+	flag.StringVar(&fs.mavenSettings, "mvn-settings", "", "alternative maven settings file, as for mvn -s")
+	flag.Var(&fs.jdks, "jdk", "a release=JAVA_HOME mapping used to build projects targeting that Java release (can be repeated)")
+	flag.BoolVar(&fs.isolate, "isolate", false, "give maven a local repository private to this run")
+	flag.StringVar(&fs.isolatedDir, "isolated-dir", "isolated-m2", "directory to keep the run-private local maven repositories in")
+	flag.StringVar(&fs.seedRepo, "seed-repo", "", "local maven repository to read through from an isolated one, like ~/.m2/repository (needs maven 3.9+)")
+	flag.BoolVar(&fs.cleanIsolated, "clean-isolated", false, "remove the run-private local maven repositories and exit")
+	flag.BoolVar(&fs.goWorkspace, "go-workspace", false, "build go modules against the modules of the other clones, through a temporary go.work")
+	flag.Var(&fs.onlyDetectors, "detectors", "comma-separated list of the only project detectors to use: maven, gradle, cargo, npm, python, go or docker (all by default)")
+	flag.Var(&fs.skipDetectors, "skip-detectors", "comma-separated list of project detectors not to use")
+	flag.IntVar(&fs.analysisWorkers, "analysis-workers", runtime.NumCPU(), "number of repositories analyzed at a time")
+	flag.StringVar(&fs.cacheDir, "cache-dir", "cache", "directory to cache project analysis results in between runs (disabled if empty)")
+	flag.BoolVar(&fs.offline, "offline", false, "run maven (analysis included), gradle, go and cargo offline, using only what is already cached locally")
+	flag.StringVar(&fs.release, "release", "", "release the selected projects, bumping their versions: current, patch, minor or major (disabled if empty)")
+	flag.BoolVar(&fs.push, "push", false, "push release commits and tags once all the projects are released")
 
 	flag.Parse()
 
+	if fs.cleanIsolated {
+		return
+	}
+
 	noAuthToken := fs.authToken == ""
 	noGroup := fs.group == ""
 
@@ -92,12 +220,79 @@ func (fs *Flags) Parse() {
 		fs.fail("a gitlab group needs to be specified")
 	}
 
+	if fs.color != "auto" && fs.color != "always" && fs.color != "never" {
+		fs.fail(fmt.Sprintf("invalid -color value %q", fs.color))
+	}
+	if fs.console != "tail" && fs.console != "full" {
+		fs.fail(fmt.Sprintf("invalid -console value %q", fs.console))
+	}
+	if fs.testDeps != "hard" && fs.testDeps != "soft" {
+		fs.fail(fmt.Sprintf("invalid -test-deps value %q", fs.testDeps))
+	}
+	if fs.optionalDeps != "hard" && fs.optionalDeps != "soft" {
+		fs.fail(fmt.Sprintf("invalid -optional-deps value %q", fs.optionalDeps))
+	}
+	if fs.release != "" {
+		part, err := release.ParsePart(fs.release)
+		if err != nil {
+			fs.fail(fmt.Sprintf("invalid -release value %q", fs.release))
+		}
+		fs.releasePart = part
+	}
+	if fs.push && fs.release == "" {
+		fs.fail("-push only makes sense together with -release")
+	}
+
 	err := fs.parseFilters()
 	if err != nil {
 		fs.fail(err.Error())
 	}
 }
 
+// mavenConfig combines the maven settings from the config file with the ones given as flags.
+// Flags take precedence, even over the configuration kept in the repositories.
+func (fs *Flags) mavenConfig(mvnCfg maven.Config, runID string) (maven.Config, error) {
+	var err error
+	mvnCfg.Overrides = mvnCfg.Overrides.Merge(maven.Invocation{
+		Goals:      fs.mavenGoals.list,
+		Profiles:   fs.mavenProfiles.list,
+		Properties: fs.mavenDefines.defs,
+		Threads:    fs.mavenThreads,
+		Settings:   fs.mavenSettings,
+	})
+	if len(fs.jdks.defs) > 0 && mvnCfg.JDKs == nil {
+		mvnCfg.JDKs = map[string]string{}
+	}
+	for javaRelease, home := range fs.jdks.defs {
+		mvnCfg.JDKs[javaRelease] = home
+	}
+	if fs.offline {
+		mvnCfg.Offline = true
+	}
+
+	if fs.isolate {
+		mvnCfg.LocalRepository, err = maven.IsolatedRepository(fs.isolatedDir, runID)
+		if err != nil {
+			return maven.Config{}, err
+		}
+		mvnCfg.SeedRepository, err = expandHome(fs.seedRepo)
+		if err != nil {
+			return maven.Config{}, err
+		}
+		log.Printf("using isolated local maven repository %s", mvnCfg.LocalRepository)
+	}
+	return mvnCfg, nil
+}
+
+func (fs *Flags) outputStyle() prefixio.Style {
+	color := fs.color == "always" || fs.color == "auto" && prefixio.ColorEnabled(os.Stdout)
+	return prefixio.Style{
+		Timestamp: fs.timestamps,
+		Elapsed:   fs.elapsed,
+		Color:     color,
+	}
+}
+
 func (fs *Flags) parseFilters() error {
 	builder := filterparser.NewBuilder()
 	filters, err := filterparser.Parse(builder, flag.Args()...)
@@ -115,42 +310,275 @@ func (fs *Flags) fail(message string) {
 	os.Exit(1)
 }
 
-func runBuild(ctx context.Context, repos *repo.Set, flags *Flags) error {
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", oops.Wrapf(err, "cannot expand %s", path)
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
+func cleanIsolatedRepositories(dir string) {
+	removed, err := maven.CleanIsolatedRepositories(dir)
+	for _, path := range removed {
+		log.Printf("removed %s", path)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func recordToolchain(ctx context.Context, rec *provenance.Recorder) {
+	rec.Toolchain("go", runtime.Version())
+
+	gitVersion, err := repo.GitVersion(ctx)
+	if err != nil {
+		log.Printf("problem recording toolchain: %s", err)
+	} else {
+		rec.Toolchain("git", gitVersion)
+	}
+
+	mvnVersion, err := maven.Version(ctx)
+	if err != nil {
+		log.Printf("problem recording toolchain: %s", err)
+	} else {
+		rec.Toolchain("mvn", mvnVersion)
+	}
+}
+
+func runBuild(
+	ctx context.Context, repos *repo.Set, flags *Flags,
+	rec *provenance.Recorder, out *prefixio.Mux, logs *buildlog.Run, report *junit.Report,
+) error {
 	clones, err := repo.SyncAll(ctx, repos, ".")
 	if err != nil {
 		return oops.Wrapf(err, "problem syncing repos")
 	}
 
 	err = clones.EachTry(func(l repo.Local) error {
-		return l.CheckoutFirst(ctx, flags.branches.list[0], flags.branches.list[1:]...)
+		ref, err := l.CheckoutFirst(ctx, flags.branches.list[0], flags.branches.list[1:]...)
+		if err != nil {
+			return err
+		}
+		commit, err := l.CurrentHash(ctx)
+		if err != nil {
+			return err
+		}
+		rec.Source(l.URL, ref, commit)
+		return nil
 	})
 	if err != nil {
 		return oops.Wrapf(err, "problem checking out appropriate branches")
 	}
 
-	prjs, err := analyzeProjects(ctx, clones)
+	cfg, err := LoadConfig(flags.configFile)
+	if err != nil {
+		return err
+	}
+	mvnCfg, err := flags.mavenConfig(cfg.Maven, logs.ID())
+	if err != nil {
+		return err
+	}
+	gradleCfg := cfg.Gradle
+	goCfg := cfg.Go
+	goCfg.Siblings = clones.Locals()
+	goCfg.Workspace = goCfg.Workspace || flags.goWorkspace
+	cargoCfg := cfg.Cargo
+	if flags.offline {
+		gradleCfg.Offline = true
+		goCfg.Offline = true
+		cargoCfg.Offline = true
+	}
+	if flags.cacheDir != "" {
+		mvnCfg.Cache = cache.At(flags.cacheDir)
+		mvnCfg.MavenVersion, err = maven.Version(ctx)
+		if err != nil {
+			log.Printf("analysis results get cached without knowing the maven version: %s", err)
+		}
+	}
+
+	reg, err := flags.registry(
+		mvnCfg.Detector(), gradleCfg.Detector(), cargoCfg.Detector(), cfg.NPM.Detector(), cfg.Python.Detector(),
+		goCfg.Detector(), cfg.Docker.Detector())
+	if err != nil {
+		return err
+	}
+	log.Printf("detecting projects with %s", detectorNames(reg.Enabled()))
+
+	analysisStart := time.Now()
+	prjs, err := analyzeProjects(ctx, clones, withManifest(reg.Detect), flags.analysisWorkers)
 	if err != nil {
 		return oops.Wrapf(err, "problem analyzing projects")
 	}
+	log.Printf("analysis took %s", time.Now().Sub(analysisStart))
 
 	ps := unibuild.NewProjectSuite(prjs...)
+	if flags.testDeps == "soft" {
+		ps.Soften(unibuild.Test)
+	}
+	if flags.optionalDeps == "soft" {
+		ps.Soften(unibuild.Optional)
+	}
+	if flags.rulesFile != "" {
+		rs, err := rules.Read(flags.rulesFile)
+		if err != nil {
+			return err
+		}
+		ps.AddRules(rs)
+	}
 	ordSuite, err := ps.ResolveOrder()
 	if err != nil {
 		return oops.Wrapf(err, "problem finding build order")
 	}
+	for _, e := range ordSuite.SoftEdges() {
+		log.Printf("soft edge ignored for build order: %s uses %s (%s)", e.From.Info().Name, e.To.Info().Name, edgeKind(e))
+	}
+	if flags.graphFile != "" {
+		err := writeGraph(flags.graphFile, ordSuite)
+		if err != nil {
+			return err
+		}
+	}
 
 	filterSuite := ordSuite.Filter(flags.filters...)
 
+	if flags.plan {
+		printPlan(filterSuite)
+		return nil
+	}
+
+	if flags.release != "" {
+		return releaseProjects(ctx, filterSuite.Order(), flags, rec, out, logs, report)
+	}
+
 	for _, p := range filterSuite.Order() {
-		err := p.Build(ctx, os.Stdout)
+		err := buildProject(ctx, p, flags, out, logs, report)
 		if err != nil {
 			return oops.Wrapf(err, "problem building project %s", p.Info().Name)
 		}
+		recordBuilt(rec, p)
 	}
 
 	return nil
 }
 
+// edgeKind describes the kind of an edge, telling manual edges apart.
+func edgeKind(e unibuild.Edge) string {
+	if e.Manual {
+		return "manual " + e.Kind.String()
+	}
+	return e.Kind.String()
+}
+
+func writeGraph(path string, suite unibuild.OrderedProjectSuite) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return oops.Wrapf(err, "cannot create graph file %s", path)
+	}
+	err = suite.WriteDOT(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return oops.Wrapf(err, "cannot write graph file %s", path)
+}
+
+func printPlan(suite unibuild.FilteredProjectSuite) {
+	for i, p := range suite.Order() {
+		info := p.Info()
+		fmt.Printf("%d. %s %s\n", i+1, info.Name, info.Version)
+		if planner, ok := p.(unibuild.Planner); ok {
+			fmt.Printf("   %s\n", planner.Plan())
+		}
+	}
+}
+
+const _CondenseInterval = 2 * time.Second
+
+// A testReporter is a project that knows the results of the tests run by its latest build.
+type testReporter interface {
+	TestResults() []junit.Suite
+}
+
+func buildProject(
+	ctx context.Context, p unibuild.Project, flags *Flags,
+	out *prefixio.Mux, logs *buildlog.Run, report *junit.Report,
+) error {
+	name := p.Info().Name
+
+	var console io.Writer = out.Start(name)
+	condenser := buildlog.Condense(console, _CondenseInterval)
+	if flags.console == "tail" {
+		console = condenser
+	}
+
+	plog, err := logs.Open(name, console, flags.tailLines)
+	if err != nil {
+		return err
+	}
+	defer plog.Close()
+
+	err = p.Build(ctx, plog)
+	condenser.Flush()
+	if tr, ok := p.(testReporter); ok {
+		report.Add(name, tr.TestResults()...)
+	}
+	if err != nil {
+		log.Printf("last lines of the %s build log:", name)
+		for _, line := range plog.Tail() {
+			log.Printf("  %s", line)
+		}
+		log.Printf("full %s build log at %s", name, plog.Path())
+	}
+	return err
+}
+
+func releaseProjects(
+	ctx context.Context, order []unibuild.Project, flags *Flags,
+	rec *provenance.Recorder, out *prefixio.Mux, logs *buildlog.Run, report *junit.Report,
+) error {
+	plan, err := release.NewPlan(order, flags.releasePart)
+	if err != nil {
+		return err
+	}
+	for _, step := range plan.Steps {
+		log.Printf("releasing %s %s as %s", step.Project.Info().Name, step.From, step.To)
+	}
+
+	return plan.Run(ctx, release.Options{
+		Push: flags.push,
+		Build: func(ctx context.Context, step release.Step) error {
+			err := buildProject(ctx, step.Project, flags, out, logs, report)
+			if err != nil {
+				return err
+			}
+			recordBuiltAs(rec, step.Project, step.To)
+			return nil
+		},
+	})
+}
+
+func recordBuilt(rec *provenance.Recorder, p unibuild.Project) {
+	recordBuiltAs(rec, p, p.Info().Version)
+}
+
+// recordBuiltAs records a project as built with the given version, which differs from the analyzed one when releasing.
+func recordBuiltAs(rec *provenance.Recorder, p unibuild.Project, version string) {
+	info := p.Info()
+	builds := p.Builds()
+	coords := make([]string, 0, len(builds))
+	for _, b := range builds {
+		coords = append(coords, b.ID.Name+":"+version)
+	}
+	step := provenance.Step{Project: info.Name, Version: version, Produces: coords}
+	if planner, ok := p.(unibuild.Planner); ok {
+		step.Command = planner.Plan()
+	}
+	rec.Built(step)
+}
+
 func getRepos(baseURL, authToken, name string) (*repo.Set, error) {
 	cli := gitlab.NewClient(nil, authToken)
 	err := cli.SetBaseURL(baseURL)
@@ -175,19 +603,74 @@ func getRepos(baseURL, authToken, name string) (*repo.Set, error) {
 	return repos, nil
 }
 
-func analyzeProjects(ctx context.Context, clones *repo.ClonedSet) ([]unibuild.Project, error) {
-	prjs := make([]unibuild.Project, 0, clones.Size())
-	err := clones.EachTry(func(cln repo.Local) error {
-		p, err := maven.NewProject(ctx, cln)
-		if err != nil {
-			log.Printf("problem analyzing project in repo at %s: %s", cln.Path, err)
-			return nil
-		}
-		prjs = append(prjs, p)
-		return nil
-	})
+// registry registers the detectors of the supported ecosystems, enabling the ones the flags ask for.
+func (fs *Flags) registry(detectors ...unibuild.Detector) (*unibuild.Registry, error) {
+	reg, err := unibuild.NewRegistry(detectors...)
 	if err != nil {
 		return nil, err
 	}
-	return prjs, nil
+	if len(fs.onlyDetectors.list) > 0 {
+		err = reg.Only(fs.onlyDetectors.list...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = reg.Disable(fs.skipDetectors.list...)
+	return reg, err
+}
+
+// withManifest applies the manifest of a repository, if it has one, to the project detected in it.
+// A manifest can make a project of a repository no detector claims.
+func withManifest(analyze analysis.Analyzer) analysis.Analyzer {
+	return func(ctx context.Context, cln repo.Local) (unibuild.Detection, error) {
+		det, err := analyze(ctx, cln)
+		if err != nil && oops.Cause(err) != unibuild.ErrUnrecognised {
+			return det, err
+		}
+
+		var detected unibuild.Project
+		switch len(det.Projects) {
+		case 0:
+		case 1:
+			detected = det.Projects[0]
+		default:
+			if _, mErr := manifest.Read(cln.Path); oops.Cause(mErr) == manifest.ErrNoManifest {
+				return det, err
+			}
+			return det, oops.Errorf("%s cannot apply to the %d projects the %s detector found", manifest.FileName, len(det.Projects), det.Detector)
+		}
+
+		mPrj, mErr := manifest.NewProject(ctx, cln, detected)
+		if oops.Cause(mErr) == manifest.ErrNoManifest {
+			return det, err
+		}
+		if mErr != nil {
+			return unibuild.Detection{}, mErr
+		}
+		claimedBy := "manifest"
+		if detected != nil {
+			claimedBy = det.Detector + "+manifest"
+		}
+		return unibuild.Detection{Detector: claimedBy, Projects: []unibuild.Project{mPrj}}, nil
+	}
+}
+
+func detectorNames(detectors []unibuild.Detector) string {
+	names := make([]string, 0, len(detectors))
+	for _, d := range detectors {
+		names = append(names, d.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// analyzeProjects finds the projects in the clones, analyzing several at a time.
+// Repositories that are not projects of any supported kind are skipped.
+// Any that are, but cannot be analyzed, make it fail, as leaving them out could produce a wrong build order.
+func analyzeProjects(ctx context.Context, clones *repo.ClonedSet, analyze analysis.Analyzer, workers int) ([]unibuild.Project, error) {
+	res := analysis.Analyze(ctx, clones.Locals(), analyze, analysis.Options{
+		Workers:      workers,
+		Unrecognised: func(err error) bool { return oops.Cause(err) == unibuild.ErrUnrecognised },
+	})
+	res.WriteSummary(log.Writer())
+	return res.Projects, res.Err()
 }
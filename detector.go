@@ -0,0 +1,204 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package unibuild
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild/repo"
+)
+
+var (
+	// ErrDeclined is what a detector fails with when a repository is not one it knows about.
+	ErrDeclined = errors.New("declined by detector")
+	// ErrUnrecognised means every detector declined a repository.
+	ErrUnrecognised        = errors.New("not a project of any supported kind")
+	ErrDuplicateDetector   = errors.New("detector already registered")
+	ErrUnknownDetector     = errors.New("no such detector")
+	ErrDetectorWithoutName = errors.New("detector has no name")
+)
+
+// A Detector finds the projects in cloned repositories of some kind.
+type Detector interface {
+	Name() string
+	// Priority decides which detectors get to look at a repository first: the higher, the earlier.
+	Priority() int
+	// Detect finds the projects in a clone.
+	// It declines a repository by failing with ErrDeclined, or by finding no projects.
+	Detect(ctx context.Context, clone repo.Local) ([]Project, error)
+}
+
+// A Detection is what the detector that claimed a repository found in it.
+type Detection struct {
+	Detector string
+	Projects []Project
+}
+
+// NewDetector makes a detector out of a function finding at most a single project.
+// The function declines by failing with an error whose cause is notFound.
+func NewDetector(
+	name string, priority int, notFound error,
+	find func(ctx context.Context, clone repo.Local) (Project, error),
+) Detector {
+	return funcDetector{name, priority, notFound, find}
+}
+
+type funcDetector struct {
+	name     string
+	priority int
+	notFound error
+	find     func(ctx context.Context, clone repo.Local) (Project, error)
+}
+
+func (d funcDetector) Name() string { return d.name }
+
+func (d funcDetector) Priority() int { return d.priority }
+
+func (d funcDetector) Detect(ctx context.Context, clone repo.Local) ([]Project, error) {
+	p, err := d.find(ctx, clone)
+	if oops.Cause(err) == d.notFound {
+		return nil, oops.Wrapf(ErrDeclined, "%s", firstLine(err.Error()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []Project{p}, nil
+}
+
+// A Registry of detectors, each of which can be enabled or disabled.
+// Detectors are enabled when registered.
+type Registry struct {
+	detectors []Detector
+	disabled  map[string]bool
+}
+
+// NewRegistry creates a registry with the given detectors.
+func NewRegistry(detectors ...Detector) (*Registry, error) {
+	reg := &Registry{disabled: map[string]bool{}}
+	for _, d := range detectors {
+		if err := reg.Register(d); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+// Register adds a detector, which must be named differently from the ones already there.
+func (reg *Registry) Register(d Detector) error {
+	if d.Name() == "" {
+		return ErrDetectorWithoutName
+	}
+	if reg.find(d.Name()) != nil {
+		return oops.Wrapf(ErrDuplicateDetector, "cannot register %s", d.Name())
+	}
+	reg.detectors = append(reg.detectors, d)
+	return nil
+}
+
+func (reg *Registry) find(name string) Detector {
+	for _, d := range reg.detectors {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// Names lists all the registered detectors, enabled or not, in the order they are tried in.
+func (reg *Registry) Names() []string {
+	names := make([]string, 0, len(reg.detectors))
+	for _, d := range reg.ordered() {
+		names = append(names, d.Name())
+	}
+	return names
+}
+
+// Enable enables the named detectors.
+func (reg *Registry) Enable(names ...string) error { return reg.setDisabled(false, names) }
+
+// Disable disables the named detectors.
+func (reg *Registry) Disable(names ...string) error { return reg.setDisabled(true, names) }
+
+// Only leaves just the named detectors enabled.
+func (reg *Registry) Only(names ...string) error {
+	if err := reg.Disable(reg.Names()...); err != nil {
+		return err
+	}
+	return reg.Enable(names...)
+}
+
+func (reg *Registry) setDisabled(disabled bool, names []string) error {
+	for _, name := range names {
+		if reg.find(name) == nil {
+			return oops.Wrapf(ErrUnknownDetector, "%q is not one of %s", name, strings.Join(reg.Names(), ", "))
+		}
+	}
+	for _, name := range names {
+		reg.disabled[name] = disabled
+	}
+	return nil
+}
+
+// Enabled lists the enabled detectors in the order they are tried in.
+func (reg *Registry) Enabled() []Detector {
+	var enabled []Detector
+	for _, d := range reg.ordered() {
+		if !reg.disabled[d.Name()] {
+			enabled = append(enabled, d)
+		}
+	}
+	return enabled
+}
+
+// ordered sorts the detectors by priority, keeping the ones with the same priority in registration order.
+func (reg *Registry) ordered() []Detector {
+	ordered := append([]Detector{}, reg.detectors...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority() > ordered[j].Priority() })
+	return ordered
+}
+
+// Detect tries the enabled detectors on a clone until one of them claims it.
+// When all of them decline, it fails with ErrUnrecognised, listing their reasons.
+func (reg *Registry) Detect(ctx context.Context, clone repo.Local) (Detection, error) {
+	var reasons []string
+	for _, d := range reg.Enabled() {
+		prjs, err := d.Detect(ctx, clone)
+		switch {
+		case oops.Cause(err) == ErrDeclined:
+			reasons = append(reasons, d.Name()+": "+declineReason(err))
+		case err != nil:
+			return Detection{Detector: d.Name()}, oops.Wrapf(err, "%s detector failed", d.Name())
+		case len(prjs) == 0:
+			reasons = append(reasons, d.Name()+": no projects found")
+		default:
+			return Detection{Detector: d.Name(), Projects: prjs}, nil
+		}
+	}
+	return Detection{}, oops.Wrapf(ErrUnrecognised, "%s", strings.Join(reasons, "; "))
+}
+
+// declineReason is the innermost explanation attached to a decline, without the stack trace.
+func declineReason(err error) string {
+	for _, stack := range oops.Frames(err) {
+		for _, frame := range stack {
+			if frame.Reason != "" {
+				return firstLine(frame.Reason)
+			}
+		}
+	}
+	return ErrDeclined.Error()
+}
+
+func firstLine(s string) string {
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+		return s[:nl]
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package unibuild_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+var errNotMine = errors.New("no build file found")
+
+// detector claims the repositories with the listed names.
+func detector(name string, priority int, claims ...string) unibuild.Detector {
+	return unibuild.NewDetector(name, priority, errNotMine, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		for _, c := range claims {
+			if c == clone.Name {
+				return &Project{Info_: unibuild.ProjectInfo{Name: name + "/" + clone.Name}}, nil
+			}
+		}
+		return nil, oops.Wrapf(errNotMine, "in %s", clone.Path)
+	})
+}
+
+func clone(name string) repo.Local { return repo.Local{Remote: repo.Remote{Name: name}, Path: name} }
+
+func TestRegistryTriesDetectorsByPriority(t *testing.T) {
+	// given
+	reg, err := unibuild.NewRegistry(detector("low", 1, "app"), detector("high", 2, "app"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	det, err := reg.Detect(context.Background(), clone("app"))
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(det.Detector == "high", t.Errorf, "got repository claimed by %s, want high", det.Detector)
+	assert.That(strings.Join(reg.Names(), ",") == "high,low", t.Errorf, "got detectors %q", reg.Names())
+}
+
+func TestRegistryMovesOnWhenADetectorDeclines(t *testing.T) {
+	// given
+	reg, err := unibuild.NewRegistry(detector("maven", 2, "lib"), detector("npm", 1, "app"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	det, err := reg.Detect(context.Background(), clone("app"))
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(det.Detector == "npm" && len(det.Projects) == 1, t.Errorf, "got detection %#v", det)
+}
+
+func TestRegistryReportsWhyNoDetectorClaimedARepository(t *testing.T) {
+	// given
+	reg, err := unibuild.NewRegistry(detector("maven", 2), detector("npm", 1))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	_, err = reg.Detect(context.Background(), clone("docs"))
+
+	// then
+	assert.That(oops.Cause(err) == unibuild.ErrUnrecognised, t.Fatalf, "got error %v, want %v", err, unibuild.ErrUnrecognised)
+	want := "maven: no build file found; npm: no build file found"
+	assert.That(strings.Contains(err.Error(), want), t.Errorf, "got error %q, want it to mention %q", err, want)
+}
+
+func TestRegistryStopsAtADetectorFailing(t *testing.T) {
+	// given
+	broken := unibuild.NewDetector("broken", 2, errNotMine, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return nil, oops.Errorf("bad build file")
+	})
+	reg, err := unibuild.NewRegistry(broken, detector("npm", 1, "app"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	det, err := reg.Detect(context.Background(), clone("app"))
+
+	// then
+	assert.That(err != nil && det.Detector == "broken", t.Errorf, "got detection %#v and error %v", det, err)
+}
+
+func TestDisabledDetectorsAreSkipped(t *testing.T) {
+	// given
+	reg, err := unibuild.NewRegistry(detector("maven", 3, "app"), detector("gradle", 2, "app"), detector("npm", 1, "app"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	onlyErr := reg.Only("gradle", "npm")
+	disableErr := reg.Disable("gradle")
+	det, err := reg.Detect(context.Background(), clone("app"))
+
+	// then
+	assert.That(onlyErr == nil && disableErr == nil, t.Fatalf, "unexpected errors: %v, %v", onlyErr, disableErr)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(det.Detector == "npm", t.Errorf, "got repository claimed by %s, want npm", det.Detector)
+	assert.That(len(reg.Enabled()) == 1, t.Errorf, "got %d detectors enabled, want 1", len(reg.Enabled()))
+}
+
+func TestRegistryRejectsUnknownAndDuplicateDetectors(t *testing.T) {
+	// given
+	reg, err := unibuild.NewRegistry(detector("maven", 1))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	disableErr := reg.Disable("ant")
+	registerErr := reg.Register(detector("maven", 2))
+
+	// then
+	assert.That(oops.Cause(disableErr) == unibuild.ErrUnknownDetector, t.Errorf, "got error %v, want %v", disableErr, unibuild.ErrUnknownDetector)
+	assert.That(oops.Cause(registerErr) == unibuild.ErrDuplicateDetector, t.Errorf, "got error %v, want %v", registerErr, unibuild.ErrDuplicateDetector)
+}
@@ -0,0 +1,261 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package docker
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+var ErrBadDockerfile = errors.New("malformed Dockerfile")
+
+// A Dockerfile, as far as the images it is built from go.
+type Dockerfile struct {
+	Stages []Stage
+}
+
+// A Stage of a (possibly multi-stage) build.
+type Stage struct {
+	// Name given to the stage with AS, if any.
+	Name string
+	// From is what the stage is built on: an image, an earlier stage or scratch.
+	// It is left empty when it depends on a build argument with no value.
+	From string
+	// CopiesFrom lists what files get copied from with COPY --from, which may also be images.
+	CopiesFrom []string
+}
+
+// Images lists the images the Dockerfile uses, whether by building on them or by copying files out of them.
+// Earlier stages, scratch and references that could not be resolved are left out.
+func (df Dockerfile) Images() []string {
+	var images []string
+	seen := map[string]bool{}
+	stages := map[string]bool{"scratch": true}
+	add := func(ref string) {
+		if ref == "" || stages[strings.ToLower(ref)] || seen[ref] {
+			return
+		}
+		if _, err := strconv.Atoi(ref); err == nil {
+			// COPY --from can refer to a stage by its index.
+			return
+		}
+		seen[ref] = true
+		images = append(images, ref)
+	}
+	for _, stage := range df.Stages {
+		add(stage.From)
+		for _, from := range stage.CopiesFrom {
+			add(from)
+		}
+		if stage.Name != "" {
+			stages[strings.ToLower(stage.Name)] = true
+		}
+	}
+	return images
+}
+
+// ParseDockerfile parses the FROM, ARG and COPY instructions of a Dockerfile.
+// Build arguments override the defaults of the ARG instructions.
+func ParseDockerfile(src string, buildArgs map[string]string) (Dockerfile, error) {
+	var df Dockerfile
+	global := map[string]string{}
+	var scope map[string]string
+	for _, inst := range instructions(src) {
+		keyword, rest := splitWord(inst)
+		switch strings.ToUpper(keyword) {
+		case "ARG":
+			target := scope
+			if target == nil {
+				target = global
+			}
+			for _, decl := range strings.Fields(rest) {
+				declareArg(target, global, buildArgs, decl)
+			}
+
+		case "FROM":
+			fields := fields(rest)
+			stage := Stage{}
+			if len(fields) == 0 {
+				return Dockerfile{}, oops.Wrapf(ErrBadDockerfile, "FROM without an image")
+			}
+			if len(fields) >= 3 && strings.EqualFold(fields[len(fields)-2], "AS") {
+				stage.Name = fields[len(fields)-1]
+			}
+			stage.From, _ = expand(fields[0], global)
+			df.Stages = append(df.Stages, stage)
+			scope = map[string]string{}
+
+		case "COPY":
+			if len(df.Stages) == 0 {
+				return Dockerfile{}, oops.Wrapf(ErrBadDockerfile, "COPY before FROM")
+			}
+			stage := &df.Stages[len(df.Stages)-1]
+			for _, f := range fields(rest) {
+				if !strings.HasPrefix(f, "--from=") {
+					continue
+				}
+				from, _ := expand(strings.TrimPrefix(f, "--from="), merged(global, scope))
+				stage.CopiesFrom = append(stage.CopiesFrom, from)
+			}
+		}
+	}
+	return df, nil
+}
+
+// declareArg handles an ARG declaration, which may have a default.
+// Within a stage, an argument declared without a default takes the value it had before the first FROM.
+func declareArg(target, global, buildArgs map[string]string, decl string) {
+	name, value := decl, ""
+	hasDefault := false
+	if eq := strings.IndexByte(decl, '='); eq >= 0 {
+		name, value, hasDefault = decl[:eq], unquote(decl[eq+1:]), true
+	}
+	switch v, given := buildArgs[name]; {
+	case given:
+		target[name] = v
+	case hasDefault:
+		target[name], _ = expand(value, merged(global, target))
+	default:
+		if v, ok := global[name]; ok {
+			target[name] = v
+		}
+	}
+}
+
+func merged(global, scope map[string]string) map[string]string {
+	all := make(map[string]string, len(global)+len(scope))
+	for k, v := range global {
+		all[k] = v
+	}
+	for k, v := range scope {
+		all[k] = v
+	}
+	return all
+}
+
+// expand substitutes the $VAR, ${VAR}, ${VAR:-default} and ${VAR:+alternative} forms with the values of arguments.
+// It yields the empty string, and false, when an argument without a value is needed.
+func expand(s string, args map[string]string) (string, bool) {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		var name, modifier, word string
+		if s[i+1] == '{' {
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", false
+			}
+			name = s[i+2 : i+end]
+			if sep := strings.IndexAny(name, ":-+"); sep >= 0 {
+				name, modifier = name[:sep], name[sep:]
+				if strings.HasPrefix(modifier, ":") && len(modifier) > 1 {
+					modifier, word = modifier[:2], modifier[2:]
+				} else {
+					modifier, word = modifier[:1], modifier[1:]
+				}
+			}
+			i += end
+		} else {
+			end := i + 1
+			for end < len(s) && (s[end] == '_' || isAlnum(s[end])) {
+				end++
+			}
+			name = s[i+1 : end]
+			i = end - 1
+		}
+
+		value, set := args[name]
+		switch modifier {
+		case ":-", "-":
+			if !set || (modifier == ":-" && value == "") {
+				value, set = word, true
+			}
+		case ":+", "+":
+			if set && (modifier == "+" || value != "") {
+				value = word
+			}
+			set = true
+		}
+		if !set {
+			return "", false
+		}
+		out.WriteString(value)
+	}
+	return out.String(), true
+}
+
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// instructions joins the continued lines of a Dockerfile and drops the comments.
+// The escape character can be changed with a parser directive at the top.
+func instructions(src string) []string {
+	escape := `\`
+	var insts []string
+	var current strings.Builder
+	directives := true
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			if directives {
+				directive := strings.ToLower(strings.Join(strings.Fields(trimmed[1:]), ""))
+				if strings.HasPrefix(directive, "escape=") && len(directive) == len("escape=")+1 {
+					escape = directive[len("escape="):]
+				}
+			}
+			continue
+		}
+		directives = false
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasSuffix(trimmed, escape) {
+			current.WriteString(strings.TrimSuffix(trimmed, escape))
+			current.WriteByte(' ')
+			continue
+		}
+		current.WriteString(trimmed)
+		insts = append(insts, current.String())
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		insts = append(insts, current.String())
+	}
+	return insts
+}
+
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if sp := strings.IndexAny(s, " \t"); sp >= 0 {
+		return s[:sp], strings.TrimSpace(s[sp+1:])
+	}
+	return s, ""
+}
+
+// fields splits the arguments of an instruction, dropping flags other than --from.
+func fields(s string) []string {
+	var out []string
+	for _, f := range strings.Fields(s) {
+		if strings.HasPrefix(f, "--") && !strings.HasPrefix(f, "--from=") {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
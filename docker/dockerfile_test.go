@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package docker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/docker"
+)
+
+func TestMultiStageBuildsUseTheImagesOfTheirStages(t *testing.T) {
+	// given
+	src := `
+FROM golang:1.21 AS build
+RUN go build -o /out/app .
+
+FROM build AS test
+RUN go test ./...
+
+FROM gcr.io/distroless/static
+COPY --from=build /out/app /app
+COPY --from=0 /etc/passwd /etc/passwd
+COPY --from=busybox:1.36 /bin/sh /bin/sh
+`
+
+	// when
+	df, err := docker.ParseDockerfile(src, nil)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(df.Stages) == 3, t.Errorf, "got %d stages, want 3", len(df.Stages))
+	assertImages(t, df, "golang:1.21", "gcr.io/distroless/static", "busybox:1.36")
+}
+
+func TestArgumentsAreSubstitutedInFromLines(t *testing.T) {
+	// given
+	src := `
+ARG REGISTRY=registry.acme.example
+ARG VARIANT
+ARG TAG=1.4
+FROM ${REGISTRY}/acme/base:$TAG
+FROM ${REGISTRY}/acme/runtime:${VARIANT:-slim}
+FROM ${REGISTRY}/acme/tools:${VARIANT}
+`
+
+	for _, tt := range []struct {
+		name      string
+		buildArgs map[string]string
+		want      []string
+	}{
+		{"defaults", nil, []string{"registry.acme.example/acme/base:1.4", "registry.acme.example/acme/runtime:slim"}},
+		{"build args", map[string]string{"VARIANT": "alpine", "TAG": "2.0"}, []string{
+			"registry.acme.example/acme/base:2.0",
+			"registry.acme.example/acme/runtime:alpine",
+			"registry.acme.example/acme/tools:alpine",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			df, err := docker.ParseDockerfile(src, tt.buildArgs)
+
+			// then
+			assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+			assertImages(t, df, tt.want...)
+		})
+	}
+}
+
+func TestContinuedLinesAndCommentsAreHandled(t *testing.T) {
+	// given
+	src := "# escape=`\n" +
+		"FROM `\n" +
+		"  # the tag gets bumped by renovate\n" +
+		"  mcr.microsoft.com/windows/servercore:ltsc2022 `\n" +
+		"  AS base\n" +
+		"FROM base\n"
+
+	// when
+	df, err := docker.ParseDockerfile(src, nil)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(df.Stages) == 2 && df.Stages[0].Name == "base", t.Errorf, "got stages %#v", df.Stages)
+	assertImages(t, df, "mcr.microsoft.com/windows/servercore:ltsc2022")
+}
+
+func assertImages(t *testing.T, df docker.Dockerfile, want ...string) {
+	t.Helper()
+	got := df.Images()
+	assert.That(strings.Join(got, " ") == strings.Join(want, " "), t.Errorf, "got images %q, want %q", got, want)
+}
+
+func TestReferencesAreNormalised(t *testing.T) {
+	for _, tt := range []struct{ ref, name, tag string }{
+		{"debian", "docker.io/library/debian", "latest"},
+		{"debian:bookworm-slim", "docker.io/library/debian", "bookworm-slim"},
+		{"acme/base:1.4", "docker.io/acme/base", "1.4"},
+		{"index.docker.io/acme/base", "docker.io/acme/base", "latest"},
+		{"Registry.Acme.Example/acme/base:1.4", "registry.acme.example/acme/base", "1.4"},
+		{"localhost:5000/base", "localhost:5000/base", "latest"},
+		{"localhost/base", "localhost/base", "latest"},
+		{"alpine@sha256:abc", "docker.io/library/alpine", ""},
+	} {
+		// when
+		ref := docker.ParseReference(tt.ref)
+
+		// then
+		assert.That(ref.Name == tt.name, t.Errorf, "%q: got name %q, want %q", tt.ref, ref.Name, tt.name)
+		assert.That(ref.Tag == tt.tag, t.Errorf, "%q: got tag %q, want %q", tt.ref, ref.Tag, tt.tag)
+	}
+}
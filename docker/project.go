@@ -0,0 +1,345 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package docker lets unibuild build repositories of docker images, like base images and the services built on them.
+//
+// The Dockerfiles in a repository are scanned for the images they are built from, in any stage and with the build
+// arguments substituted, and for the ones files are copied out of. A Dockerfile does not say what image it builds, so
+// that comes from the configuration: by default the image at the root of a repository is named after the repository,
+// and others get the name of their directory or Dockerfile variant appended.
+//
+// Most repositories with a Dockerfile also belong to some other ecosystem, whose detectors get to claim them first.
+package docker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+var ErrNotDocker = errors.New("no Dockerfile found")
+
+// DefaultCommands build each image and push it.
+var DefaultCommands = []string{
+	"docker build -f {dockerfile} -t {image}:{tag}{build-args} {context}",
+	"docker push {image}:{tag}",
+}
+
+// LocalCommands only build an image.
+// They replace the DefaultCommands for images with no Registry or Images entry saying where they go,
+// so that those never get pushed to Docker Hub.
+var LocalCommands = []string{
+	"docker build -f {dockerfile} -t {image}:{tag}{build-args} {context}",
+}
+
+// A Config controls how docker images are named and built.
+type Config struct {
+	// Registry (with the namespace) that images named after their repository get pushed to,
+	// like registry.acme.example/acme.
+	Registry string `json:"registry,omitempty"`
+	// Images names the images built from whole repositories, or from single Dockerfiles in them when keyed by
+	// repository/path/to/Dockerfile.
+	Images map[string]string `json:"images,omitempty"`
+	// Tag the images are built with, latest by default.
+	Tag       string            `json:"tag,omitempty"`
+	BuildArgs map[string]string `json:"buildArgs,omitempty"`
+	// Commands run for each image by the shell, in the root of the repository, instead of the DefaultCommands.
+	// The {image}, {tag}, {dockerfile}, {context} and {build-args} placeholders get replaced.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// An Image built from a Dockerfile.
+type Image struct {
+	// Dockerfile is the path to the Dockerfile, relative to the repository root and slash-separated.
+	Dockerfile string
+	Name       string
+	// Local images have nowhere configured to be pushed to.
+	Local bool
+	Uses  []Requirement
+}
+
+// A Project that builds docker images.
+type Project struct {
+	name     string
+	clone    repo.Local
+	tag      string
+	args     map[string]string
+	commands []string
+	// localCommands are run for the local images.
+	localCommands []string
+	images        []Image
+	uses          []unibuild.Requirement
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
+
+// NewProject attempts to create a docker project given a locally cloned repository.
+func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds docker projects.
+// It goes after the detectors of other ecosystems, since their repositories often hold a Dockerfile too.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("docker", 5, ErrNotDocker, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create a docker project given a locally cloned repository.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	paths, err := FindDockerfiles(clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem looking for Dockerfiles in %s", clone.Path)
+	}
+	if len(paths) == 0 {
+		return Project{}, oops.Wrapf(ErrNotDocker, "in %s", clone.Path)
+	}
+
+	var images []Image
+	for _, p := range paths {
+		raw, err := ioutil.ReadFile(filepath.Join(clone.Path, filepath.FromSlash(p)))
+		if err != nil {
+			return Project{}, oops.Wrapf(err, "cannot read %s in %s", p, clone.Path)
+		}
+		df, err := ParseDockerfile(string(raw), cfg.BuildArgs)
+		if err != nil {
+			return Project{}, oops.Wrapf(err, "cannot parse %s in %s", p, clone.Path)
+		}
+		img := Image{
+			Dockerfile: p,
+			Name:       ParseReference(cfg.imageName(clone.Name, p)).Name,
+			Local:      !cfg.hasDestination(clone.Name, p),
+		}
+		for _, ref := range df.Images() {
+			img.Uses = append(img.Uses, NewRequirement(ref))
+		}
+		images = append(images, img)
+	}
+
+	commands, localCommands := cfg.Commands, cfg.Commands
+	if len(commands) == 0 {
+		commands, localCommands = DefaultCommands, LocalCommands
+	}
+	tag := cfg.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	images = buildOrder(images)
+	prj := Project{
+		name:          clone.Name,
+		clone:         clone,
+		tag:           tag,
+		args:          cfg.BuildArgs,
+		commands:      commands,
+		localCommands: localCommands,
+		images:        images,
+		uses:          findUses(images),
+	}
+	return prj, nil
+}
+
+// imageName names the image built from a Dockerfile in a repository.
+func (cfg Config) imageName(repoName, dockerfile string) string {
+	if name, ok := cfg.Images[repoName+"/"+dockerfile]; ok {
+		return name
+	}
+	base, ok := cfg.Images[repoName]
+	if !ok {
+		base = strings.ToLower(repoName)
+		if cfg.Registry != "" {
+			base = strings.TrimSuffix(cfg.Registry, "/") + "/" + base
+		}
+	}
+	if variant := variantOf(dockerfile); variant != "" {
+		return base + "-" + variant
+	}
+	return base
+}
+
+// hasDestination tells whether the configuration says where the image built from a Dockerfile goes.
+func (cfg Config) hasDestination(repoName, dockerfile string) bool {
+	_, named := cfg.Images[repoName+"/"+dockerfile]
+	_, namedRepo := cfg.Images[repoName]
+	return cfg.Registry != "" || named || namedRepo
+}
+
+// variantOf tells apart the Dockerfiles of a repository by their directory and name.
+// The Dockerfile at the root has no variant.
+func variantOf(dockerfile string) string {
+	dir, file := path.Split(dockerfile)
+	var parts []string
+	if dir != "" {
+		parts = append(parts, strings.Split(strings.Trim(dir, "/"), "/")...)
+	}
+	switch {
+	case strings.HasPrefix(file, "Dockerfile."):
+		parts = append(parts, strings.TrimPrefix(file, "Dockerfile."))
+	case strings.HasSuffix(file, ".Dockerfile"):
+		parts = append(parts, strings.TrimSuffix(file, ".Dockerfile"))
+	}
+	return strings.ToLower(strings.Join(parts, "-"))
+}
+
+// FindDockerfiles lists the Dockerfiles within dir, as slash-separated relative paths.
+// Those named Dockerfile, Dockerfile.variant or variant.Dockerfile count. Hidden and vendored directories, as well as
+// test data, are skipped.
+func FindDockerfiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if p != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "vendor" || name == "node_modules" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".Dockerfile") {
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+// buildOrder puts images before the ones built from them, keeping the order otherwise.
+func buildOrder(images []Image) []Image {
+	built := map[string]bool{}
+	ordered := make([]Image, 0, len(images))
+	waits := func(img Image) bool {
+		for _, req := range img.Uses {
+			for _, other := range images {
+				if other.Name == req.ref.Name && other.Name != img.Name && !built[other.Name] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for len(ordered) < len(images) {
+		progress := false
+		for _, img := range images {
+			if built[img.Name] || waits(img) {
+				continue
+			}
+			ordered, built[img.Name], progress = append(ordered, img), true, true
+		}
+		if !progress {
+			// The images depend on each other in a cycle, which docker cannot build anyway.
+			for _, img := range images {
+				if !built[img.Name] {
+					ordered, built[img.Name] = append(ordered, img), true
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// findUses lists the images used by any of the images, except for the ones the project builds itself.
+func findUses(images []Image) []unibuild.Requirement {
+	own := map[string]bool{}
+	for _, img := range images {
+		own[img.Name] = true
+	}
+	var uses []unibuild.Requirement
+	for _, img := range images {
+		for _, req := range img.Uses {
+			if !own[req.ref.Name] {
+				uses = append(uses, req)
+			}
+		}
+	}
+	return unibuild.MergeRequirements(uses)
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{Name: prj.name}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion {
+	builds := make([]unibuild.RequirementVersion, 0, len(prj.images))
+	for _, img := range prj.images {
+		builds = append(builds, unibuild.RequirementVersion{ID: unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: img.Name}})
+	}
+	return builds
+}
+
+// Images the project builds, in the order they get built in.
+func (prj Project) Images() []Image { return prj.images }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Plan shows the commands the images are built with.
+func (prj Project) Plan() string { return strings.Join(prj.allCommands(), " && ") }
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	return prj.clone.RunShell(ctx, logTo, prj.allCommands()...)
+}
+
+// allCommands fills in the commands for every image, in build order.
+func (prj Project) allCommands() []string {
+	var args strings.Builder
+	names := make([]string, 0, len(prj.args))
+	for name := range prj.args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args.WriteString(" --build-arg " + shellQuote(name+"="+prj.args[name]))
+	}
+
+	var cmds []string
+	for _, img := range prj.images {
+		r := strings.NewReplacer(
+			"{image}", img.Name,
+			"{tag}", prj.tag,
+			"{dockerfile}", img.Dockerfile,
+			"{context}", path.Dir(img.Dockerfile),
+			"{build-args}", args.String(),
+		)
+		commands := prj.commands
+		if img.Local {
+			commands = prj.localCommands
+		}
+		for _, command := range commands {
+			cmds = append(cmds, r.Replace(command))
+		}
+	}
+	return cmds
+}
+
+// shellQuote quotes s for sh, unless it is safe as is.
+func shellQuote(s string) string {
+	if strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.=/:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
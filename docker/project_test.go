@@ -0,0 +1,136 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package docker_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/docker"
+	"github.com/szabba/unibuild/repo"
+)
+
+var _Config = docker.Config{Registry: "registry.acme.example/acme"}
+
+func TestProjectImagesAreNamedAfterTheRepository(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "base-images"}, Path: "testdata/base-images"}
+
+	// when
+	prj, err := _Config.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := []string{"registry.acme.example/acme/base-images", "registry.acme.example/acme/base-images-python"}
+	builds := prj.Builds()
+	assert.That(len(builds) == len(want), t.Fatalf, "got builds %v, want %v", builds, want)
+	for i, b := range builds {
+		assert.That(b.ID == docker.ImageID(want[i]), t.Errorf, "build #%d: got %s, want %s", i, b.ID, want[i])
+	}
+
+	uses := prj.Uses()
+	assert.That(len(uses) == 1, t.Fatalf, "got uses %v, want just debian", uses)
+	assert.That(uses[0].ID() == docker.ImageID("debian"), t.Errorf, "got use %s", uses[0].ID())
+}
+
+func TestProjectImagesAreBuiltAfterTheOnesTheyAreBuiltFrom(t *testing.T) {
+	// given
+	cfg := _Config
+	cfg.Images = map[string]string{"orders/worker/Dockerfile": "registry.acme.example/acme/orders-worker"}
+	clone := repo.Local{Remote: repo.Remote{Name: "orders"}, Path: "testdata/orders"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	images := prj.Images()
+	assert.That(len(images) == 2, t.Fatalf, "got images %v, want the service and worker ones", images)
+	assert.That(images[0].Dockerfile == "Dockerfile" && images[1].Dockerfile == "worker/Dockerfile", t.Errorf, "got images %v", images)
+	assert.That(images[1].Name == "registry.acme.example/acme/orders-worker", t.Errorf, "got image %q", images[1].Name)
+
+	want := map[unibuild.RequirementIdentity]bool{
+		docker.ImageID("golang"):                                 true,
+		docker.ImageID("registry.acme.example/acme/base-images"): true,
+		docker.ImageID("registry.acme.example/acme/migrations"):  true,
+	}
+	assert.That(len(prj.Uses()) == len(want), t.Errorf, "got uses %v, want %v", prj.Uses(), want)
+	for _, req := range prj.Uses() {
+		assert.That(want[req.ID()], t.Errorf, "unexpected use %s", req.ID())
+	}
+}
+
+func TestProjectPlanFillsInTheCommands(t *testing.T) {
+	// given
+	cfg := _Config
+	cfg.Tag = "1.4.0"
+	cfg.BuildArgs = map[string]string{"DEBIAN_RELEASE": "trixie"}
+	clone := repo.Local{Remote: repo.Remote{Name: "base-images"}, Path: "testdata/base-images"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "docker build -f Dockerfile -t registry.acme.example/acme/base-images:1.4.0 --build-arg DEBIAN_RELEASE=trixie . && " +
+		"docker push registry.acme.example/acme/base-images:1.4.0 && " +
+		"docker build -f python/Dockerfile -t registry.acme.example/acme/base-images-python:1.4.0 --build-arg DEBIAN_RELEASE=trixie python && " +
+		"docker push registry.acme.example/acme/base-images-python:1.4.0"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan\n%s\nwant\n%s", prj.Plan(), want)
+}
+
+func TestProjectPlanOnlyPushesImagesThatHaveADestination(t *testing.T) {
+	// given
+	cfg := docker.Config{Images: map[string]string{"base-images/python/Dockerfile": "registry.acme.example/acme/python"}}
+	clone := repo.Local{Remote: repo.Remote{Name: "base-images"}, Path: "testdata/base-images"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "docker build -f Dockerfile -t docker.io/library/base-images:latest . && " +
+		"docker build -f python/Dockerfile -t registry.acme.example/acme/python:latest python && " +
+		"docker push registry.acme.example/acme/python:latest"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan\n%s\nwant\n%s", prj.Plan(), want)
+}
+
+func TestImageRebuildsCascade(t *testing.T) {
+	// given
+	ctx := context.Background()
+	orders, err := _Config.NewProject(ctx, repo.Local{Remote: repo.Remote{Name: "orders"}, Path: "testdata/orders"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	base, err := _Config.NewProject(ctx, repo.Local{Remote: repo.Remote{Name: "base-images"}, Path: "testdata/base-images"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	ordered, err := unibuild.NewProjectSuite(orders, base).ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	order := ordered.Order()
+	assert.That(len(order) == 2, t.Fatalf, "got %d projects, want 2", len(order))
+	assert.That(order[0].Info().Name == "base-images", t.Errorf, "got %s built first, want base-images", order[0].Info().Name)
+}
+
+func TestDetectorDeclinesRepositoriesWithoutDockerfiles(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "docker")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+	detector := _Config.Detector()
+
+	// when
+	_, err = detector.Detect(context.Background(), repo.Local{Path: dir})
+
+	// then
+	assert.That(oops.Cause(err) == unibuild.ErrDeclined, t.Errorf, "got error %v, want %v", err, unibuild.ErrDeclined)
+}
@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package docker
+
+import (
+	"strings"
+
+	"github.com/szabba/unibuild"
+)
+
+// Ecosystem of the requirements of docker projects: images, named by the repository they are pushed to.
+const Ecosystem = "docker"
+
+// The registry images without a registry in their name get pulled from.
+const _DefaultRegistry = "docker.io"
+
+// A Reference to an image, split into its parts.
+type Reference struct {
+	// Name is the repository of the image, including the registry.
+	Name string
+	Tag  string
+	// Digest is empty unless the image is referred to by one.
+	Digest string
+}
+
+// ParseReference splits an image reference, like registry.acme.example/acme/base:1.4, into its parts.
+// The name is normalised the way docker does it: images without a registry come from Docker Hub, where official ones
+// live under library/. A missing tag is latest, unless there is a digest.
+func ParseReference(ref string) Reference {
+	var r Reference
+	if at := strings.IndexByte(ref, '@'); at >= 0 {
+		ref, r.Digest = ref[:at], ref[at+1:]
+	}
+	if colon := strings.LastIndexByte(ref, ':'); colon > strings.LastIndexByte(ref, '/') {
+		ref, r.Tag = ref[:colon], ref[colon+1:]
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+
+	parts := strings.Split(strings.ToLower(ref), "/")
+	if len(parts) == 1 || !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		parts = append([]string{_DefaultRegistry}, parts...)
+	}
+	if parts[0] == "index.docker.io" {
+		parts[0] = _DefaultRegistry
+	}
+	if parts[0] == _DefaultRegistry && len(parts) == 2 {
+		parts = []string{_DefaultRegistry, "library", parts[1]}
+	}
+	r.Name = strings.Join(parts, "/")
+	return r
+}
+
+func (r Reference) String() string {
+	s := r.Name
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// ImageID identifies an image as a requirement, irrespective of its tag.
+func ImageID(ref string) unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: ParseReference(ref).Name}
+}
+
+// A Requirement on an image, which some stage of a Dockerfile is built on or copies files from.
+// Images are always needed to build.
+type Requirement struct {
+	ref Reference
+}
+
+var _ unibuild.KindedRequirement = Requirement{}
+
+// NewRequirement creates a requirement on the referenced image.
+func NewRequirement(ref string) Requirement {
+	return Requirement{ref: ParseReference(ref)}
+}
+
+func (req Requirement) ID() unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: req.ref.Name}
+}
+
+func (req Requirement) Kind() unibuild.RequirementKind { return unibuild.Compile }
+
+// Reference to the image, with the tag or digest it was asked for with.
+func (req Requirement) Reference() Reference { return req.ref }
@@ -0,0 +1,61 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package unibuild
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteDOT writes the dependency graph of the projects in the Graphviz DOT format, in build order.
+// Edges point from the projects that use something to the ones that build it, labelled with the kind of the
+// requirement. Manual edges are labelled as such and drawn bold, while soft ones are dashed.
+func (ops OrderedProjectSuite) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph unibuild {")
+	for _, ix := range ops.ixOrder {
+		fmt.Fprintf(bw, "\t%s;\n", strconv.Quote(ops.projects[ix].Info().Name))
+	}
+	for _, e := range ops.dotEdges() {
+		label, style := e.kind.String(), "solid"
+		if e.manual {
+			label, style = "manual "+label, "bold"
+		}
+		if e.soft {
+			style = "dashed"
+		}
+		fmt.Fprintf(bw, "\t%s -> %s [label=%s, style=%s];\n",
+			strconv.Quote(ops.projects[e.from].Info().Name), strconv.Quote(ops.projects[e.to].Info().Name),
+			strconv.Quote(label), style)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotEdges merges the edges between the same projects, keeping the strongest kind.
+// Manual edges are kept apart from the ones the projects declare.
+func (ops OrderedProjectSuite) dotEdges() []edge {
+	type key struct {
+		from, to int
+		manual   bool
+	}
+	index := map[key]int{}
+	var edges []edge
+	for _, e := range ops.edges {
+		k := key{e.from, e.to, e.manual}
+		i, present := index[k]
+		switch {
+		case !present:
+			index[k] = len(edges)
+			edges = append(edges, e)
+		default:
+			edges[i].kind = edges[i].kind.Stronger(e.kind)
+			edges[i].soft = edges[i].soft && e.soft
+		}
+	}
+	return edges
+}
@@ -1,13 +1,19 @@
 module github.com/szabba/unibuild
 
+go 1.27.1
+
 require (
 	github.com/golang/mock v1.1.1
-	github.com/golang/protobuf v1.1.0 // indirect
-	github.com/google/go-querystring v0.0.0-20170111101155-53e6ce116135 // indirect
 	github.com/samsarahq/go v0.0.0-20180710184812-c046b0801eb2
 	github.com/soniakeys/graph v0.0.0-20180428232946-7b5d1f6e4fe0
 	github.com/szabba/assert v1.0.0
 	github.com/xanzy/go-gitlab v0.10.8
+)
+
+require (
+	github.com/golang/protobuf v1.1.0 // indirect
+	github.com/google/go-querystring v0.0.0-20170111101155-53e6ce116135 // indirect
+	github.com/soniakeys/bits v1.0.0 // indirect
 	golang.org/x/net v0.0.0-20180816102801-aaf60122140d // indirect
 	golang.org/x/oauth2 v0.0.0-20180724155351-3d292e4d0cdc // indirect
 	golang.org/x/sync v0.0.0-20180314180146-1d60e4601c6f // indirect
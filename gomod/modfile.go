@@ -0,0 +1,231 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gomod
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+const _ModFile = "go.mod"
+
+var (
+	ErrNotGo      = errors.New("no go.mod found")
+	ErrBadModFile = errors.New("malformed go.mod")
+)
+
+// A Module is what is declared in a go.mod file.
+type Module struct {
+	// Dir the go.mod is in.
+	Dir     string
+	Path    string
+	Go      string
+	Require []Require
+	Replace []Replace
+}
+
+// A Require directive.
+type Require struct {
+	Path, Version string
+	// Indirect requirements are only there for the modules required directly.
+	Indirect bool
+}
+
+// A Replace directive.
+// The old version is empty when every version is replaced, and the new one when the replacement is a directory.
+type Replace struct {
+	Old, OldVersion string
+	New, NewVersion string
+}
+
+// Local tells whether the replacement is a directory rather than a module.
+func (r Replace) Local() bool {
+	return strings.HasPrefix(r.New, "./") || strings.HasPrefix(r.New, "../") || filepath.IsAbs(r.New)
+}
+
+// Replacement finds the replace directive that applies to the requirement.
+// A directive for the exact version wins over one for all versions.
+func (mod Module) Replacement(req Require) (Replace, bool) {
+	var found Replace
+	ok := false
+	for _, r := range mod.Replace {
+		if r.Old != req.Path {
+			continue
+		}
+		if r.OldVersion == req.Version {
+			return r, true
+		}
+		if r.OldVersion == "" {
+			found, ok = r, true
+		}
+	}
+	return found, ok
+}
+
+// ReadModule reads the go.mod in dir.
+func ReadModule(dir string) (Module, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, _ModFile))
+	if os.IsNotExist(err) {
+		return Module{}, oops.Wrapf(ErrNotGo, "in %s", dir)
+	}
+	if err != nil {
+		return Module{}, oops.Wrapf(err, "cannot read %s in %s", _ModFile, dir)
+	}
+	mod, err := ParseModule(string(raw))
+	mod.Dir = dir
+	return mod, oops.Wrapf(err, "in %s", dir)
+}
+
+// ReadModules reads the go.mod in dir and those of any modules nested in it, sorted by directory.
+// Vendored and test data directories, along with hidden ones, are skipped just as the go command does.
+func ReadModules(dir string) ([]Module, error) {
+	root, err := ReadModule(dir)
+	if err != nil {
+		return nil, err
+	}
+	mods := []Module{root}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == dir {
+			return nil
+		}
+		name := info.Name()
+		if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return filepath.SkipDir
+		}
+		mod, err := ReadModule(path)
+		if oops.Cause(err) == ErrNotGo {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mods = append(mods, mod)
+		return nil
+	})
+	return mods, oops.Wrapf(err, "cannot find the modules nested in %s", dir)
+}
+
+// ParseModule parses the contents of a go.mod file.
+// Only the module, go, require and replace directives are kept.
+func ParseModule(src string) (Module, error) {
+	var mod Module
+	block := ""
+	for i, line := range strings.Split(src, "\n") {
+		fields, comment, err := splitLine(line)
+		if err != nil {
+			return Module{}, oops.Wrapf(err, "on line %d", i+1)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		err = mod.add(verb, fields, comment)
+		if err != nil {
+			return Module{}, oops.Wrapf(err, "on line %d", i+1)
+		}
+	}
+	if mod.Path == "" {
+		return Module{}, oops.Wrapf(ErrBadModFile, "no module directive")
+	}
+	return mod, nil
+}
+
+func (mod *Module) add(verb string, args []string, comment string) error {
+	switch verb {
+	case "module":
+		if len(args) != 1 {
+			return oops.Wrapf(ErrBadModFile, "module takes a path, got %q", args)
+		}
+		mod.Path = args[0]
+	case "go":
+		if len(args) != 1 {
+			return oops.Wrapf(ErrBadModFile, "go takes a version, got %q", args)
+		}
+		mod.Go = args[0]
+	case "require":
+		if len(args) != 2 {
+			return oops.Wrapf(ErrBadModFile, "require takes a path and a version, got %q", args)
+		}
+		indirect := strings.TrimSpace(comment) == "indirect"
+		mod.Require = append(mod.Require, Require{Path: args[0], Version: args[1], Indirect: indirect})
+	case "replace":
+		arrow := -1
+		for i, a := range args {
+			if a == "=>" {
+				arrow = i
+			}
+		}
+		if arrow < 1 || arrow > 2 || len(args)-arrow-1 < 1 || len(args)-arrow-1 > 2 {
+			return oops.Wrapf(ErrBadModFile, "replace takes a module, => and its replacement, got %q", args)
+		}
+		r := Replace{Old: args[0], New: args[arrow+1]}
+		if arrow == 2 {
+			r.OldVersion = args[1]
+		}
+		if len(args) == arrow+3 {
+			r.NewVersion = args[arrow+2]
+		}
+		mod.Replace = append(mod.Replace, r)
+	}
+	return nil
+}
+
+// splitLine splits a line into fields, unquoting the quoted ones, and returns the comment ending it separately.
+func splitLine(line string) ([]string, string, error) {
+	var fields []string
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return fields, line[i+2:], nil
+		case c == '"' || c == '`':
+			end := strings.IndexByte(line[i+1:], c)
+			if end < 0 {
+				return nil, "", oops.Wrapf(ErrBadModFile, "unterminated string")
+			}
+			quoted := line[i : i+end+2]
+			s, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, "", oops.Wrapf(ErrBadModFile, "bad string %s", quoted)
+			}
+			fields = append(fields, s)
+			i += end + 2
+		case c == '(' || c == ')':
+			fields = append(fields, string(c))
+			i++
+		default:
+			j := i
+			for j < len(line) && !strings.ContainsRune(" \t\r()\"`", rune(line[j])) && !strings.HasPrefix(line[j:], "//") {
+				j++
+			}
+			fields = append(fields, line[i:j])
+			i = j
+		}
+	}
+	return fields, "", nil
+}
@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gomod_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/gomod"
+)
+
+func TestReadModules(t *testing.T) {
+	// when
+	mods, err := gomod.ReadModules("testdata/payments")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(mods) == 2, t.Fatalf, "got %d modules, want 2", len(mods))
+
+	root, tools := mods[0], mods[1]
+	assert.That(root.Path == "example.com/payments" && root.Go == "1.20", t.Errorf, "got root module %s, go %s", root.Path, root.Go)
+	assert.That(tools.Path == "example.com/payments/tools", t.Errorf, "got nested module %s", tools.Path)
+	assert.That(tools.Dir == filepath.Join("testdata", "payments", "tools"), t.Errorf, "got nested module directory %s", tools.Dir)
+
+	wantRequire := []gomod.Require{
+		{Path: "example.com/ledger", Version: "v1.3.0"},
+		{Path: "example.com/legacy", Version: "v0.1.0"},
+		{Path: "example.com/old", Version: "v1.0.0"},
+		{Path: "github.com/google/uuid", Version: "v1.3.0", Indirect: true},
+	}
+	assert.That(reflect.DeepEqual(root.Require, wantRequire), t.Errorf, "got requirements %#v, want %#v", root.Require, wantRequire)
+
+	wantReplace := []gomod.Replace{
+		{Old: "example.com/legacy", New: "../legacy-fork"},
+		{Old: "example.com/old", OldVersion: "v1.0.0", New: "example.com/new", NewVersion: "v1.1.0"},
+	}
+	assert.That(reflect.DeepEqual(root.Replace, wantReplace), t.Errorf, "got replacements %#v, want %#v", root.Replace, wantReplace)
+}
+
+func TestParseModuleWithSingleLineDirectivesAndQuotes(t *testing.T) {
+	// given
+	src := "module \"example.com/ledger\"\nrequire example.com/money v0.2.0 // indirect\nreplace example.com/money v0.2.0 => ./money\n"
+
+	// when
+	mod, err := gomod.ParseModule(src)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(mod.Path == "example.com/ledger", t.Errorf, "got module path %q", mod.Path)
+	assert.That(len(mod.Require) == 1 && mod.Require[0].Indirect, t.Errorf, "got requirements %#v", mod.Require)
+	r, ok := mod.Replacement(mod.Require[0])
+	assert.That(ok && r.Local(), t.Errorf, "got replacement %#v", r)
+}
+
+func TestParseModuleNeedsAModulePath(t *testing.T) {
+	// when
+	_, err := gomod.ParseModule("go 1.21\n")
+
+	// then
+	assert.That(oops.Cause(err) == gomod.ErrBadModFile, t.Errorf, "got error %v, want %v", err, gomod.ErrBadModFile)
+}
+
+func TestReadModulesOfSomethingElse(t *testing.T) {
+	// when
+	_, err := gomod.ReadModules("testdata")
+
+	// then
+	assert.That(oops.Cause(err) == gomod.ErrNotGo, t.Errorf, "got error %v, want %v", err, gomod.ErrNotGo)
+}
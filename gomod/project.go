@@ -0,0 +1,297 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package gomod lets unibuild build Go modules, including repositories with several of them.
+//
+// Go resolves modules through tags, so a project in the suite is not built in a version others can use until it
+// gets tagged. In workspace mode, the modules a project requires from the other clones are put in a temporary
+// go.work instead, so changes spanning several repositories can be tested before any of them is tagged.
+package gomod
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+// A Config controls how Go modules are built.
+type Config struct {
+	// Workspace builds projects against the modules they require from the sibling clones.
+	Workspace bool `json:"workspace,omitempty"`
+	// Offline only uses modules that are already in the module cache.
+	Offline bool `json:"offline,omitempty"`
+	// Siblings are the clones searched for modules in workspace mode.
+	Siblings []repo.Local `json:"-"`
+}
+
+// Commands run in every module of a project.
+func Commands() [][]string {
+	return [][]string{{"go", "build", "./..."}, {"go", "test", "./..."}}
+}
+
+// A Project made up of the Go modules in a repository.
+type Project struct {
+	name    string
+	clone   repo.Local
+	modules []Module
+	env     []string
+	// work lists the sibling modules used in workspace mode.
+	work   []Module
+	uses   []unibuild.Requirement
+	builds []unibuild.RequirementVersion
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
+
+// NewProject attempts to create a Go project given a locally cloned repository.
+func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds Go modules.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("go", 10, ErrNotGo, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create a Go project given a locally cloned repository.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	mods, err := ReadModules(clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem reading go modules in %s", clone.Path)
+	}
+
+	prj := Project{
+		name:    clone.Name,
+		clone:   clone,
+		modules: mods,
+		uses:    findUses(clone, mods),
+		builds:  findBuilds(mods),
+	}
+	if cfg.Offline {
+		prj.env = append(prj.env, "GOPROXY=off")
+	}
+	if cfg.Workspace {
+		prj.work, err = cfg.siblingModules(clone, mods)
+		if err != nil {
+			return Project{}, err
+		}
+	}
+	return prj, nil
+}
+
+func findBuilds(mods []Module) []unibuild.RequirementVersion {
+	builds := make([]unibuild.RequirementVersion, 0, len(mods))
+	for _, mod := range mods {
+		builds = append(builds, unibuild.RequirementVersion{ID: ModuleID(mod.Path)})
+	}
+	return builds
+}
+
+// findUses lists the modules required by any of the modules of a project, after replacements.
+// Modules replaced by directories are left out, as they do not come from anywhere else.
+func findUses(clone repo.Local, mods []Module) []unibuild.Requirement {
+	own := map[unibuild.RequirementIdentity]bool{}
+	for _, mod := range mods {
+		own[ModuleID(mod.Path)] = true
+	}
+
+	var uses []unibuild.Requirement
+	for _, mod := range mods {
+		for _, req := range required(mod) {
+			if req.Version == "" {
+				clone.Log().Printf("%s replaces %s with a directory, which is left out of the analysis", mod.Path, req.Path)
+				continue
+			}
+			if r := NewRequirement(req); !own[r.ID()] {
+				uses = append(uses, r)
+			}
+		}
+	}
+	return unibuild.MergeRequirements(uses)
+}
+
+// required lists the modules a module requires, with any replacements applied.
+// A module replaced with a directory has no version.
+func required(mod Module) []Require {
+	reqs := make([]Require, 0, len(mod.Require))
+	for _, req := range mod.Require {
+		if r, ok := mod.Replacement(req); ok {
+			req = Require{Path: r.New, Version: r.NewVersion, Indirect: req.Indirect}
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// siblingModules finds the modules of other clones the project requires, directly or through one another.
+func (cfg Config) siblingModules(clone repo.Local, own []Module) ([]Module, error) {
+	available := map[string]Module{}
+	for _, sib := range cfg.Siblings {
+		if sib.Path == clone.Path {
+			continue
+		}
+		mods, err := ReadModules(sib.Path)
+		if oops.Cause(err) == ErrNotGo {
+			continue
+		}
+		if err != nil {
+			return nil, oops.Wrapf(err, "problem reading the go modules of sibling clone %s", sib.Name)
+		}
+		for _, mod := range mods {
+			available[mod.Path] = mod
+		}
+	}
+
+	var used []Module
+	pending := append([]Module{}, own...)
+	for len(pending) > 0 {
+		mod := pending[0]
+		pending = pending[1:]
+		for _, req := range required(mod) {
+			sib, ok := available[req.Path]
+			if !ok || req.Version == "" {
+				continue
+			}
+			delete(available, req.Path)
+			used = append(used, sib)
+			pending = append(pending, sib)
+		}
+	}
+	return used, nil
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{Name: prj.name}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion { return prj.builds }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Plan shows the commands the project is built with, and where.
+func (prj Project) Plan() string {
+	cmds := make([]string, 0, len(Commands()))
+	for _, cmd := range Commands() {
+		cmds = append(cmds, strings.Join(cmd, " "))
+	}
+	plan := strings.Join(cmds, " && ")
+
+	if len(prj.modules) > 1 {
+		dirs := make([]string, 0, len(prj.modules))
+		for _, mod := range prj.modules {
+			dirs = append(dirs, prj.relative(mod.Dir))
+		}
+		plan += " in each of " + strings.Join(dirs, ", ")
+	}
+	if len(prj.work) > 0 {
+		paths := make([]string, 0, len(prj.work))
+		for _, mod := range prj.work {
+			paths = append(paths, mod.Path)
+		}
+		plan += " with a go.work using " + strings.Join(paths, ", ")
+	}
+	return plan
+}
+
+func (prj Project) relative(dir string) string {
+	rel, err := filepath.Rel(prj.clone.Path, dir)
+	if err != nil {
+		return dir
+	}
+	return rel
+}
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	env := append([]string{}, prj.env...)
+	if len(prj.work) > 0 {
+		dir, err := ioutil.TempDir("", "unibuild-gowork")
+		if err != nil {
+			return oops.Wrapf(err, "cannot create a directory for the go.work of %s", prj.name)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "go.work")
+		err = prj.writeWork(path)
+		if err != nil {
+			return err
+		}
+		env = append(env, "GOWORK="+path, "GOFLAGS="+workspaceFlags(os.Getenv("GOFLAGS")))
+	}
+
+	for _, mod := range prj.modules {
+		for _, args := range Commands() {
+			cmd := prj.clone.CommandTo(ctx, repo.CombinedOutput(logTo), args[0], args[1:]...)
+			cmd.Dir = mod.Dir
+			cmd.Env = append(os.Environ(), env...)
+			if err := cmd.Run(); err != nil {
+				return oops.Wrapf(err, "in module %s at %s, %s failed", mod.Path, mod.Dir, strings.Join(args, " "))
+			}
+		}
+	}
+	return nil
+}
+
+// writeWork writes a go.work using the modules of the project and the sibling ones they need.
+func (prj Project) writeWork(path string) error {
+	mods := append(append([]Module{}, prj.modules...), prj.work...)
+	goVersion := "1.18"
+	var uses strings.Builder
+	for _, mod := range mods {
+		dir, err := filepath.Abs(mod.Dir)
+		if err != nil {
+			return oops.Wrapf(err, "cannot find the absolute path of %s", mod.Dir)
+		}
+		fmt.Fprintf(&uses, "\t%s\n", strconv.Quote(dir))
+		// The go.work cannot declare an older go version than any of the modules it uses.
+		if newerGo(mod.Go, goVersion) {
+			goVersion = mod.Go
+		}
+	}
+
+	work := fmt.Sprintf("go %s\n\nuse (\n%s)\n", goVersion, uses.String())
+	err := ioutil.WriteFile(path, []byte(work), 0644)
+	return oops.Wrapf(err, "cannot write %s", path)
+}
+
+// workspaceFlags drops any -mod flag, which go refuses in workspace mode unless it is the default one.
+func workspaceFlags(goflags string) string {
+	var kept []string
+	for _, f := range strings.Fields(goflags) {
+		if !strings.HasPrefix(f, "-mod=") {
+			kept = append(kept, f)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// newerGo tells whether the go version a is newer than b.
+func newerGo(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, _ := strconv.Atoi(as[i])
+		bn, _ := strconv.Atoi(bs[i])
+		if an != bn {
+			return an > bn
+		}
+	}
+	return len(as) > len(bs)
+}
@@ -0,0 +1,94 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gomod_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/gomod"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestProjectRequirements(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "payments"}, Path: "testdata/payments"}
+
+	// when
+	prj, err := gomod.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	builds := prj.Builds()
+	assert.That(len(builds) == 2, t.Fatalf, "got builds %v, want 2", builds)
+	assert.That(builds[1].ID == gomod.ModuleID("example.com/payments/tools"), t.Errorf, "got build %s", builds[1].ID)
+
+	want := []string{"example.com/ledger", "example.com/new", "github.com/google/uuid", "golang.org/x/tools"}
+	uses := prj.Uses()
+	assert.That(len(uses) == len(want), t.Fatalf, "got requirements %v, want %q", uses, want)
+	for i, req := range uses {
+		assert.That(req.ID() == gomod.ModuleID(want[i]), t.Errorf, "got requirement %s, want %s", req.ID(), want[i])
+	}
+}
+
+func TestProjectPlan(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "payments"}, Path: "testdata/payments"}
+
+	// when
+	prj, err := gomod.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "go build ./... && go test ./... in each of ., tools"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func TestWorkspaceBuildUsesTheSiblingClones(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "gomod-workspace")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, filepath.Join(dir, "ledger"), map[string]string{
+		"go.mod":    "module example.com/ledger\n\ngo 1.18\n",
+		"ledger.go": "package ledger\n\nfunc Balance() int { return 42 }\n",
+	})
+	writeFiles(t, filepath.Join(dir, "payments"), map[string]string{
+		"go.mod":      "module example.com/payments\n\ngo 1.18\n\nrequire example.com/ledger v1.4.0\n",
+		"payments.go": "package payments\n\nimport \"example.com/ledger\"\n\nfunc Due() int { return ledger.Balance() }\n",
+	})
+	ledger := repo.Local{Remote: repo.Remote{Name: "ledger"}, Path: filepath.Join(dir, "ledger")}
+	payments := repo.Local{Remote: repo.Remote{Name: "payments"}, Path: filepath.Join(dir, "payments")}
+
+	cfg := gomod.Config{Workspace: true, Offline: true, Siblings: []repo.Local{ledger, payments}}
+	prj, err := cfg.NewProject(context.Background(), payments)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	var out bytes.Buffer
+	err = prj.Build(context.Background(), &out)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s\n%s", err, out.String())
+	want := "go build ./... && go test ./... with a go.work using example.com/ledger"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	err := os.MkdirAll(dir, 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	}
+}
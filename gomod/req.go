@@ -0,0 +1,40 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gomod
+
+import (
+	"github.com/szabba/unibuild"
+)
+
+// Ecosystem of the requirements of Go modules.
+const Ecosystem = "go"
+
+// ModuleID identifies a module as a requirement, irrespective of its version.
+// Major versions above 1 are part of the module path, so they are different requirements.
+func ModuleID(path string) unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: path}
+}
+
+// A Requirement on a Go module.
+// Go picks the newest version any module in the build asks for, so the minimal version is not a constraint.
+type Requirement struct {
+	id      unibuild.RequirementIdentity
+	version string
+}
+
+var _ unibuild.KindedRequirement = Requirement{}
+
+// NewRequirement creates a requirement out of a require directive.
+// Tests cannot be built without the requirements of the packages they test, so all of them are compile ones.
+func NewRequirement(req Require) Requirement {
+	return Requirement{id: ModuleID(req.Path), version: req.Version}
+}
+
+func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return unibuild.Compile }
+
+// MinVersion is the version the requiring module asks for at least.
+func (req Requirement) MinVersion() string { return req.version }
@@ -0,0 +1,15 @@
+module example.com/payments
+
+go 1.20
+
+require (
+	example.com/ledger v1.3.0
+	example.com/legacy v0.1.0
+	example.com/old v1.0.0
+	github.com/google/uuid v1.3.0 // indirect
+)
+
+// The fork is checked out next to the repository.
+replace example.com/legacy => ../legacy-fork
+
+replace example.com/old v1.0.0 => example.com/new v1.1.0
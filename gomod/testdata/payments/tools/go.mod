@@ -0,0 +1,10 @@
+module example.com/payments/tools
+
+go 1.21
+
+require (
+	example.com/payments v0.0.0
+	golang.org/x/tools v0.14.0
+)
+
+replace example.com/payments => ../
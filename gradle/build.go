@@ -0,0 +1,527 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gradle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+)
+
+// ErrNotGradle is the cause of errors returned when asked to read a directory that holds no gradle build.
+var ErrNotGradle = errors.New("not a gradle project")
+
+const (
+	_RootPath           = ":"
+	_PropertiesFile     = "gradle.properties"
+	_UnspecifiedVersion = "unspecified"
+	_PluginMarkerSuffix = ".gradle.plugin"
+)
+
+var (
+	_SettingsFiles = []string{"settings.gradle", "settings.gradle.kts"}
+	_BuildFiles    = []string{"build.gradle", "build.gradle.kts"}
+	_PropertyRef   = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_.]*)\}?`)
+)
+
+// A Build is a root project along with all the subprojects it includes.
+type Build struct {
+	// Projects start with the root one.
+	Projects []Subproject
+	// Skipped lists the declarations that could not be understood without running gradle.
+	Skipped []string
+}
+
+// A Subproject of a build.
+// The root project is one as well, with the path ":".
+type Subproject struct {
+	Path         string
+	Dir          string
+	Group        string
+	Name         string
+	Version      string
+	Dependencies []Dependency
+	Plugins      []Plugin
+}
+
+// A Dependency declared in a build script.
+// For project dependencies only the Project path is set.
+type Dependency struct {
+	Configuration string
+	Group         string
+	Name          string
+	Version       string
+	Project       string
+}
+
+// A Plugin applied through the plugins block.
+type Plugin struct {
+	ID      string
+	Version string
+}
+
+// Kind tells what the dependency is needed for, going by the configuration it is declared in.
+func (dep Dependency) Kind() unibuild.RequirementKind {
+	conf := dep.Configuration
+	if strings.HasPrefix(conf, "test") || strings.Contains(conf, "Test") {
+		return unibuild.Test
+	}
+	return unibuild.Compile
+}
+
+// IsCore tells whether the plugin ships with gradle itself.
+// Other plugins need IDs with a namespace, which the core ones lack (or have under org.gradle).
+func (p Plugin) IsCore() bool {
+	return !strings.Contains(p.ID, ".") || strings.HasPrefix(p.ID, "org.gradle.")
+}
+
+// MarkerGroup and MarkerName are the coordinates of the artifact a plugin is resolved through.
+func (p Plugin) MarkerGroup() string { return p.ID }
+
+func (p Plugin) MarkerName() string { return p.ID + _PluginMarkerSuffix }
+
+// ReadBuild reads the gradle build in dir from its settings and build scripts, without running gradle.
+//
+// Only the common, declarative forms are understood: literal group and version assignments (also in gradle.properties,
+// allprojects and subprojects blocks), string, map and project dependency notations, and plugin IDs.
+// Whatever else is found is listed in the Skipped field of the result.
+func ReadBuild(dir string) (Build, error) {
+	settingsPath := firstExisting(dir, _SettingsFiles)
+	rootScriptPath := firstExisting(dir, _BuildFiles)
+	if settingsPath == "" && rootScriptPath == "" {
+		return Build{}, oops.Wrapf(ErrNotGradle, "no settings or build script in %s", dir)
+	}
+
+	r := buildReader{dir: dir, rootName: filepath.Base(dir), dirs: map[string]string{}}
+	err := r.readSettings(settingsPath)
+	if err != nil {
+		return Build{}, err
+	}
+
+	rootProps, err := readProperties(filepath.Join(dir, _PropertiesFile))
+	if err != nil {
+		return Build{}, err
+	}
+	rootStmts, err := readScript(rootScriptPath)
+	if err != nil {
+		return Build{}, err
+	}
+
+	var b Build
+	for _, path := range r.paths {
+		sub, err := r.readSubproject(path, rootProps, rootStmts)
+		if err != nil {
+			return Build{}, oops.Wrapf(err, "problem reading gradle project %s", path)
+		}
+		b.Projects = append(b.Projects, sub)
+	}
+	b.Skipped = r.skipped
+	return b, nil
+}
+
+type buildReader struct {
+	dir      string
+	rootName string
+	paths    []string
+	// dirs keeps the directories of the subprojects that do not live where their path says.
+	dirs    map[string]string
+	skipped []string
+}
+
+func (r *buildReader) readSettings(path string) error {
+	stmts, err := readScript(path)
+	if err != nil {
+		return err
+	}
+
+	r.paths = []string{_RootPath}
+	seen := map[string]bool{_RootPath: true}
+	for _, st := range stmts {
+		toks := st.tokens
+		if !st.in() || len(toks) == 0 || toks[0].kind != _Ident {
+			continue
+		}
+		switch toks[0].text {
+		case "rootProject.name":
+			if name, ok := assigned(toks); ok {
+				r.rootName = name
+			}
+		case "project":
+			r.readProjectDir(toks)
+		case "include":
+			for _, tok := range toks[1:] {
+				if tok.kind != _String {
+					continue
+				}
+				// Including a nested project includes its ancestors too.
+				for _, p := range ancestry(tok.text) {
+					if !seen[p] {
+						seen[p] = true
+						r.paths = append(r.paths, p)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readProjectDir understands project(':x').projectDir = file('dir') and its variants.
+func (r *buildReader) readProjectDir(toks []token) {
+	var strs []string
+	isProjectDir := false
+	for _, tok := range toks {
+		switch {
+		case tok.kind == _String:
+			strs = append(strs, tok.text)
+		case tok.is(_Ident, ".projectDir"):
+			isProjectDir = true
+		}
+	}
+	if isProjectDir && len(strs) >= 2 {
+		r.dirs[strs[0]] = filepath.Join(r.dir, filepath.FromSlash(strs[len(strs)-1]))
+	}
+}
+
+// ancestry lists the paths leading to a subproject, ending with its own.
+func ancestry(include string) []string {
+	var paths []string
+	parts := strings.Split(strings.TrimPrefix(include, ":"), ":")
+	for i := range parts {
+		paths = append(paths, ":"+strings.Join(parts[:i+1], ":"))
+	}
+	return paths
+}
+
+func (r *buildReader) readSubproject(path string, rootProps map[string]string, rootStmts []statement) (Subproject, error) {
+	isRoot := path == _RootPath
+	sub := Subproject{
+		Path:    path,
+		Dir:     filepath.Join(append([]string{r.dir}, strings.Split(strings.TrimPrefix(path, ":"), ":")...)...),
+		Name:    path[strings.LastIndex(path, ":")+1:],
+		Group:   r.defaultGroup(path),
+		Version: _UnspecifiedVersion,
+	}
+	if isRoot {
+		sub.Dir, sub.Name = r.dir, r.rootName
+	}
+	if dir, ok := r.dirs[path]; ok {
+		sub.Dir = dir
+	}
+
+	props := copyProperties(rootProps)
+	var stmts []statement
+	if !isRoot {
+		own, err := readProperties(filepath.Join(sub.Dir, _PropertiesFile))
+		if err != nil {
+			return Subproject{}, err
+		}
+		for k, v := range own {
+			props[k] = v
+		}
+		stmts, err = readScript(firstExisting(sub.Dir, _BuildFiles))
+		if err != nil {
+			return Subproject{}, err
+		}
+	}
+	if v, ok := props["group"]; ok {
+		sub.Group = v
+	}
+	if v, ok := props["version"]; ok {
+		sub.Version = v
+	}
+
+	// The root script configures other projects through the allprojects and subprojects blocks.
+	shared := []string{"allprojects"}
+	if !isRoot {
+		shared = append(shared, "subprojects")
+	}
+	for _, block := range shared {
+		r.apply(&sub, props, rootStmts, block)
+	}
+	if isRoot {
+		stmts = rootStmts
+	}
+	r.apply(&sub, props, stmts)
+	return sub, nil
+}
+
+// defaultGroup is the group gradle gives projects that do not set one: the path of their parent, starting with the
+// name of the root project.
+func (r *buildReader) defaultGroup(path string) string {
+	if path == _RootPath {
+		return ""
+	}
+	parent := path[:strings.LastIndex(path, ":")]
+	return r.rootName + strings.Replace(parent, ":", ".", -1)
+}
+
+// apply applies the statements found in the given blocks to the subproject.
+func (r *buildReader) apply(sub *Subproject, props map[string]string, stmts []statement, blocks ...string) {
+	deps := append(append([]string{}, blocks...), "dependencies")
+	buildscriptDeps := append(append([]string{}, blocks...), "buildscript", "dependencies")
+	plugins := append(append([]string{}, blocks...), "plugins")
+
+	ext := append(append([]string{}, blocks...), "ext")
+
+	for _, st := range stmts {
+		switch {
+		case st.in(blocks...) && defineProperty(props, st.tokens, true):
+		case st.in(ext...) && defineProperty(props, st.tokens, false):
+		case st.in(blocks...):
+			r.applyAssignment(sub, props, st)
+		case st.in(deps...), st.in(buildscriptDeps...):
+			dep, ok := r.parseDependency(props, st.tokens)
+			if ok {
+				sub.Dependencies = append(sub.Dependencies, dep)
+			}
+		case st.in(plugins...):
+			p, ok := parsePlugin(st.tokens)
+			if ok {
+				sub.Plugins = append(sub.Plugins, p)
+			}
+		}
+	}
+}
+
+func (r *buildReader) applyAssignment(sub *Subproject, props map[string]string, st statement) {
+	toks := st.tokens
+	if len(toks) == 0 || toks[0].kind != _Ident {
+		return
+	}
+	var field *string
+	switch toks[0].text {
+	case "group", "project.group":
+		field = &sub.Group
+	case "version", "project.version":
+		field = &sub.Version
+	default:
+		return
+	}
+
+	value, ok := assigned(toks)
+	if !ok {
+		r.skip(sub, st)
+		return
+	}
+	value, ok = expand(value, props)
+	if !ok {
+		r.skip(sub, st)
+		return
+	}
+	*field = value
+}
+
+// defineProperty records extra properties defined with ext.name = 'value' (or, in the Kotlin DSL, val name = "value").
+// Inside an ext block, the statements are plain name = 'value' assignments.
+func defineProperty(props map[string]string, toks []token, qualified bool) bool {
+	if len(toks) < 3 || toks[0].kind != _Ident {
+		return false
+	}
+	name := toks[0].text
+	switch {
+	case !qualified:
+	case strings.HasPrefix(name, "ext.") && len(name) > len("ext."):
+		name = name[len("ext."):]
+	case name == "val" && toks[1].kind == _Ident:
+		name, toks = toks[1].text, toks[1:]
+	default:
+		return false
+	}
+
+	value, ok := assigned(toks)
+	if !ok || !toks[1].is(_Punct, "=") {
+		return false
+	}
+	value, _ = expand(value, props)
+	props[name] = value
+	return true
+}
+
+// parseDependency understands string, map and project notations, optionally wrapped in platform() and the like.
+func (r *buildReader) parseDependency(props map[string]string, toks []token) (Dependency, bool) {
+	if len(toks) < 2 || toks[0].kind != _Ident || toks[1].is(_Punct, "=") {
+		return Dependency{}, false
+	}
+	dep := Dependency{Configuration: toks[0].text}
+	var notation string
+	for i := 1; i < len(toks); i++ {
+		tok := toks[i]
+		switch {
+		case tok.kind == _Ident && (tok.text == "files" || tok.text == "fileTree" || tok.text == "gradleApi" || tok.text == "localGroovy"):
+			return Dependency{}, false
+		case tok.kind == _Ident && strings.HasPrefix(tok.text, "libs."):
+			r.skipped = append(r.skipped, "version catalog dependency "+tok.text)
+			return Dependency{}, false
+		case tok.kind == _Ident && tok.text == "project":
+			for _, t := range toks[i+1:] {
+				if t.kind == _String {
+					dep.Project = t.text
+					return dep, true
+				}
+			}
+			return Dependency{}, false
+		case tok.kind == _Ident && i+2 < len(toks) && (toks[i+1].is(_Punct, ":") || toks[i+1].is(_Punct, "=")) && toks[i+2].kind == _String:
+			switch tok.text {
+			case "group":
+				dep.Group = toks[i+2].text
+			case "name":
+				dep.Name = toks[i+2].text
+			case "version":
+				dep.Version = toks[i+2].text
+			}
+			i += 2
+		case tok.kind == _String && notation == "" && dep.Group == "":
+			notation = tok.text
+		}
+	}
+
+	if notation != "" {
+		parts := strings.Split(notation, ":")
+		if len(parts) < 2 {
+			return Dependency{}, false
+		}
+		dep.Group, dep.Name = parts[0], parts[1]
+		if len(parts) > 2 {
+			dep.Version = parts[2]
+		}
+	}
+	if dep.Group == "" || dep.Name == "" {
+		return Dependency{}, false
+	}
+
+	var okGroup, okName bool
+	dep.Group, okGroup = expand(dep.Group, props)
+	dep.Name, okName = expand(dep.Name, props)
+	// Versions do not matter for the build order, so they can stay unresolved.
+	dep.Version, _ = expand(dep.Version, props)
+	if !okGroup || !okName {
+		r.skipped = append(r.skipped, "dependency "+dep.Group+":"+dep.Name)
+		return Dependency{}, false
+	}
+	return dep, true
+}
+
+// parsePlugin understands both id 'x' version 'y' and id("x") version "y".
+func parsePlugin(toks []token) (Plugin, bool) {
+	if len(toks) < 2 || !toks[0].is(_Ident, "id") {
+		return Plugin{}, false
+	}
+	var p Plugin
+	for i, tok := range toks[1:] {
+		switch {
+		case tok.kind == _String && p.ID == "":
+			p.ID = tok.text
+		case tok.is(_Ident, "version") && i+2 < len(toks) && toks[i+2].kind == _String:
+			p.Version = toks[i+2].text
+		}
+	}
+	return p, p.ID != ""
+}
+
+func (r *buildReader) skip(sub *Subproject, st statement) {
+	var parts []string
+	for _, tok := range st.tokens {
+		parts = append(parts, tok.text)
+	}
+	r.skipped = append(r.skipped, sub.Path+" "+strings.Join(parts, " "))
+}
+
+// assigned returns the string assigned in a statement like group = 'x' or group 'x'.
+func assigned(toks []token) (string, bool) {
+	rest := toks[1:]
+	if len(rest) > 0 && rest[0].is(_Punct, "=") {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && rest[0].is(_Punct, "(") {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 || rest[0].kind != _String {
+		return "", false
+	}
+	return rest[0].text, true
+}
+
+// expand replaces references to gradle properties in a string.
+// It reports whether all of them could be resolved.
+func expand(s string, props map[string]string) (string, bool) {
+	ok := true
+	out := _PropertyRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := _PropertyRef.FindStringSubmatch(ref)[1]
+		value, present := props[name]
+		if !present {
+			ok = false
+			return ref
+		}
+		return value
+	})
+	return out, ok
+}
+
+func firstExisting(dir string, names []string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// readScript reads a script, if there is one at the path.
+func readScript(path string) ([]statement, error) {
+	if path == "" {
+		return nil, nil
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot read gradle script %s", path)
+	}
+	return parseScript(string(src)), nil
+}
+
+// readProperties reads a gradle.properties file, if there is one.
+// Only the simple key=value and key:value forms are supported.
+func readProperties(path string) (map[string]string, error) {
+	props := map[string]string{}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return props, nil
+	}
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot read %s", path)
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			continue
+		}
+		props[strings.TrimSpace(line[:sep])] = strings.TrimSpace(line[sep+1:])
+	}
+	return props, oops.Wrapf(sc.Err(), "cannot read %s", path)
+}
+
+func copyProperties(props map[string]string) map[string]string {
+	cp := make(map[string]string, len(props))
+	for k, v := range props {
+		cp[k] = v
+	}
+	return cp
+}
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gradle_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/gradle"
+)
+
+func TestReadBuildWithGroovyScripts(t *testing.T) {
+	// when
+	b, err := gradle.ReadBuild("testdata/groovy")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(b.Projects) == 3, t.Fatalf, "got %d projects, want 3", len(b.Projects))
+
+	root, api, svc := b.Projects[0], b.Projects[1], b.Projects[2]
+	assert.That(root.Path == ":" && root.Name == "billing", t.Errorf, "got root project %#v", root)
+	assert.That(api.Path == ":api" && svc.Path == ":service", t.Errorf, "got paths %s and %s", api.Path, svc.Path)
+	assert.That(svc.Dir == filepath.Join("testdata", "groovy", "svc"), t.Errorf, "got service directory %s", svc.Dir)
+	for _, sub := range b.Projects {
+		assert.That(sub.Group == "com.acme.billing", t.Errorf, "%s: got group %q", sub.Path, sub.Group)
+		assert.That(sub.Version == "2.1.0", t.Errorf, "%s: got version %q", sub.Path, sub.Version)
+	}
+
+	wantAPIDeps := []gradle.Dependency{
+		{Configuration: "testImplementation", Group: "junit", Name: "junit", Version: "4.13.2"},
+		{Configuration: "api", Group: "com.acme", Name: "shop-core", Version: "1.4.0"},
+		{Configuration: "implementation", Group: "com.acme", Name: "bom", Version: "3"},
+	}
+	assert.That(reflect.DeepEqual(api.Dependencies, wantAPIDeps), t.Errorf, "got api dependencies %#v, want %#v", api.Dependencies, wantAPIDeps)
+
+	wantSvcDeps := []gradle.Dependency{
+		{Configuration: "testImplementation", Group: "junit", Name: "junit", Version: "4.13.2"},
+		{Configuration: "implementation", Project: ":api"},
+		{Configuration: "implementation", Group: "com.acme", Name: "payments-client", Version: "2.0.0"},
+		{Configuration: "integrationTestImplementation", Group: "org.testcontainers", Name: "postgresql", Version: "1.19.0"},
+	}
+	assert.That(reflect.DeepEqual(svc.Dependencies, wantSvcDeps), t.Errorf, "got service dependencies %#v, want %#v", svc.Dependencies, wantSvcDeps)
+
+	wantPlugins := []gradle.Plugin{{ID: "java"}, {ID: "com.acme.conventions", Version: "1.0"}}
+	assert.That(reflect.DeepEqual(svc.Plugins, wantPlugins), t.Errorf, "got plugins %#v, want %#v", svc.Plugins, wantPlugins)
+
+	assert.That(len(b.Skipped) == 1, t.Errorf, "got skipped declarations %q, want just the version catalog one", b.Skipped)
+}
+
+func TestReadBuildWithKotlinScripts(t *testing.T) {
+	// when
+	b, err := gradle.ReadBuild("testdata/kotlin")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(b.Projects) == 3, t.Fatalf, "got %d projects, want 3", len(b.Projects))
+
+	core, app := b.Projects[1], b.Projects[2]
+	assert.That(core.Group == "com.acme.inventory", t.Errorf, "got core group %q", core.Group)
+	assert.That(core.Version == "0.3.0-SNAPSHOT", t.Errorf, "got core version %q", core.Version)
+	assert.That(app.Version == "1.0.0", t.Errorf, "got app version %q", app.Version)
+
+	wantCoreDeps := []gradle.Dependency{
+		{Configuration: "implementation", Group: "com.acme", Name: "shop-core", Version: "1.4.0"},
+	}
+	assert.That(reflect.DeepEqual(core.Dependencies, wantCoreDeps), t.Errorf, "got core dependencies %#v, want %#v", core.Dependencies, wantCoreDeps)
+
+	wantAppDeps := []gradle.Dependency{
+		{Configuration: "implementation", Project: ":core"},
+		{Configuration: "implementation", Group: "org.slf4j", Name: "slf4j-api", Version: "2.0.9"},
+		{Configuration: "testImplementation", Group: "org.junit.jupiter", Name: "junit-jupiter", Version: "5.10.0"},
+	}
+	assert.That(reflect.DeepEqual(app.Dependencies, wantAppDeps), t.Errorf, "got app dependencies %#v, want %#v", app.Dependencies, wantAppDeps)
+}
+
+func TestReadBuildOfASingleProject(t *testing.T) {
+	// when
+	b, err := gradle.ReadBuild("testdata/single")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(b.Projects) == 1, t.Fatalf, "got %d projects, want 1", len(b.Projects))
+	root := b.Projects[0]
+	assert.That(root.Name == "single" && root.Group == "com.acme" && root.Version == "0.1.0", t.Errorf, "got %#v", root)
+}
+
+func TestReadBuildOfSomethingElse(t *testing.T) {
+	// when
+	_, err := gradle.ReadBuild("testdata")
+
+	// then
+	assert.That(oops.Cause(err) == gradle.ErrNotGradle, t.Errorf, "got error %v, want %v", err, gradle.ErrNotGradle)
+}
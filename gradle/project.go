@@ -0,0 +1,168 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package gradle lets unibuild build gradle projects.
+//
+// The builds are analyzed by reading their settings and build scripts, which covers the common, declarative cases
+// without having to run gradle.
+package gradle
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+const _Wrapper = "gradlew"
+
+// A Config controls how gradle projects are built.
+type Config struct {
+	// Tasks to run, build and publish by default.
+	Tasks []string `json:"tasks,omitempty"`
+	// Offline keeps gradle from touching remote repositories.
+	Offline bool `json:"offline,omitempty"`
+}
+
+// DefaultTasks build and publish a project.
+func DefaultTasks() []string { return []string{"build", "publish"} }
+
+// A Project that is built using gradle.
+type Project struct {
+	name       string
+	version    string
+	clone      repo.Local
+	executable string
+	args       []string
+	uses       []unibuild.Requirement
+	builds     []unibuild.RequirementVersion
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
+
+// NewProject attempts to create a gradle project given a locally cloned repository.
+func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds gradle projects.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("gradle", 30, ErrNotGradle, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create a gradle project given a locally cloned repository.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	b, err := ReadBuild(clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem reading gradle build in %s", clone.Path)
+	}
+	for _, s := range b.Skipped {
+		clone.Log().Printf("skipped a gradle declaration that needs gradle to understand: %s", s)
+	}
+
+	builds := findBuilds(b)
+	prj := Project{
+		name:       clone.Name,
+		version:    b.Projects[0].Version,
+		clone:      clone,
+		executable: findExecutable(clone.Path),
+		args:       cfg.args(),
+		uses:       findUses(b, builds),
+		builds:     builds,
+	}
+	return prj, nil
+}
+
+func (cfg Config) args() []string {
+	args := []string{"--console=plain"}
+	if cfg.Offline {
+		args = append(args, "--offline")
+	}
+	tasks := cfg.Tasks
+	if len(tasks) == 0 {
+		tasks = DefaultTasks()
+	}
+	return append(args, tasks...)
+}
+
+// findExecutable prefers the gradle wrapper of the project, when it has one.
+func findExecutable(dir string) string {
+	if info, err := os.Stat(filepath.Join(dir, _Wrapper)); err == nil && !info.IsDir() {
+		return "./" + _Wrapper
+	}
+	return "gradle"
+}
+
+func findBuilds(b Build) []unibuild.RequirementVersion {
+	builds := make([]unibuild.RequirementVersion, 0, len(b.Projects))
+	for _, sub := range b.Projects {
+		builds = append(builds, unibuild.RequirementVersion{ID: moduleID(sub.Group, sub.Name), Version: sub.Version})
+	}
+	return builds
+}
+
+func findUses(b Build, builds []unibuild.RequirementVersion) []unibuild.Requirement {
+	own := make(map[unibuild.RequirementIdentity]bool, len(builds))
+	for _, bld := range builds {
+		own[bld.ID] = true
+	}
+
+	var all []unibuild.Requirement
+	add := func(req Requirement) {
+		if !own[req.ID()] {
+			all = append(all, req)
+		}
+	}
+
+	for _, sub := range b.Projects {
+		for _, dep := range sub.Dependencies {
+			// Project dependencies stay within the build.
+			if dep.Project == "" {
+				add(NewDependencyRequirement(dep))
+			}
+		}
+		for _, p := range sub.Plugins {
+			if !p.IsCore() {
+				add(NewPluginRequirement(p))
+			}
+		}
+	}
+	return unibuild.MergeRequirements(all)
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{
+		Name:    prj.name,
+		Version: prj.version,
+	}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion { return prj.builds }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Plan shows the gradle command the project is built with.
+func (prj Project) Plan() string {
+	return strings.Join(append([]string{prj.executable}, prj.args...), " ")
+}
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	cmd := prj.clone.CommandTo(ctx, repo.CombinedOutput(logTo), prj.executable, prj.args...)
+	err := cmd.Run()
+	return oops.Wrapf(err, "in repository at %s, gradle build failed", prj.clone.Path)
+}
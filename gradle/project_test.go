@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gradle_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/gradle"
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestProjectRequirements(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "billing"}, Path: "testdata/groovy"}
+
+	// when
+	prj, err := gradle.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Info().Version == "2.1.0", t.Errorf, "got version %q", prj.Info().Version)
+
+	wantBuilds := []string{"com.acme.billing:billing", "com.acme.billing:api", "com.acme.billing:service"}
+	assert.That(len(prj.Builds()) == len(wantBuilds), t.Fatalf, "got builds %v, want %q", prj.Builds(), wantBuilds)
+	for i, b := range prj.Builds() {
+		assert.That(b.ID.Ecosystem == maven.Ecosystem, t.Errorf, "%s: got ecosystem %q", b.ID.Name, b.ID.Ecosystem)
+		assert.That(b.ID.Name == wantBuilds[i], t.Errorf, "got build %q, want %q", b.ID.Name, wantBuilds[i])
+	}
+
+	want := map[string]unibuild.RequirementKind{
+		"junit:junit":        unibuild.Test,
+		"com.acme:shop-core": unibuild.Compile,
+		"com.acme:bom":       unibuild.Compile,
+		"com.acme.conventions:com.acme.conventions.gradle.plugin": unibuild.Compile,
+		"com.acme:payments-client":                                unibuild.Compile,
+		"org.testcontainers:postgresql":                           unibuild.Test,
+	}
+	assert.That(len(prj.Uses()) == len(want), t.Errorf, "got %d requirements, want %d", len(prj.Uses()), len(want))
+	for _, req := range prj.Uses() {
+		kind, known := want[req.ID().Name]
+		assert.That(known, t.Errorf, "unexpected requirement %s", req.ID())
+		got := unibuild.KindOf(req)
+		assert.That(got == kind, t.Errorf, "%s: got kind %s, want %s", req.ID(), got, kind)
+	}
+}
+
+func TestProjectPlan(t *testing.T) {
+	// given
+	cfg := gradle.Config{Offline: true}
+	clone := repo.Local{Remote: repo.Remote{Name: "single"}, Path: "testdata/single"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "./gradlew --console=plain --offline build publish"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func TestGradleProjectIsBuiltAfterTheMavenLibraryItUses(t *testing.T) {
+	// given
+	ctx := context.Background()
+	lib, err := maven.NewProject(ctx, repo.Local{Remote: repo.Remote{Name: "shop"}, Path: "../maven/testdata/multimodule"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	app, err := gradle.NewProject(ctx, repo.Local{Remote: repo.Remote{Name: "inventory"}, Path: "testdata/kotlin"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	ordSuite, err := unibuild.NewProjectSuite(app, lib).ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	order := ordSuite.Order()
+	assert.That(len(order) == 2, t.Fatalf, "got %d projects, want 2", len(order))
+	assert.That(order[0].Info().Name == "shop" && order[1].Info().Name == "inventory", t.Errorf,
+		"got order %s, %s", order[0].Info().Name, order[1].Info().Name)
+}
@@ -0,0 +1,37 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gradle
+
+import (
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/maven"
+)
+
+// A Requirement of a gradle project.
+// Its identity is the one of a maven module, so gradle and maven projects can use each other's artifacts.
+type Requirement struct {
+	id   unibuild.RequirementIdentity
+	kind unibuild.RequirementKind
+}
+
+var _ unibuild.KindedRequirement = Requirement{}
+
+func moduleID(group, name string) unibuild.RequirementIdentity {
+	return maven.Identity{GroupID: group, ArtifactID: name}.RequirementID()
+}
+
+// NewDependencyRequirement creates a requirement whose kind follows from the configuration of dep.
+func NewDependencyRequirement(dep Dependency) Requirement {
+	return Requirement{id: moduleID(dep.Group, dep.Name), kind: dep.Kind()}
+}
+
+// NewPluginRequirement creates a requirement on the marker artifact a plugin is resolved through.
+func NewPluginRequirement(p Plugin) Requirement {
+	return Requirement{id: moduleID(p.MarkerGroup(), p.MarkerName()), kind: unibuild.Compile}
+}
+
+func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return req.kind }
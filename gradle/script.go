@@ -0,0 +1,190 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gradle
+
+import (
+	"strings"
+)
+
+// The scripts are not evaluated.
+// They are split into statements, each remembering the blocks it is nested in, which is enough to pick out the
+// declarations that follow common conventions in both the Groovy and the Kotlin DSL.
+
+type tokenKind int
+
+const (
+	_Ident tokenKind = iota
+	_String
+	_Punct
+	_Newline
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (tok token) is(kind tokenKind, text string) bool { return tok.kind == kind && tok.text == text }
+
+// lex splits a script into tokens, dropping comments.
+// Strings are unquoted, but interpolations in them are kept as they are.
+func lex(src string) []token {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n' || c == ';':
+			toks = append(toks, token{_Newline, "\n"})
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 4
+			}
+		case c == '"' || c == '\'':
+			text, n := lexString(src[i:])
+			toks = append(toks, token{_String, text})
+			i += n
+		case isIdentByte(c):
+			j := i
+			for j < len(src) && isIdentByte(src[j]) {
+				j++
+			}
+			toks = append(toks, token{_Ident, src[i:j]})
+			i = j
+		default:
+			toks = append(toks, token{_Punct, string(c)})
+			i++
+		}
+	}
+	return toks
+}
+
+// lexString reads a quoted (possibly triple quoted) string at the start of src.
+// It returns the unquoted text and the number of bytes read.
+func lexString(src string) (string, int) {
+	quote := src[:1]
+	if strings.HasPrefix(src, strings.Repeat(quote, 3)) {
+		quote = src[:3]
+	}
+	var text strings.Builder
+	for i := len(quote); i < len(src); i++ {
+		if strings.HasPrefix(src[i:], quote) {
+			return text.String(), i + len(quote)
+		}
+		if src[i] == '\\' && i+1 < len(src) {
+			i++
+		}
+		text.WriteByte(src[i])
+	}
+	return text.String(), len(src)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || c == '$' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// A statement of a script along with the names of the blocks it is nested in, outermost first.
+type statement struct {
+	blocks []string
+	tokens []token
+}
+
+// in tells whether the statement is nested in exactly the given blocks.
+func (st statement) in(blocks ...string) bool {
+	if len(st.blocks) != len(blocks) {
+		return false
+	}
+	for i, b := range blocks {
+		if st.blocks[i] != b {
+			return false
+		}
+	}
+	return true
+}
+
+// parseScript splits a script into statements.
+// A statement ends at a newline, unless it is within parentheses or the line ends with a comma or an operator.
+// Opening a block ends the statement naming it.
+func parseScript(src string) []statement {
+	var (
+		stmts  []statement
+		blocks []string
+		cur    []token
+		parens int
+		// outer keeps the parentheses left open around each block, like for a closure passed as an argument.
+		outer []int
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			stmts = append(stmts, statement{blocks: append([]string{}, blocks...), tokens: cur})
+		}
+		cur = nil
+	}
+
+	for _, tok := range lex(src) {
+		switch {
+		case tok.is(_Punct, "(") || tok.is(_Punct, "["):
+			parens++
+			cur = append(cur, tok)
+		case tok.is(_Punct, ")") || tok.is(_Punct, "]"):
+			parens--
+			cur = append(cur, tok)
+		case tok.is(_Punct, "{"):
+			name := blockName(cur)
+			// The opening statement counts too, as in a dependency declared with a configuration closure.
+			flush()
+			blocks = append(blocks, name)
+			outer = append(outer, parens)
+			parens = 0
+		case tok.is(_Punct, "}"):
+			flush()
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+				parens = outer[len(outer)-1]
+				outer = outer[:len(outer)-1]
+			}
+		case tok.kind == _Newline:
+			if parens > 0 || continues(cur) {
+				continue
+			}
+			flush()
+		default:
+			cur = append(cur, tok)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// blockName names a block after the first identifier of the statement opening it, like dependencies or allprojects.
+func blockName(opening []token) string {
+	for _, tok := range opening {
+		if tok.kind == _Ident {
+			return tok.text
+		}
+	}
+	return ""
+}
+
+// continues tells whether a statement carries on past the end of the line.
+func continues(toks []token) bool {
+	if len(toks) == 0 {
+		return false
+	}
+	last := toks[len(toks)-1]
+	return last.kind == _Punct && strings.Contains(",=+", last.text)
+}
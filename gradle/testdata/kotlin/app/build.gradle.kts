@@ -0,0 +1,9 @@
+version = "1.0.0"
+
+dependencies {
+    implementation(project(":core"))
+    implementation("org.slf4j:slf4j-api:2.0.9") {
+        exclude(group = "org.example")
+    }
+    testImplementation(group = "org.junit.jupiter", name = "junit-jupiter", version = "5.10.0")
+}
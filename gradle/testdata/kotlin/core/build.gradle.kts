@@ -0,0 +1,6 @@
+val coreGroup = "com.acme"
+
+dependencies {
+    implementation("$coreGroup:shop-core:1.4.0")
+    testImplementation(kotlin("test"))
+}
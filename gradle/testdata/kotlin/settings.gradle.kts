@@ -0,0 +1,2 @@
+rootProject.name = "inventory"
+include(":core", ":app")
@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package junit reads and writes test reports in the JUnit XML format, as produced by the surefire and failsafe
+// maven plugins.
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// A Suite of test cases, usually all the ones from a single test class.
+type Suite struct {
+	XMLName  xml.Name `xml:"testsuite"`
+	Name     string   `xml:"name,attr"`
+	Tests    int      `xml:"tests,attr"`
+	Failures int      `xml:"failures,attr"`
+	Errors   int      `xml:"errors,attr"`
+	Skipped  int      `xml:"skipped,attr"`
+	Time     string   `xml:"time,attr,omitempty"`
+	Cases    []Case   `xml:"testcase"`
+}
+
+// A Case is the result of a single test.
+type Case struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr,omitempty"`
+	Time      string   `xml:"time,attr,omitempty"`
+	Failure   *Problem `xml:"failure,omitempty"`
+	Error     *Problem `xml:"error,omitempty"`
+	Skipped   *Problem `xml:"skipped,omitempty"`
+}
+
+// A Problem explains why a test failed, errored or was skipped.
+type Problem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Broken tells whether the test failed or errored.
+func (c Case) Broken() bool { return c.Failure != nil || c.Error != nil }
+
+// String identifies the test the way maven does in its output.
+func (c Case) String() string {
+	if c.ClassName == "" {
+		return c.Name
+	}
+	return c.ClassName + "#" + c.Name
+}
+
+// Broken lists the cases in the suite that failed or errored.
+func (s Suite) Broken() []Case {
+	var broken []Case
+	for _, c := range s.Cases {
+		if c.Broken() {
+			broken = append(broken, c)
+		}
+	}
+	return broken
+}
+
+// Broken lists the cases in all the suites that failed or errored.
+func Broken(suites []Suite) []Case {
+	var broken []Case
+	for _, s := range suites {
+		broken = append(broken, s.Broken()...)
+	}
+	return broken
+}
+
+// ReadReport reads a report file, which can hold either a single <testsuite> or several wrapped in <testsuites>.
+func ReadReport(path string) ([]Suite, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot read test report %s", path)
+	}
+
+	var multi struct {
+		XMLName xml.Name `xml:"testsuites"`
+		Suites  []Suite  `xml:"testsuite"`
+	}
+	if bytes.Contains(raw, []byte("<testsuites")) {
+		err = xml.Unmarshal(raw, &multi)
+		return multi.Suites, oops.Wrapf(err, "cannot parse test report %s", path)
+	}
+
+	var single Suite
+	err = xml.Unmarshal(raw, &single)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot parse test report %s", path)
+	}
+	return []Suite{single}, nil
+}
+
+// ReadDir reads all the TEST-*.xml reports in a directory written to no earlier than since.
+// Older reports are left out, so that ones left over from previous builds do not get mixed in.
+// A missing directory holds no reports.
+func ReadDir(dir string, since time.Time) ([]Suite, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot list test reports in %s", dir)
+	}
+
+	// Some filesystems only keep modification times with a second precision.
+	since = since.Truncate(time.Second)
+
+	var suites []Suite
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, "TEST-") || !strings.HasSuffix(name, ".xml") || e.ModTime().Before(since) {
+			continue
+		}
+		read, err := ReadReport(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, read...)
+	}
+	return suites, nil
+}
+
+// A Report aggregates suites from several projects into a single document.
+type Report struct {
+	XMLName  xml.Name `xml:"testsuites"`
+	Tests    int      `xml:"tests,attr"`
+	Failures int      `xml:"failures,attr"`
+	Errors   int      `xml:"errors,attr"`
+	Skipped  int      `xml:"skipped,attr"`
+	Suites   []Suite  `xml:"testsuite"`
+}
+
+// Add adds the suites of a project to the report.
+// The suite names get prefixed with the project name, so that the same test class in two projects can be told apart.
+func (r *Report) Add(project string, suites ...Suite) {
+	for _, s := range suites {
+		s.Name = project + "/" + s.Name
+		r.Tests += s.Tests
+		r.Failures += s.Failures
+		r.Errors += s.Errors
+		r.Skipped += s.Skipped
+		r.Suites = append(r.Suites, s)
+	}
+}
+
+// WriteTo writes the report as XML.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	buf := bytes.NewBufferString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	err := enc.Encode(r)
+	if err != nil {
+		return 0, oops.Wrapf(err, "cannot encode test report")
+	}
+	buf.WriteByte('\n')
+	return buf.WriteTo(w)
+}
+
+// WriteFile writes the report as XML to the file at path.
+func (r *Report) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return oops.Wrapf(err, "cannot create test report file %s", path)
+	}
+	_, err = r.WriteTo(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return oops.Wrapf(f.Close(), "cannot close test report file %s", path)
+}
@@ -0,0 +1,157 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package junit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/junit"
+)
+
+const _SurefireReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="com.example.CalculatorTest" tests="3" failures="1" errors="0" skipped="1" time="0.042">
+  <properties>
+    <property name="java.version" value="17"/>
+  </properties>
+  <testcase name="adds" classname="com.example.CalculatorTest" time="0.001"/>
+  <testcase name="divides" classname="com.example.CalculatorTest" time="0.040">
+    <failure message="expected: &lt;2&gt; but was: &lt;3&gt;" type="org.opentest4j.AssertionFailedError">stack trace</failure>
+  </testcase>
+  <testcase name="multiplies" classname="com.example.CalculatorTest" time="0">
+    <skipped/>
+  </testcase>
+</testsuite>
+`
+
+const _MultiSuiteReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="A" tests="1" failures="0" errors="1">
+    <testcase name="a" classname="A"><error message="boom"/></testcase>
+  </testsuite>
+  <testsuite name="B" tests="1" failures="0" errors="0">
+    <testcase name="b" classname="B"/>
+  </testsuite>
+</testsuites>
+`
+
+func TestReadReportWithSingleSuite(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeReport(t, dir, "TEST-com.example.CalculatorTest.xml", _SurefireReport)
+
+	// when
+	suites, err := junit.ReadReport(path)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(suites) == 1, t.Fatalf, "got %d suites, want 1", len(suites))
+	s := suites[0]
+	assert.That(s.Name == "com.example.CalculatorTest", t.Errorf, "got suite name %q", s.Name)
+	assert.That(s.Tests == 3 && s.Failures == 1 && s.Skipped == 1, t.Errorf, "got counts %d/%d/%d", s.Tests, s.Failures, s.Skipped)
+	assert.That(len(s.Cases) == 3, t.Fatalf, "got %d cases, want 3", len(s.Cases))
+
+	broken := s.Broken()
+	assert.That(len(broken) == 1, t.Fatalf, "got %d broken cases, want 1", len(broken))
+	want := "com.example.CalculatorTest#divides"
+	assert.That(broken[0].String() == want, t.Errorf, "got broken case %q, want %q", broken[0], want)
+	assert.That(broken[0].Failure.Message == "expected: <2> but was: <3>", t.Errorf, "got message %q", broken[0].Failure.Message)
+}
+
+func TestReadReportWithManySuites(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := writeReport(t, dir, "TEST-all.xml", _MultiSuiteReport)
+
+	// when
+	suites, err := junit.ReadReport(path)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(suites) == 2, t.Fatalf, "got %d suites, want 2", len(suites))
+	broken := junit.Broken(suites)
+	assert.That(len(broken) == 1 && broken[0].String() == "A#a", t.Errorf, "got broken cases %v", broken)
+}
+
+func TestReadDirSkipsOtherFilesAndStaleReports(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeReport(t, dir, "TEST-com.example.CalculatorTest.xml", _SurefireReport)
+	writeReport(t, dir, "com.example.CalculatorTest.txt", "not XML")
+	stale := writeReport(t, dir, "TEST-all.xml", _MultiSuiteReport)
+	old := time.Now().Add(-time.Hour)
+	err := os.Chtimes(stale, old, old)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	suites, err := junit.ReadDir(dir, time.Now().Add(-time.Minute))
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(suites) == 1, t.Fatalf, "got %d suites, want 1", len(suites))
+	assert.That(suites[0].Name == "com.example.CalculatorTest", t.Errorf, "got suite %q", suites[0].Name)
+}
+
+func TestReadDirOfMissingDirectory(t *testing.T) {
+	// when
+	suites, err := junit.ReadDir(filepath.Join("no", "such", "dir"), time.Time{})
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(len(suites) == 0, t.Errorf, "got %d suites, want none", len(suites))
+}
+
+func TestReportAggregatesProjects(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	first, err := junit.ReadReport(writeReport(t, dir, "TEST-first.xml", _SurefireReport))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	second, err := junit.ReadReport(writeReport(t, dir, "TEST-second.xml", _MultiSuiteReport))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	report := new(junit.Report)
+	report.Add("calc", first...)
+	report.Add("letters", second...)
+	path := filepath.Join(dir, "junit.xml")
+
+	// when
+	err = report.WriteFile(path)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	suites, err := junit.ReadReport(path)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(suites) == 3, t.Fatalf, "got %d suites, want 3", len(suites))
+	assert.That(suites[0].Name == "calc/com.example.CalculatorTest", t.Errorf, "got suite name %q", suites[0].Name)
+	assert.That(suites[2].Name == "letters/B", t.Errorf, "got suite name %q", suites[2].Name)
+	assert.That(report.Tests == 5 && report.Failures == 1 && report.Errors == 1, t.Errorf,
+		"got totals %d/%d/%d, want 5/1/1", report.Tests, report.Failures, report.Errors)
+
+	raw, _ := ioutil.ReadFile(path)
+	assert.That(strings.Contains(string(raw), `<testsuites tests="5"`), t.Errorf, "totals missing from\n%s", raw)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "junit")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return dir
+}
+
+func writeReport(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return path
+}
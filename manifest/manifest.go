@@ -0,0 +1,131 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package manifest lets repositories describe how unibuild should treat them, in a unibuild.toml at their root.
+//
+// A manifest can describe a project no ecosystem covers, like a documentation site or a docker image:
+//
+//	version = "1.4.0"
+//	builds = ["docker:acme/docs-site"]
+//	commands = ["make site", "make publish"]
+//
+//	[uses]
+//	compile = ["npm:@acme/design-tokens"]
+//	test = ["maven:com.acme:api-fixtures"]
+//
+// In a repository some ecosystem does cover, it extends what was found: the builds and uses are added and the
+// commands run after the usual build. With mode = "override" the fields the manifest sets replace what was found.
+package manifest
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/toml"
+)
+
+// FileName of the manifest, at the root of a repository.
+const FileName = "unibuild.toml"
+
+// How a manifest combines with what an ecosystem detector found.
+const (
+	Extend   = "extend"
+	Override = "override"
+)
+
+var (
+	ErrNoManifest  = errors.New("no " + FileName + " found")
+	ErrBadManifest = errors.New("malformed " + FileName)
+)
+
+// A Manifest as read from a unibuild.toml.
+type Manifest struct {
+	// Mode is either Extend (the default) or Override.
+	Mode     string   `json:"mode"`
+	Version  string   `json:"version"`
+	Commands []string `json:"commands"`
+	Builds   []string `json:"builds"`
+	Uses     struct {
+		Compile  []string `json:"compile"`
+		Test     []string `json:"test"`
+		Optional []string `json:"optional"`
+	} `json:"uses"`
+}
+
+// Read reads the manifest in dir.
+func Read(dir string) (Manifest, error) {
+	path := filepath.Join(dir, FileName)
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Manifest{}, oops.Wrapf(ErrNoManifest, "in %s", dir)
+	}
+	if err != nil {
+		return Manifest{}, oops.Wrapf(err, "cannot read %s", path)
+	}
+
+	var m Manifest
+	err = toml.Unmarshal(raw, &m)
+	if err != nil {
+		return Manifest{}, oops.Wrapf(err, "cannot parse %s", path)
+	}
+	if m.Mode == "" {
+		m.Mode = Extend
+	}
+	if m.Mode != Extend && m.Mode != Override {
+		return Manifest{}, oops.Wrapf(ErrBadManifest, "in %s, mode must be %s or %s, not %q", path, Extend, Override, m.Mode)
+	}
+	return m, nil
+}
+
+// BuildVersions lists what the manifest says is built, all in the version of the manifest.
+func (m Manifest) BuildVersions() ([]unibuild.RequirementVersion, error) {
+	builds := make([]unibuild.RequirementVersion, 0, len(m.Builds))
+	for _, b := range m.Builds {
+		id, err := unibuild.ParseRequirementIdentity(b)
+		if err != nil {
+			return nil, oops.Wrapf(err, "bad build in %s", FileName)
+		}
+		builds = append(builds, unibuild.RequirementVersion{ID: id, Version: m.Version})
+	}
+	return builds, nil
+}
+
+// Requirements lists what the manifest says is used, of every kind.
+func (m Manifest) Requirements() ([]unibuild.Requirement, error) {
+	var reqs []unibuild.Requirement
+	for _, group := range []struct {
+		kind unibuild.RequirementKind
+		ids  []string
+	}{
+		{unibuild.Compile, m.Uses.Compile},
+		{unibuild.Test, m.Uses.Test},
+		{unibuild.Optional, m.Uses.Optional},
+	} {
+		for _, s := range group.ids {
+			id, err := unibuild.ParseRequirementIdentity(s)
+			if err != nil {
+				return nil, oops.Wrapf(err, "bad %s requirement in %s", group.kind, FileName)
+			}
+			reqs = append(reqs, Requirement{id: id, kind: group.kind})
+		}
+	}
+	return reqs, nil
+}
+
+// A Requirement declared in a manifest.
+type Requirement struct {
+	id   unibuild.RequirementIdentity
+	kind unibuild.RequirementKind
+}
+
+var _ unibuild.KindedRequirement = Requirement{}
+
+func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return req.kind }
@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package manifest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/manifest"
+)
+
+func TestRead(t *testing.T) {
+	// when
+	m, err := manifest.Read("testdata/docs")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(m.Mode == manifest.Extend, t.Errorf, "got mode %q, want %q", m.Mode, manifest.Extend)
+	assert.That(len(m.Commands) == 2, t.Errorf, "got commands %q", m.Commands)
+
+	builds, err := m.BuildVersions()
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := unibuild.RequirementVersion{ID: unibuild.RequirementIdentity{Ecosystem: "docker", Name: "acme/docs-site"}, Version: "1.4.0"}
+	assert.That(len(builds) == 1 && builds[0] == want, t.Errorf, "got builds %v, want %v", builds, want)
+
+	reqs, err := m.Requirements()
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(reqs) == 2, t.Fatalf, "got %d requirements, want 2", len(reqs))
+	assert.That(unibuild.KindOf(reqs[1]) == unibuild.Test, t.Errorf, "got %s requirement, want a test one", unibuild.KindOf(reqs[1]))
+}
+
+func TestReadOfARepositoryWithoutAManifest(t *testing.T) {
+	// when
+	_, err := manifest.Read("testdata")
+
+	// then
+	assert.That(oops.Cause(err) == manifest.ErrNoManifest, t.Errorf, "got error %v, want %v", err, manifest.ErrNoManifest)
+}
+
+func TestReadRejectsAnUnknownMode(t *testing.T) {
+	// given
+	dir := writeManifest(t, `mode = "replace"`)
+	defer os.RemoveAll(dir)
+
+	// when
+	_, err := manifest.Read(dir)
+
+	// then
+	assert.That(oops.Cause(err) == manifest.ErrBadManifest, t.Errorf, "got error %v, want %v", err, manifest.ErrBadManifest)
+}
+
+func TestRequirementsNeedAnEcosystem(t *testing.T) {
+	// given
+	dir := writeManifest(t, "[uses]\ncompile = [\"design-tokens\"]\n")
+	defer os.RemoveAll(dir)
+	m, err := manifest.Read(dir)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	_, err = m.Requirements()
+
+	// then
+	assert.That(oops.Cause(err) == unibuild.ErrBadIdentity, t.Errorf, "got error %v, want %v", err, unibuild.ErrBadIdentity)
+}
+
+func writeManifest(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "manifest")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, manifest.FileName), []byte(content), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return dir
+}
@@ -0,0 +1,126 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package manifest
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/junit"
+	"github.com/szabba/unibuild/repo"
+)
+
+// A Project described by a manifest, possibly on top of one an ecosystem detector found.
+type Project struct {
+	clone    repo.Local
+	mode     string
+	version  string
+	commands []string
+	uses     []unibuild.Requirement
+	builds   []unibuild.RequirementVersion
+	// detected is nil when no ecosystem covers the repository.
+	detected unibuild.Project
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+	_ unibuild.Wrapper = Project{}
+)
+
+// NewProject reads the manifest of a clone and applies it to the detected project, which may be nil.
+func NewProject(ctx context.Context, clone repo.Local, detected unibuild.Project) (Project, error) {
+	m, err := Read(clone.Path)
+	if err != nil {
+		return Project{}, err
+	}
+	builds, err := m.BuildVersions()
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "in %s", clone.Path)
+	}
+	uses, err := m.Requirements()
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "in %s", clone.Path)
+	}
+
+	prj := Project{
+		clone:    clone,
+		mode:     m.Mode,
+		version:  m.Version,
+		commands: m.Commands,
+		uses:     uses,
+		builds:   builds,
+		detected: detected,
+	}
+	if detected == nil {
+		return prj, nil
+	}
+
+	if prj.version == "" {
+		prj.version = detected.Info().Version
+	}
+	if m.Mode == Extend || len(prj.uses) == 0 {
+		prj.uses = append(append([]unibuild.Requirement{}, detected.Uses()...), prj.uses...)
+	}
+	if m.Mode == Extend || len(prj.builds) == 0 {
+		prj.builds = append(append([]unibuild.RequirementVersion{}, detected.Builds()...), prj.builds...)
+	}
+	return prj, nil
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{
+		Name:    prj.clone.Name,
+		Version: prj.version,
+	}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion { return prj.builds }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Unwrap returns the detected project the manifest applies to, if there is one.
+func (prj Project) Unwrap() unibuild.Project { return prj.detected }
+
+// buildsDetected tells whether the detected project gets built, before the commands of the manifest.
+func (prj Project) buildsDetected() bool {
+	return prj.detected != nil && (prj.mode == Extend || len(prj.commands) == 0)
+}
+
+// Plan shows how the detected project is built, if it is, followed by the commands of the manifest.
+func (prj Project) Plan() string {
+	var steps []string
+	if planner, ok := prj.detected.(unibuild.Planner); ok && prj.buildsDetected() {
+		steps = append(steps, planner.Plan())
+	}
+	steps = append(steps, prj.commands...)
+	return strings.Join(steps, " && ")
+}
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	if prj.buildsDetected() {
+		err := prj.detected.Build(ctx, logTo)
+		if err != nil {
+			return err
+		}
+	}
+	return prj.clone.RunShell(ctx, logTo, prj.commands...)
+}
+
+// TestResults of the detected project, when it reports them.
+func (prj Project) TestResults() []junit.Suite {
+	tr, ok := prj.detected.(interface{ TestResults() []junit.Suite })
+	if !ok || !prj.buildsDetected() {
+		return nil
+	}
+	return tr.TestResults()
+}
@@ -0,0 +1,102 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package manifest_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/manifest"
+	"github.com/szabba/unibuild/repo"
+)
+
+// A detected project, as an ecosystem detector would find it.
+type detected struct{}
+
+func (detected) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{Name: "docs", Version: "0.9.0"}
+}
+
+func (detected) Uses() []unibuild.Requirement {
+	return []unibuild.Requirement{requirement{Ecosystem: "npm", Name: "react"}}
+}
+
+func (detected) Builds() []unibuild.RequirementVersion {
+	return []unibuild.RequirementVersion{{ID: unibuild.RequirementIdentity{Ecosystem: "npm", Name: "@acme/docs"}, Version: "0.9.0"}}
+}
+
+func (detected) Plan() string { return "npm ci" }
+
+func (detected) Build(_ context.Context, logTo io.Writer) error {
+	_, err := io.WriteString(logTo, "detected build\n")
+	return err
+}
+
+type requirement unibuild.RequirementIdentity
+
+func (req requirement) ID() unibuild.RequirementIdentity { return unibuild.RequirementIdentity(req) }
+
+func TestStandaloneProject(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "docs"}, Path: "testdata/docs"}
+
+	// when
+	prj, err := manifest.NewProject(context.Background(), clone, nil)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Info() == unibuild.ProjectInfo{Name: "docs", Version: "1.4.0"}, t.Errorf, "got %#v", prj.Info())
+	assert.That(len(prj.Builds()) == 1 && len(prj.Uses()) == 2, t.Errorf, "got builds %v and uses %v", prj.Builds(), prj.Uses())
+	assert.That(prj.Plan() == "make site && make publish", t.Errorf, "got plan %q", prj.Plan())
+	_, same := unibuild.Unwrap(prj).(manifest.Project)
+	assert.That(same, t.Errorf, "unwrapped a standalone project into %#v", unibuild.Unwrap(prj))
+}
+
+func TestManifestExtendsTheDetectedProject(t *testing.T) {
+	// given
+	dir := writeManifest(t, "builds = [\"docker:acme/docs-site\"]\ncommands = [\"echo extended\"]\n")
+	defer os.RemoveAll(dir)
+	clone := repo.Local{Remote: repo.Remote{Name: "docs"}, Path: dir}
+
+	// when
+	prj, err := manifest.NewProject(context.Background(), clone, detected{})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	var out bytes.Buffer
+	err = prj.Build(context.Background(), &out)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Info().Version == "0.9.0", t.Errorf, "got version %q", prj.Info().Version)
+	assert.That(len(prj.Builds()) == 2 && len(prj.Uses()) == 1, t.Errorf, "got builds %v and uses %v", prj.Builds(), prj.Uses())
+	assert.That(prj.Plan() == "npm ci && echo extended", t.Errorf, "got plan %q", prj.Plan())
+	assert.That(out.String() == "detected build\nextended\n", t.Errorf, "got output %q", out.String())
+	assert.That(unibuild.Unwrap(prj) == detected{}, t.Errorf, "got unwrapped %#v", unibuild.Unwrap(prj))
+}
+
+func TestManifestOverridesTheDetectedProject(t *testing.T) {
+	// given
+	dir := writeManifest(t, "mode = \"override\"\ncommands = [\"echo overridden\"]\n\n[uses]\ncompile = [\"npm:preact\"]\n")
+	defer os.RemoveAll(dir)
+	clone := repo.Local{Remote: repo.Remote{Name: "docs"}, Path: dir}
+
+	// when
+	prj, err := manifest.NewProject(context.Background(), clone, detected{})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	var out bytes.Buffer
+	err = prj.Build(context.Background(), &out)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(prj.Uses()) == 1 && prj.Uses()[0].ID().Name == "preact", t.Errorf, "got uses %v", prj.Uses())
+	assert.That(len(prj.Builds()) == 1 && prj.Builds()[0].ID.Name == "@acme/docs", t.Errorf, "got builds %v", prj.Builds())
+	assert.That(strings.TrimSpace(out.String()) == "overridden", t.Errorf, "got output %q", out.String())
+}
@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild/cache"
+	"github.com/szabba/unibuild/repo"
+)
+
+// _CacheFormat has to change whenever the way an EffectivePom is cached does.
+const _CacheFormat = "1"
+
+// _WrapperProperties pick the maven release used through the wrapper, which can affect the effective POM.
+var _WrapperProperties = filepath.Join(".mvn", "wrapper", "maven-wrapper.properties")
+
+// cachedEffectivePom returns the effective POM of the clone from the cache, analyzing the clone only when it is not
+// cached yet.
+// An entry is only reused for the same commit, the same POM contents, the same maven version and the same JDK.
+func (cfg Config) cachedEffectivePom(ctx context.Context, clone repo.Local, tc Toolchain) (EffectivePom, error) {
+	key, err := cfg.cacheKey(ctx, clone, tc)
+	if err != nil {
+		clone.Log().Printf("not caching the analysis: %s", err)
+		return cfg.readEffectivePom(ctx, clone, tc)
+	}
+
+	buf := new(bytes.Buffer)
+	err = cfg.Cache.Get(key, func() (io.Reader, error) {
+		effPom, err := cfg.readEffectivePom(ctx, clone, tc)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(effPom)
+		return bytes.NewReader(raw), oops.Wrapf(err, "cannot encode effective POM")
+	}, buf)
+	if err != nil {
+		return EffectivePom{}, err
+	}
+
+	var effPom EffectivePom
+	err = json.Unmarshal(buf.Bytes(), &effPom)
+	return effPom, oops.Wrapf(err, "cannot decode cached effective POM of %s", clone.Path)
+}
+
+func (cfg Config) cacheKey(ctx context.Context, clone repo.Local, tc Toolchain) (cache.Key, error) {
+	commit, err := clone.CurrentHash(ctx)
+	if err != nil {
+		return cache.Key{}, err
+	}
+	poms, err := hashPoms(clone.Path)
+	if err != nil {
+		return cache.Key{}, err
+	}
+	props := cache.Properties{
+		"format": _CacheFormat,
+		"commit": commit,
+		"poms":   poms,
+		"maven":  cfg.MavenVersion,
+		"jdk":    tc.JavaHome,
+	}
+	return cache.Key{Type: reflect.TypeOf(EffectivePom{}), Properties: props}, nil
+}
+
+// hashPoms hashes the paths and contents of all the POMs in the tree, along with the maven wrapper configuration.
+// That catches changes that are not committed yet.
+func hashPoms(dir string) (string, error) {
+	paths, err := PomFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	wrapper := filepath.Join(dir, _WrapperProperties)
+	if _, err := os.Stat(wrapper); err == nil {
+		paths = append(paths, wrapper)
+	}
+
+	h := sha256.New()
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", oops.Wrapf(err, "cannot hash %s", path)
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(content))
+		h.Write(content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/cache"
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+// _EffectivePomWrapper pretends to be a maven wrapper computing an effective POM.
+// It counts how many times it was run.
+const _EffectivePomWrapper = `#!/bin/sh
+echo run >> runs.txt
+for arg in "$@"; do
+  case "$arg" in
+    -Doutput=*) out="${arg#-Doutput=}" ;;
+  esac
+done
+cat > "$out" <<POM
+<project>
+  <groupId>com.acme</groupId>
+  <artifactId>billing</artifactId>
+  <version>1.0.0</version>
+  <dependencies>
+    <dependency>
+      <groupId>com.acme.payments</groupId>
+      <artifactId>payments-client</artifactId>
+    </dependency>
+  </dependencies>
+</project>
+POM
+`
+
+func TestAnalysisIsCachedUntilThePomsChange(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "analysis-cache")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "billing")
+	pom, err := ioutil.ReadFile(filepath.Join("testdata", "externalparent", "pom.xml"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	initRepo(t, path, map[string]string{"pom.xml": string(pom), "mvnw": _EffectivePomWrapper})
+
+	cfg := maven.Config{Cache: cache.At(filepath.Join(dir, "cache")), MavenVersion: "Apache Maven 3.9.6"}
+	clone := repo.Local{Remote: repo.Remote{Name: "billing"}, Path: path}
+
+	// when
+	first, firstErr := cfg.NewProject(context.Background(), clone)
+	second, secondErr := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(firstErr == nil, t.Fatalf, "unexpected error: %s", firstErr)
+	assert.That(secondErr == nil, t.Fatalf, "unexpected error: %s", secondErr)
+	assert.That(countRuns(t, path) == 1, t.Errorf, "mvn run %d times, want once", countRuns(t, path))
+	assert.That(first.Info() == second.Info(), t.Errorf, "got %#v from the cache, want %#v", second.Info(), first.Info())
+	assert.That(len(second.Uses()) == 1, t.Errorf, "got %d requirements from the cache, want 1", len(second.Uses()))
+
+	// when
+	changed := strings.Replace(string(pom), "<artifactId>billing</artifactId>", "<artifactId>invoicing</artifactId>", 1)
+	err = ioutil.WriteFile(filepath.Join(path, "pom.xml"), []byte(changed), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	_, err = cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(countRuns(t, path) == 2, t.Errorf, "mvn run %d times, want twice", countRuns(t, path))
+}
+
+// initRepo creates a git repository with a single commit adding the given files.
+func initRepo(t *testing.T, path string, files map[string]string) {
+	err := os.MkdirAll(path, 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(path, name), []byte(content), 0755)
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "--quiet", "--message", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = path
+		out, err := cmd.CombinedOutput()
+		assert.That(err == nil, t.Fatalf, "git %s: %s\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func countRuns(t *testing.T, path string) int {
+	runs, err := ioutil.ReadFile(filepath.Join(path, "runs.txt"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return strings.Count(string(runs), "run")
+}
@@ -0,0 +1,167 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild/cache"
+)
+
+// RepoConfigFile is where a repository can keep the defaults of the Invocation used to build it, relative to its root.
+// They do not apply over the Overrides of a Config.
+const RepoConfigFile = ".mvn/unibuild.json"
+
+// An Invocation describes how mvn is run to build a project.
+type Invocation struct {
+	Goals      []string          `json:"goals,omitempty"`
+	Profiles   []string          `json:"profiles,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Threads    string            `json:"threads,omitempty"`
+	Settings   string            `json:"settings,omitempty"`
+	// JavaVersion overrides the Java release the project is assumed to target when choosing a JDK.
+	JavaVersion string `json:"javaVersion,omitempty"`
+	// Offline keeps maven from touching remote repositories.
+	Offline bool `json:"offline,omitempty"`
+}
+
+// DefaultInvocation deploys a project after a clean build.
+func DefaultInvocation() Invocation {
+	return Invocation{Goals: []string{"clean", "deploy"}}
+}
+
+// Merge returns a copy of inv with the fields set in other overriding it.
+// Properties are merged key by key.
+func (inv Invocation) Merge(other Invocation) Invocation {
+	out := inv
+	if len(other.Goals) > 0 {
+		out.Goals = other.Goals
+	}
+	if len(other.Profiles) > 0 {
+		out.Profiles = other.Profiles
+	}
+	if other.Threads != "" {
+		out.Threads = other.Threads
+	}
+	if other.Settings != "" {
+		out.Settings = other.Settings
+	}
+	if other.JavaVersion != "" {
+		out.JavaVersion = other.JavaVersion
+	}
+	out.Offline = inv.Offline || other.Offline
+	out.Properties = make(map[string]string, len(inv.Properties)+len(other.Properties))
+	for k, v := range inv.Properties {
+		out.Properties[k] = v
+	}
+	for k, v := range other.Properties {
+		out.Properties[k] = v
+	}
+	return out
+}
+
+// Args returns the arguments mvn should be run with.
+func (inv Invocation) Args() []string {
+	args := []string{"-U", "-B"}
+	if inv.Offline {
+		args = []string{"-o", "-B"}
+	}
+	if inv.Settings != "" {
+		args = append(args, "-s", inv.Settings)
+	}
+	if inv.Threads != "" {
+		args = append(args, "-T", inv.Threads)
+	}
+	if len(inv.Profiles) > 0 {
+		args = append(args, "-P", strings.Join(inv.Profiles, ","))
+	}
+
+	args = append(args, inv.defineArgs()...)
+	return append(args, inv.Goals...)
+}
+
+func (inv Invocation) defineArgs() []string {
+	keys := make([]string, 0, len(inv.Properties))
+	for k := range inv.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys))
+	for _, k := range keys {
+		args = append(args, "-D"+k+"="+inv.Properties[k])
+	}
+	return args
+}
+
+// A Config controls how maven projects are built.
+type Config struct {
+	// Invocation applies to all projects.
+	Invocation Invocation `json:"invocation"`
+	// Projects overrides the invocation for individual projects, by project name.
+	Projects map[string]Invocation `json:"projects,omitempty"`
+	// Overrides apply to all projects on top of everything else, the repositories' own configuration included.
+	Overrides Invocation `json:"-"`
+	// JDKs maps Java releases to the JAVA_HOME used to build projects targeting them.
+	JDKs map[string]string `json:"jdks,omitempty"`
+	// LocalRepository, when set, replaces the user's local maven repository for all maven invocations.
+	LocalRepository string `json:"-"`
+	// SeedRepository is read through when an artifact is missing from the LocalRepository.
+	SeedRepository string `json:"-"`
+	// Offline runs all maven invocations, analysis included, without access to remote repositories.
+	Offline bool `json:"offline,omitempty"`
+	// Cache, when set, keeps the results of analyzing projects between runs.
+	Cache *cache.Cache `json:"-"`
+	// MavenVersion is the version of maven in use, on which cached analysis results depend.
+	MavenVersion string `json:"-"`
+}
+
+// invocationFor determines how to build the project in clone.
+// The defaults are overridden by the global, then per-project configuration, then by the repository's own
+// RepoConfigFile and finally by the Overrides.
+func (cfg Config) invocationFor(name, cloneDir string) (Invocation, error) {
+	inv := DefaultInvocation().Merge(cfg.Invocation).Merge(cfg.Projects[name])
+
+	repoInv, err := readRepoConfig(cloneDir)
+	if err != nil {
+		return Invocation{}, err
+	}
+	// The repository a run uses and whether it can reach remote ones are not up to the projects.
+	return inv.Merge(repoInv).Merge(cfg.Overrides).Merge(Invocation{Properties: cfg.repositoryProperties(), Offline: cfg.Offline}), nil
+}
+
+func readRepoConfig(cloneDir string) (Invocation, error) {
+	path := filepath.Join(cloneDir, filepath.FromSlash(RepoConfigFile))
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Invocation{}, nil
+	}
+	if err != nil {
+		return Invocation{}, oops.Wrapf(err, "cannot read %s", path)
+	}
+
+	var inv Invocation
+	err = json.Unmarshal(raw, &inv)
+	return inv, oops.Wrapf(err, "cannot parse %s", path)
+}
+
+func formatCommand(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,102 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestDefaultInvocationArgs(t *testing.T) {
+	// when
+	args := maven.DefaultInvocation().Args()
+
+	// then
+	want := []string{"-U", "-B", "clean", "deploy"}
+	assert.That(reflect.DeepEqual(args, want), t.Errorf, "got %q, want %q", args, want)
+}
+
+func TestInvocationArgs(t *testing.T) {
+	// given
+	inv := maven.Invocation{
+		Goals:      []string{"install"},
+		Profiles:   []string{"ci", "fast"},
+		Properties: map[string]string{"skipTests": "true", "a": "b"},
+		Threads:    "1C",
+		Settings:   "ci-settings.xml",
+	}
+
+	// when
+	args := inv.Args()
+
+	// then
+	want := []string{"-U", "-B", "-s", "ci-settings.xml", "-T", "1C", "-P", "ci,fast", "-Da=b", "-DskipTests=true", "install"}
+	assert.That(reflect.DeepEqual(args, want), t.Errorf, "got %q, want %q", args, want)
+}
+
+func TestInvocationMerge(t *testing.T) {
+	// given
+	base := maven.Invocation{
+		Goals:      []string{"clean", "deploy"},
+		Properties: map[string]string{"a": "1", "b": "2"},
+		Threads:    "4",
+	}
+	override := maven.Invocation{
+		Goals:      []string{"verify"},
+		Properties: map[string]string{"b": "3"},
+	}
+
+	// when
+	merged := base.Merge(override)
+
+	// then
+	want := maven.Invocation{
+		Goals:      []string{"verify"},
+		Properties: map[string]string{"a": "1", "b": "3"},
+		Threads:    "4",
+	}
+	assert.That(reflect.DeepEqual(merged, want), t.Errorf, "got %#v, want %#v", merged, want)
+}
+
+func TestRepositoryConfigOverridesTheGlobalOne(t *testing.T) {
+	// given
+	cfg := maven.Config{
+		Invocation: maven.Invocation{Goals: []string{"install"}, Profiles: []string{"ci"}},
+		Projects: map[string]maven.Invocation{
+			"repoconfig": {Threads: "2"},
+		},
+	}
+	clone := repo.Local{Remote: repo.Remote{Name: "repoconfig"}, Path: "testdata/repoconfig"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "mvn -U -B -T 2 -P ci -DskipITs=true verify"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func TestOverridesTakePrecedenceOverTheRepositoryConfig(t *testing.T) {
+	// given
+	cfg := maven.Config{
+		Overrides: maven.Invocation{Goals: []string{"clean", "verify"}, Properties: map[string]string{"skipITs": "false"}},
+	}
+	clone := repo.Local{Remote: repo.Remote{Name: "repoconfig"}, Path: "testdata/repoconfig"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "mvn -U -B -DskipITs=false clean verify"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
@@ -0,0 +1,65 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// IsolatedRepository returns the path of the local maven repository private to a single run.
+func IsolatedRepository(baseDir, runID string) (string, error) {
+	path, err := filepath.Abs(filepath.Join(baseDir, runID))
+	return path, oops.Wrapf(err, "cannot locate isolated repository for run %s", runID)
+}
+
+// CleanIsolatedRepositories removes all the run-private local repositories kept in baseDir.
+// It returns the paths of the removed repositories.
+func CleanIsolatedRepositories(baseDir string) ([]string, error) {
+	children, err := ioutil.ReadDir(baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot list isolated repositories in %s", baseDir)
+	}
+
+	removed := make([]string, 0, len(children))
+	for _, c := range children {
+		path := filepath.Join(baseDir, c.Name())
+		err := os.RemoveAll(path)
+		if err != nil {
+			return removed, oops.Wrapf(err, "cannot remove isolated repository %s", path)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// repositoryProperties point maven at the local repository configured for the run, if any.
+// The seed repository is used as a read-only tail of the local one (supported since maven 3.9),
+// so that artifacts built during the run are only ever written to the isolated repository.
+func (cfg Config) repositoryProperties() map[string]string {
+	props := map[string]string{}
+	if cfg.LocalRepository != "" {
+		props["maven.repo.local"] = cfg.LocalRepository
+	}
+	if cfg.LocalRepository != "" && cfg.SeedRepository != "" {
+		props["maven.repo.local.tail"] = cfg.SeedRepository
+	}
+	return props
+}
+
+// analysisArgs are the extra arguments to mvn when it is used to analyze a project.
+func (cfg Config) analysisArgs() []string {
+	args := Invocation{Properties: cfg.repositoryProperties()}.defineArgs()
+	if cfg.Offline {
+		args = append([]string{"-o"}, args...)
+	}
+	return args
+}
@@ -0,0 +1,86 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestIsolatedRepositoryIsPassedToMaven(t *testing.T) {
+	// given
+	cfg := maven.Config{
+		LocalRepository: "/work/isolated-m2/run",
+		SeedRepository:  "/home/dev/.m2/repository",
+	}
+	clone := repo.Local{Remote: repo.Remote{Name: "single"}, Path: "testdata/single"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "mvn -U -B -Dmaven.repo.local=/work/isolated-m2/run -Dmaven.repo.local.tail=/home/dev/.m2/repository clean deploy"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func TestIsolatedRepositoryCannotBeOverriddenByProjects(t *testing.T) {
+	// given
+	cfg := maven.Config{
+		LocalRepository: "/work/isolated-m2/run",
+		Projects: map[string]maven.Invocation{
+			"single": {Properties: map[string]string{"maven.repo.local": "/elsewhere"}},
+		},
+	}
+	clone := repo.Local{Remote: repo.Remote{Name: "single"}, Path: "testdata/single"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "mvn -U -B -Dmaven.repo.local=/work/isolated-m2/run clean deploy"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func TestCleanIsolatedRepositories(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "isolated")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	for _, run := range []string{"run-a", "run-b"} {
+		path, err := maven.IsolatedRepository(dir, run)
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+		err = os.MkdirAll(filepath.Join(path, "com", "acme"), 0755)
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	}
+
+	// when
+	removed, err := maven.CleanIsolatedRepositories(dir)
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(len(removed) == 2, t.Errorf, "got %d removed repositories, want %d", len(removed), 2)
+	left, _ := ioutil.ReadDir(dir)
+	assert.That(len(left) == 0, t.Errorf, "%d repositories left behind", len(left))
+}
+
+func TestCleanIsolatedRepositoriesWhenThereAreNone(t *testing.T) {
+	// when
+	removed, err := maven.CleanIsolatedRepositories("testdata/does-not-exist")
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(len(removed) == 0, t.Errorf, "got %d removed repositories, want none", len(removed))
+}
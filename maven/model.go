@@ -4,7 +4,11 @@
 
 package maven
 
-import "errors"
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+)
 
 var (
 	errNoGroupID    = errors.New("no groupId")
@@ -20,15 +24,82 @@ type EffectivePom struct {
 // An EffectiveModule contains the interesting parts of the mvn help:effective-pom output for a single-module project.
 type EffectiveModule struct {
 	Header
-	Dependencies []Identity `xml:"dependencies>dependency"`
+	Packaging    string       `xml:"packaging"`
+	Properties   Properties   `xml:"properties"`
+	Modules      []string     `xml:"modules>module"`
+	Dependencies []Dependency `xml:"dependencies>dependency"`
+
+	DependencyManagement []Dependency `xml:"dependencyManagement>dependencies>dependency"`
+	Plugins              []Plugin     `xml:"build>plugins>plugin"`
+	Extensions           []Identity   `xml:"build>extensions>extension"`
+}
+
+// BOMImports are the dependencyManagement entries that import another POM's dependencyManagement.
+func (mod EffectiveModule) BOMImports() []Dependency {
+	var imports []Dependency
+	for _, dep := range mod.DependencyManagement {
+		if dep.Scope == "import" {
+			imports = append(imports, dep)
+		}
+	}
+	return imports
+}
+
+// A Plugin used to build a maven module.
+type Plugin struct {
+	Identity
+	Dependencies []Dependency `xml:"dependencies>dependency"`
+}
+
+const _DefaultPluginGroupID = "org.apache.maven.plugins"
+
+// EffectiveIdentity of the plugin.
+// If a groupId was not specified explicitly, maven's default plugin group is used.
+func (plugin Plugin) EffectiveIdentity() Identity {
+	id := plugin.Identity
+	if id.GroupID == "" {
+		id.GroupID = _DefaultPluginGroupID
+	}
+	return id
 }
 
 // A Header corresponds to the parts of a POM that determine the identity of a maven module.
 type Header struct {
-	Parent Identity `xml:"parent"`
+	Parent ParentRef `xml:"parent"`
 	Identity
 }
 
+// A ParentRef points at the POM a module inherits from.
+type ParentRef struct {
+	Identity
+	RelativePath string `xml:"relativePath"`
+}
+
+// Properties of a POM.
+type Properties map[string]string
+
+// UnmarshalXML reads the properties from the children of a <properties> element.
+func (props *Properties) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	*props = Properties{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			var value string
+			err := dec.DecodeElement(&value, &tok)
+			if err != nil {
+				return err
+			}
+			(*props)[tok.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
 // An Identity of a maven module.
 type Identity struct {
 	GroupID    string `xml:"groupId"`
@@ -36,6 +107,35 @@ type Identity struct {
 	Version    string `xml:"version"`
 }
 
+// A Dependency of a maven module.
+type Dependency struct {
+	Identity
+	Type       string      `xml:"type"`
+	Classifier string      `xml:"classifier"`
+	Scope      string      `xml:"scope"`
+	Optional   string      `xml:"optional"`
+	Exclusions []Exclusion `xml:"exclusions>exclusion"`
+}
+
+// An Exclusion stops a transitive dependency from being pulled in through a Dependency.
+type Exclusion struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+}
+
+// IsOptional tells whether the dependency was marked as optional.
+func (dep Dependency) IsOptional() bool {
+	return strings.TrimSpace(dep.Optional) == "true"
+}
+
+// EffectiveScope is the scope of the dependency, which is compile unless specified otherwise.
+func (dep Dependency) EffectiveScope() string {
+	if dep.Scope == "" {
+		return "compile"
+	}
+	return dep.Scope
+}
+
 // Validate reports issues with the header value.
 func (head Header) Validate() error {
 
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+// _UnreachableWrapper pretends to be a maven wrapper that cannot download what it needs.
+// It records the arguments it was run with.
+const _UnreachableWrapper = `#!/bin/sh
+echo "$@" > args.txt
+exit 1
+`
+
+func TestOfflineInvocationArgs(t *testing.T) {
+	// given
+	inv := maven.DefaultInvocation().Merge(maven.Invocation{Offline: true})
+
+	// when
+	args := inv.Args()
+
+	// then
+	want := []string{"-o", "-B", "clean", "deploy"}
+	assert.That(reflect.DeepEqual(args, want), t.Errorf, "got %q, want %q", args, want)
+}
+
+func TestOfflineConfigAppliesToTheBuild(t *testing.T) {
+	// given
+	cfg := maven.Config{Offline: true}
+	clone := repo.Local{Remote: repo.Remote{Name: "single"}, Path: "testdata/single"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := "mvn -o -B clean deploy"
+	assert.That(prj.Plan() == want, t.Errorf, "got plan %q, want %q", prj.Plan(), want)
+}
+
+func TestOfflineAnalysisFailureIsReported(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "offline")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	pom, err := ioutil.ReadFile(filepath.Join("testdata", "externalparent", "pom.xml"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), pom, 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "mvnw"), []byte(_UnreachableWrapper), 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	cfg := maven.Config{Offline: true}
+	clone := repo.Local{Remote: repo.Remote{Name: "externalparent"}, Path: dir}
+
+	// when
+	_, err = cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(oops.Cause(err) == maven.ErrOffline, t.Errorf, "got error %v, want %v", err, maven.ErrOffline)
+	args, readErr := ioutil.ReadFile(filepath.Join(dir, "args.txt"))
+	assert.That(readErr == nil, t.Fatalf, "mvn was not run: %s", readErr)
+	assert.That(contains(strings.Fields(string(args)), "-o"), t.Errorf, "mvn not run offline, got args %q", args)
+}
+
+func TestAnalyzingARepositoryWithoutAPom(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "testdata"}, Path: "testdata"}
+
+	// when
+	_, err := maven.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(oops.Cause(err) == maven.ErrNotMaven, t.Errorf, "got error %v, want %v", err, maven.ErrNotMaven)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -11,15 +11,17 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 
 	"github.com/samsarahq/go/oops"
-	"github.com/szabba/unibuild/prefixio"
 
 	"github.com/szabba/unibuild/repo"
 )
 
 func ParseEffectivePomOfClone(ctx context.Context, cln repo.Local) (EffectivePom, error) {
+	return parseEffectivePomOfClone(ctx, cln, Toolchain{Executable: findExecutable(cln.Path)})
+}
+
+func parseEffectivePomOfClone(ctx context.Context, cln repo.Local, tc Toolchain, extraArgs ...string) (EffectivePom, error) {
 	tmpfile, err := ioutil.TempFile("", "effective-pom-*.xml")
 	if err != nil {
 		return EffectivePom{}, err
@@ -31,7 +33,7 @@ func ParseEffectivePomOfClone(ctx context.Context, cln repo.Local) (EffectivePom
 	}
 
 	// TODO: on Windows the path might contain spaces...
-	err = writeEffectivePomTo(ctx, cln, tmpfile.Name())
+	err = writeEffectivePomTo(ctx, cln, tc, tmpfile.Name(), extraArgs...)
 	if err != nil {
 		return EffectivePom{}, err
 	}
@@ -45,15 +47,13 @@ func ParseEffectivePomOfClone(ctx context.Context, cln repo.Local) (EffectivePom
 	return ParseEffectivePom(tmpfile)
 }
 
-func writeEffectivePomTo(ctx context.Context, cln repo.Local, dst string) error {
-	cmd := exec.CommandContext(
-		ctx,
-		"mvn", "org.apache.maven.plugins:maven-help-plugin:3.1.0:effective-pom",
-		"-Doutput="+dst)
-	cmd.Dir = cln.Path
-	out, err := cmd.CombinedOutput()
-	prefixio.NewWriter(os.Stdout, cln.Name+" | ").Write(out)
-	return err
+func writeEffectivePomTo(ctx context.Context, cln repo.Local, tc Toolchain, dst string, extraArgs ...string) error {
+	args := append([]string{
+		"org.apache.maven.plugins:maven-help-plugin:3.1.0:effective-pom",
+		"-Doutput=" + dst,
+	}, extraArgs...)
+	cmd := tc.Command(ctx, cln, repo.CombinedOutput(cln.Out()), args...)
+	return cmd.Run()
 }
 
 func ParseEffectivePom(r io.Reader) (EffectivePom, error) {
@@ -0,0 +1,253 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// ErrUnresolved is the cause of errors returned by ReadPom when a POM refers to something only maven can resolve.
+var ErrUnresolved = errors.New("cannot resolve POM without maven")
+
+const (
+	_PomFile         = "pom.xml"
+	_MaxParentDepth  = 32
+	_MaxPropertyHops = 32
+)
+
+var _PropertyRef = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// ReadPom reads the POM of the project in dir, along with all the modules it aggregates, without running maven.
+//
+// Properties (including ${project.*} ones) are interpolated and inherited from parent POMs found in the same tree.
+// When a value that matters for the build order cannot be resolved, or a parent POM lives outside of the tree,
+// the returned error's cause is ErrUnresolved.
+func ReadPom(dir string) (EffectivePom, error) {
+	r := pomReader{root: filepath.Clean(dir), seen: map[string]bool{}}
+	err := r.readTree(filepath.Join(dir, _PomFile))
+	if err != nil {
+		return EffectivePom{}, err
+	}
+	return EffectivePom{Projects: r.modules}, nil
+}
+
+type pomReader struct {
+	root    string
+	modules []EffectiveModule
+	seen    map[string]bool
+}
+
+func (r *pomReader) readTree(path string) error {
+	path = filepath.Clean(path)
+	if r.seen[path] {
+		return nil
+	}
+	r.seen[path] = true
+
+	mod, _, err := r.readPomFile(path, 0)
+	if err != nil {
+		return err
+	}
+	r.modules = append(r.modules, mod)
+
+	for _, m := range mod.Modules {
+		err := r.readTree(modulePomPath(filepath.Dir(path), m))
+		if err != nil {
+			return oops.Wrapf(err, "problem reading module %s of %s", m, path)
+		}
+	}
+	return nil
+}
+
+func modulePomPath(dir, ref string) string {
+	path := filepath.Join(dir, filepath.FromSlash(ref))
+	if strings.HasSuffix(path, ".xml") {
+		return path
+	}
+	return filepath.Join(path, _PomFile)
+}
+
+// readPomFile reads and interpolates a single POM file.
+// Alongside the module it returns the properties visible to modules inheriting from it.
+// Those are left uninterpolated, as maven evaluates them in the context of the inheriting module.
+func (r *pomReader) readPomFile(path string, depth int) (EffectiveModule, Properties, error) {
+	if depth > _MaxParentDepth {
+		return EffectiveModule{}, nil, oops.Errorf("parent chain of %s is too long", path)
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return EffectiveModule{}, nil, oops.Wrapf(err, "cannot read POM %s", path)
+	}
+
+	var mod EffectiveModule
+	err = xml.Unmarshal(raw, &mod)
+	if err != nil {
+		return EffectiveModule{}, nil, oops.Wrapf(err, "cannot parse POM %s", path)
+	}
+
+	props, err := r.parentProperties(path, mod.Parent, depth)
+	if err != nil {
+		return EffectiveModule{}, nil, err
+	}
+	for k, v := range mod.Properties {
+		props[k] = v
+	}
+	inherited := props.copy()
+	props.addBuiltins(path, mod.Header)
+
+	var resolved EffectiveModule
+	err = xml.Unmarshal(props.interpolate(raw), &resolved)
+	if err != nil {
+		return EffectiveModule{}, nil, oops.Wrapf(err, "cannot parse interpolated POM %s", path)
+	}
+
+	err = resolved.checkResolved()
+	if err != nil {
+		return EffectiveModule{}, nil, oops.Wrapf(err, "in POM %s", path)
+	}
+	return resolved, inherited, nil
+}
+
+// parentProperties returns the properties inherited from the parent POM.
+// A parent that cannot be found in the same tree is reported as an ErrUnresolved,
+// as whatever the module inherits from it would be missing.
+func (r *pomReader) parentProperties(path string, parent ParentRef, depth int) (Properties, error) {
+	if parent.ArtifactID == "" {
+		return Properties{}, nil
+	}
+
+	relPath := parent.RelativePath
+	if relPath == "" {
+		relPath = "../" + _PomFile
+	}
+	parentPath := modulePomPath(filepath.Dir(path), relPath)
+
+	external := oops.Wrapf(ErrUnresolved, "parent %s:%s of %s is outside of the tree", parent.GroupID, parent.ArtifactID, path)
+	if rel, err := filepath.Rel(r.root, parentPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, external
+	}
+	_, err := os.Stat(parentPath)
+	if os.IsNotExist(err) {
+		return nil, external
+	}
+
+	parentMod, props, err := r.readPomFile(parentPath, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if parentMod.EffectiveGroupID() != parent.GroupID || parentMod.EffectiveArtifactID() != parent.ArtifactID {
+		// The relative path leads to some other POM, so the parent lives outside of the tree.
+		return nil, external
+	}
+	return props, nil
+}
+
+func (props Properties) copy() Properties {
+	cp := make(Properties, len(props))
+	for k, v := range props {
+		cp[k] = v
+	}
+	return cp
+}
+
+func (props Properties) addBuiltins(path string, head Header) {
+	basedir := filepath.Dir(path)
+	builtins := map[string]string{
+		"project.groupId":           head.EffectiveGroupID(),
+		"project.artifactId":        head.EffectiveArtifactID(),
+		"project.version":           head.EffectiveVersion(),
+		"project.parent.groupId":    head.Parent.GroupID,
+		"project.parent.artifactId": head.Parent.ArtifactID,
+		"project.parent.version":    head.Parent.Version,
+		"project.basedir":           basedir,
+		"basedir":                   basedir,
+		"pom.groupId":               head.EffectiveGroupID(),
+		"pom.artifactId":            head.EffectiveArtifactID(),
+		"pom.version":               head.EffectiveVersion(),
+		"version":                   head.EffectiveVersion(),
+	}
+	for k, v := range builtins {
+		if v != "" {
+			props[k] = v
+		}
+	}
+}
+
+// interpolate replaces property references in XML text with their values.
+// References to unknown properties are left as they are.
+func (props Properties) interpolate(text []byte) []byte {
+	return _PropertyRef.ReplaceAllFunc(text, func(ref []byte) []byte {
+		name := string(ref[2 : len(ref)-1])
+		value, ok := props.lookup(name, 0)
+		if !ok {
+			return ref
+		}
+		buf := new(bytes.Buffer)
+		xml.EscapeText(buf, []byte(value))
+		return buf.Bytes()
+	})
+}
+
+func (props Properties) lookup(name string, hops int) (string, bool) {
+	value, ok := props[name]
+	if !ok || hops > _MaxPropertyHops {
+		return "", false
+	}
+	if !_PropertyRef.MatchString(value) {
+		return value, true
+	}
+	resolved := _PropertyRef.ReplaceAllStringFunc(value, func(ref string) string {
+		inner, ok := props.lookup(ref[2:len(ref)-1], hops+1)
+		if !ok {
+			return ref
+		}
+		return inner
+	})
+	return resolved, true
+}
+
+// checkResolved reports an ErrUnresolved if any of the values that matter for the build order
+// still contain property references.
+// Versions of what the module uses do not matter, as requirements are matched by groupId and artifactId only.
+func (mod EffectiveModule) checkResolved() error {
+	own := mod.EffectiveIdentity()
+	values := []string{own.GroupID, own.ArtifactID, own.Version}
+	for _, id := range mod.used() {
+		values = append(values, id.GroupID, id.ArtifactID)
+	}
+	for _, value := range values {
+		if _PropertyRef.MatchString(value) {
+			return oops.Wrapf(ErrUnresolved, "unresolved property in %q", value)
+		}
+	}
+	return nil
+}
+
+func (mod EffectiveModule) used() []Identity {
+	ids := []Identity{mod.Parent.Identity}
+	for _, dep := range mod.Dependencies {
+		ids = append(ids, dep.Identity)
+	}
+	for _, dep := range mod.BOMImports() {
+		ids = append(ids, dep.Identity)
+	}
+	for _, plugin := range mod.Plugins {
+		ids = append(ids, plugin.EffectiveIdentity())
+		for _, dep := range plugin.Dependencies {
+			ids = append(ids, dep.Identity)
+		}
+	}
+	return append(ids, mod.Extensions...)
+}
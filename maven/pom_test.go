@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+)
+
+func TestReadPomOfSingleModule(t *testing.T) {
+	// when
+	pom, err := maven.ReadPom("testdata/single")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(pom.Projects) == 1, t.Fatalf, "got %d projects, want %d", len(pom.Projects), 1)
+
+	mod := pom.Projects[0]
+	want := maven.Identity{GroupID: "com.acme", ArtifactID: "strings", Version: "2.0"}
+	assert.That(mod.EffectiveIdentity() == want, t.Errorf, "got identity %#v, want %#v", mod.EffectiveIdentity(), want)
+	assert.That(len(mod.Dependencies) == 2, t.Fatalf, "got %d dependencies, want %d", len(mod.Dependencies), 2)
+
+	junit := mod.Dependencies[0].Version
+	assert.That(junit == "4.12", t.Errorf, "got junit version %q, want %q", junit, "4.12")
+}
+
+func TestReadPomOfMultiModuleProject(t *testing.T) {
+	// when
+	pom, err := maven.ReadPom("testdata/multimodule")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(pom.Projects) == 3, t.Fatalf, "got %d projects, want %d", len(pom.Projects), 3)
+
+	wantIDs := []maven.Identity{
+		{GroupID: "com.acme", ArtifactID: "shop-parent", Version: "1.4.0-SNAPSHOT"},
+		{GroupID: "com.acme", ArtifactID: "shop-core", Version: "1.4.0-SNAPSHOT"},
+		{GroupID: "com.acme", ArtifactID: "shop-app", Version: "1.4.0-SNAPSHOT"},
+	}
+	for i, want := range wantIDs {
+		got := pom.Projects[i].EffectiveIdentity()
+		assert.That(got == want, t.Errorf, "project %d: got identity %#v, want %#v", i, got, want)
+	}
+
+	coreDep := pom.Projects[1].Dependencies[0]
+	assert.That(coreDep.GroupID == "com.acme.utils", t.Errorf, "inherited property not interpolated: %#v", coreDep)
+
+	appDep := pom.Projects[2].Dependencies[0].Identity
+	wantDep := maven.Identity{GroupID: "com.acme", ArtifactID: "shop-core", Version: "1.4.0-SNAPSHOT"}
+	assert.That(appDep == wantDep, t.Errorf, "got dependency %#v, want %#v", appDep, wantDep)
+}
+
+func TestReadPomReportsPropertiesOnlyMavenCanResolve(t *testing.T) {
+	// when
+	_, err := maven.ReadPom("testdata/unresolved")
+
+	// then
+	assert.That(oops.Cause(err) == maven.ErrUnresolved, t.Errorf, "got error %v, want %v", err, maven.ErrUnresolved)
+}
+
+func TestReadPomReportsParentsOutsideOfTheTree(t *testing.T) {
+	// when
+	_, err := maven.ReadPom("testdata/externalparent")
+
+	// then
+	assert.That(oops.Cause(err) == maven.ErrUnresolved, t.Errorf, "got error %v, want %v", err, maven.ErrUnresolved)
+}
+
+func TestReadPomReportsParentsAboveTheTree(t *testing.T) {
+	// when
+	_, err := maven.ReadPom("testdata/multimodule/app")
+
+	// then
+	assert.That(oops.Cause(err) == maven.ErrUnresolved, t.Errorf, "got error %v, want %v", err, maven.ErrUnresolved)
+}
+
+func TestReadPomWithoutAPom(t *testing.T) {
+	// when
+	_, err := maven.ReadPom("testdata")
+
+	// then
+	assert.That(err != nil, t.Errorf, "got no error when one is expected")
+}
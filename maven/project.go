@@ -6,28 +6,70 @@ package maven
 
 import (
 	"context"
+	"errors"
 	"io"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/samsarahq/go/oops"
 
 	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/junit"
 	"github.com/szabba/unibuild/repo"
 )
 
+var (
+	// ErrNotMaven is the cause of errors returned when asked to analyze a repository that has no POM.
+	ErrNotMaven = errors.New("not a maven project")
+	// ErrOffline is the cause of errors returned when a project cannot be analyzed without network access.
+	ErrOffline = errors.New("cannot analyze project offline")
+)
+
 // A Project that is built using maven.
 type Project struct {
-	name    string
-	version string
-	clone   repo.Local
-	uses    []unibuild.Requirement
-	builds  []unibuild.RequirementVersion
+	name       string
+	version    string
+	clone      repo.Local
+	invocation Invocation
+	toolchain  Toolchain
+	uses       []unibuild.Requirement
+	builds     []unibuild.RequirementVersion
+	results    *testResults
 }
 
-var _ unibuild.Project = Project{}
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
 
 // NewProject attempts to create a maven project given a locally cloned repository.
+// The project is built with the DefaultInvocation, unless the repository configures it otherwise.
 func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
-	effPom, err := ParseEffectivePomOfClone(ctx, clone)
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds maven projects, ahead of the other ecosystems, as a maven build may also carry other build files.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("maven", 40, ErrNotMaven, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create a maven project given a locally cloned repository.
+//
+// The POMs are read directly when possible.
+// Only when that fails is the effective POM computed by running mvn.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	inv, err := cfg.invocationFor(clone.Name, clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem configuring the build of %s", clone.Path)
+	}
+
+	// The toolchain is chosen up front, so that mvn analyzes the project with the same JDK it gets built with.
+	tc := cfg.findToolchain(clone.Path, inv)
+
+	effPom, err := cfg.analyzeClone(ctx, clone, tc)
 	if err != nil {
 		return Project{}, oops.Wrapf(err, "problem scanning effective POM in %s", clone.Path)
 	}
@@ -36,28 +78,61 @@ func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
 		return Project{}, oops.Errorf("effective POM invalid: has no projects")
 	}
 
+	if tc.JavaVersion != "" && tc.JavaHome == "" && len(cfg.JDKs) > 0 {
+		clone.Log().Printf("no JDK configured for Java %s, using the inherited JAVA_HOME", tc.JavaVersion)
+	}
+
 	builds := findBuilds(effPom)
 
 	prj := Project{
-		name:    clone.Name,
-		version: effPom.Projects[0].EffectiveVersion(),
-		clone:   clone,
-		uses:    findUses(effPom, builds),
-		builds:  builds,
+		name:       clone.Name,
+		version:    effPom.Projects[0].EffectiveVersion(),
+		clone:      clone,
+		invocation: inv,
+		toolchain:  tc,
+		uses:       findUses(effPom, builds),
+		builds:     builds,
+		results:    new(testResults),
 	}
 
 	return prj, nil
 }
 
+func (cfg Config) analyzeClone(ctx context.Context, clone repo.Local, tc Toolchain) (EffectivePom, error) {
+	_, err := os.Stat(filepath.Join(clone.Path, _PomFile))
+	if os.IsNotExist(err) {
+		return EffectivePom{}, oops.Wrapf(ErrNotMaven, "no %s in %s", _PomFile, clone.Path)
+	}
+	if err != nil {
+		return EffectivePom{}, oops.Wrapf(err, "cannot find POM")
+	}
+
+	if cfg.Cache != nil {
+		return cfg.cachedEffectivePom(ctx, clone, tc)
+	}
+	return cfg.readEffectivePom(ctx, clone, tc)
+}
+
+// readEffectivePom reads the POMs directly when possible and only runs mvn when that fails.
+func (cfg Config) readEffectivePom(ctx context.Context, clone repo.Local, tc Toolchain) (EffectivePom, error) {
+	effPom, nativeErr := ReadPom(clone.Path)
+	if nativeErr == nil {
+		return effPom, nil
+	}
+	clone.Log().Printf("falling back to mvn to analyze the project: %s", oops.Cause(nativeErr))
+	effPom, err := parseEffectivePomOfClone(ctx, clone, tc, cfg.analysisArgs()...)
+	if err != nil && cfg.Offline {
+		return EffectivePom{}, oops.Wrapf(ErrOffline, "reading the POMs failed (%s) and so did mvn -o (%s)", nativeErr, err)
+	}
+	return effPom, err
+}
+
 func findBuilds(effPom EffectivePom) []unibuild.RequirementVersion {
 	builds := make([]unibuild.RequirementVersion, 0, len(effPom.Projects))
 	for _, prj := range effPom.Projects {
 		bld := unibuild.RequirementVersion{
-			ID: unibuild.RequirementIdentity{
-				Name: prj.EffectiveGroupID() + ":" + prj.EffectiveArtifactID(),
-			},
-			// TODO: Handle versioning
-			// Version: h.EffectiveVersion(),
+			ID:      prj.EffectiveIdentity().RequirementID(),
+			Version: prj.EffectiveVersion(),
 		}
 		builds = append(builds, bld)
 	}
@@ -65,22 +140,40 @@ func findBuilds(effPom EffectivePom) []unibuild.RequirementVersion {
 }
 
 func findUses(effPom EffectivePom, builds []unibuild.RequirementVersion) []unibuild.Requirement {
-	all := make(map[unibuild.Requirement]bool)
+	var all []unibuild.Requirement
 	for _, prj := range effPom.Projects {
-		for _, dep := range prj.Dependencies {
-			req := NewRequirement(dep)
-			if isSatisifed(req, builds) {
-				continue
+		for _, req := range moduleRequirements(prj) {
+			if !isSatisifed(req, builds) {
+				all = append(all, req)
 			}
-			all[req] = true
 		}
 	}
+	return unibuild.MergeRequirements(all)
+}
 
-	out := make([]unibuild.Requirement, 0, len(all))
-	for req := range all {
-		out = append(out, req)
+// moduleRequirements lists everything a module needs built before it can be built itself.
+// Besides dependencies that includes the parent POM, imported BOMs, build plugins (with their dependencies) and extensions.
+func moduleRequirements(mod EffectiveModule) []Requirement {
+	var reqs []Requirement
+	if mod.Parent.ArtifactID != "" {
+		reqs = append(reqs, NewRequirement(mod.Parent.Identity))
+	}
+	for _, dep := range mod.Dependencies {
+		reqs = append(reqs, NewDependencyRequirement(dep))
+	}
+	for _, bom := range mod.BOMImports() {
+		reqs = append(reqs, NewRequirement(bom.Identity))
+	}
+	for _, plugin := range mod.Plugins {
+		reqs = append(reqs, NewRequirement(plugin.EffectiveIdentity()))
+		for _, dep := range plugin.Dependencies {
+			reqs = append(reqs, NewRequirement(dep.Identity))
+		}
 	}
-	return out
+	for _, ext := range mod.Extensions {
+		reqs = append(reqs, NewRequirement(ext))
+	}
+	return reqs
 }
 
 func isSatisifed(req unibuild.Requirement, builds []unibuild.RequirementVersion) bool {
@@ -102,7 +195,35 @@ func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
 
 func (prj Project) Builds() []unibuild.RequirementVersion { return prj.builds }
 
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Toolchain the project is built with.
+func (prj Project) Toolchain() Toolchain { return prj.toolchain }
+
+// Plan shows the maven command the project is built with.
+func (prj Project) Plan() string { return prj.toolchain.Describe(prj.invocation.Args()...) }
+
+// TestResults are the surefire and failsafe results of the latest build of the project.
+func (prj Project) TestResults() []junit.Suite { return prj.results.get() }
+
+// Build runs maven and collects the test results it reports.
+// When the build fails, the error names the tests that broke.
 func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
-	err := prj.clone.Run(ctx, "mvn", "-U", "-B", "clean", "deploy")
+	start := time.Now()
+	cmd := prj.toolchain.Command(ctx, prj.clone, repo.CombinedOutput(logTo), prj.invocation.Args()...)
+	err := cmd.Run()
+
+	suites, repErr := TestReports(prj.clone.Path, start)
+	if repErr != nil {
+		prj.clone.Log().Printf("problem collecting test results: %s", repErr)
+	}
+	prj.results.set(suites)
+
+	broken := junit.Broken(suites)
+	if err != nil && len(broken) > 0 {
+		return oops.Wrapf(err, "in repository at %s, maven build failed with %d broken tests: %s",
+			prj.clone.Path, len(broken), describeBroken(broken))
+	}
 	return oops.Wrapf(err, "in repository at %s, maven build failed", prj.clone.Path)
 }
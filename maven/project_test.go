@@ -0,0 +1,132 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestProjectRequirementKindsFollowDependencyScopes(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "scopes"}, Path: "testdata/scopes"}
+
+	// when
+	prj, err := maven.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	want := map[string]unibuild.RequirementKind{
+		"com.acme:money":         unibuild.Compile,
+		"com.acme:test-fixtures": unibuild.Test,
+		"com.acme:metrics":       unibuild.Optional,
+	}
+	uses := prj.Uses()
+	assert.That(len(uses) == len(want), t.Fatalf, "got %d requirements, want %d", len(uses), len(want))
+	for _, req := range uses {
+		kind, known := want[req.ID().Name]
+		assert.That(known, t.Errorf, "unexpected requirement %s", req.ID().Name)
+		got := unibuild.KindOf(req)
+		assert.That(got == kind, t.Errorf, "%s: got kind %s, want %s", req.ID().Name, got, kind)
+	}
+}
+
+func TestDependencyDetailsAreParsed(t *testing.T) {
+	// when
+	pom, err := maven.ReadPom("testdata/scopes")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	deps := pom.Projects[0].Dependencies
+	assert.That(len(deps) == 4, t.Fatalf, "got %d dependencies, want %d", len(deps), 4)
+
+	money := deps[0]
+	assert.That(money.EffectiveScope() == "compile", t.Errorf, "got scope %q, want %q", money.EffectiveScope(), "compile")
+	assert.That(len(money.Exclusions) == 1, t.Errorf, "got %d exclusions, want %d", len(money.Exclusions), 1)
+
+	fixtures := deps[1]
+	assert.That(fixtures.Type == "test-jar", t.Errorf, "got type %q, want %q", fixtures.Type, "test-jar")
+	assert.That(fixtures.Classifier == "tests", t.Errorf, "got classifier %q, want %q", fixtures.Classifier, "tests")
+
+	assert.That(deps[2].IsOptional(), t.Errorf, "metrics dependency not optional")
+}
+
+func TestDependencyRequirementKindsFollowScopeAndOptionality(t *testing.T) {
+	for _, tt := range []struct {
+		scope, optional string
+		want            unibuild.RequirementKind
+	}{
+		{"", "", unibuild.Compile},
+		{"runtime", "true", unibuild.Optional},
+		{"test", "", unibuild.Test},
+		{"test", "true", unibuild.Test},
+	} {
+		// given
+		dep := maven.Dependency{Identity: maven.Identity{GroupID: "com.acme", ArtifactID: "metrics"}, Scope: tt.scope, Optional: tt.optional}
+
+		// when
+		got := maven.NewDependencyRequirement(dep).Kind()
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "scope %q, optional %q: got kind %s, want %s", tt.scope, tt.optional, got, tt.want)
+	}
+}
+
+// _CopyPomWrapper pretends to be a maven wrapper computing an effective POM that is just the POM itself.
+const _CopyPomWrapper = `#!/bin/sh
+for arg in "$@"; do
+  case "$arg" in
+    -Doutput=*) out="${arg#-Doutput=}" ;;
+  esac
+done
+cp pom.xml "$out"
+`
+
+func TestProjectUsesParentBOMsPluginsAndExtensions(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "buildedges")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	pom, err := ioutil.ReadFile(filepath.Join("testdata", "buildedges", "pom.xml"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), pom, 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	// The parent lives outside of the repository, so only maven can tell what the project inherits from it.
+	err = ioutil.WriteFile(filepath.Join(dir, "mvnw"), []byte(_CopyPomWrapper), 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	clone := repo.Local{Remote: repo.Remote{Name: "buildedges"}, Path: dir}
+
+	// when
+	prj, err := maven.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	want := map[string]bool{
+		"com.acme:corporate-parent":                        true,
+		"com.acme:platform-bom":                            true,
+		"com.acme:wagon-acme":                              true,
+		"org.apache.maven.plugins:maven-checkstyle-plugin": true,
+		"com.acme:checkstyle-rules":                        true,
+		"com.acme:codegen-maven-plugin":                    true,
+	}
+	uses := prj.Uses()
+	assert.That(len(uses) == len(want), t.Errorf, "got %d requirements, want %d", len(uses), len(want))
+	for _, req := range uses {
+		assert.That(want[req.ID().Name], t.Errorf, "unexpected requirement %s", req.ID().Name)
+	}
+}
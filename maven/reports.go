@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild/junit"
+)
+
+const (
+	_TargetDir = "target"
+	// _MaxListedBroken limits how many broken tests get named in a build error.
+	_MaxListedBroken = 10
+)
+
+// _ReportDirs are the directories within target the surefire and failsafe plugins write their reports to.
+var _ReportDirs = []string{"surefire-reports", "failsafe-reports"}
+
+// TestReports reads the surefire and failsafe reports of all the modules in the tree rooted at dir.
+// Only reports written no earlier than since are included.
+func TestReports(dir string, since time.Time) ([]junit.Suite, error) {
+	var suites []junit.Suite
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == dir {
+			return nil
+		}
+		if info.Name()[0] == '.' {
+			return filepath.SkipDir
+		}
+		if info.Name() != _TargetDir {
+			return nil
+		}
+		for _, rd := range _ReportDirs {
+			read, err := junit.ReadDir(filepath.Join(path, rd), since)
+			if err != nil {
+				return err
+			}
+			suites = append(suites, read...)
+		}
+		return filepath.SkipDir
+	})
+	return suites, oops.Wrapf(err, "cannot read test reports in %s", dir)
+}
+
+// testResults keeps the results of the latest build of a project.
+type testResults struct {
+	lock   sync.Mutex
+	suites []junit.Suite
+}
+
+func (tr *testResults) set(suites []junit.Suite) {
+	if tr == nil {
+		return
+	}
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	tr.suites = suites
+}
+
+func (tr *testResults) get() []junit.Suite {
+	if tr == nil {
+		return nil
+	}
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	return tr.suites
+}
+
+// describeBroken names the broken tests, up to a limit.
+func describeBroken(broken []junit.Case) string {
+	names := make([]string, 0, _MaxListedBroken)
+	for i, c := range broken {
+		if i == _MaxListedBroken {
+			names = append(names, fmt.Sprintf("and %d more", len(broken)-i))
+			break
+		}
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+// _FailingWrapper pretends to be a maven wrapper whose build fails on a unit and an integration test.
+const _FailingWrapper = `#!/bin/sh
+mkdir -p target/surefire-reports core/target/failsafe-reports
+cat > target/surefire-reports/TEST-com.example.UnitTest.xml <<REPORT
+<testsuite name="com.example.UnitTest" tests="2" failures="1" errors="0" skipped="0">
+  <testcase name="passes" classname="com.example.UnitTest"/>
+  <testcase name="fails" classname="com.example.UnitTest"><failure message="nope"/></testcase>
+</testsuite>
+REPORT
+cat > core/target/failsafe-reports/TEST-com.example.IT.xml <<REPORT
+<testsuite name="com.example.IT" tests="1" failures="0" errors="1" skipped="0">
+  <testcase name="talksToDatabase" classname="com.example.IT"><error message="no database"/></testcase>
+</testsuite>
+REPORT
+exit 1
+`
+
+func TestBuildCollectsTestResults(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "reports")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	pom, err := ioutil.ReadFile(filepath.Join("testdata", "single", "pom.xml"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), pom, 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "mvnw"), []byte(_FailingWrapper), 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	clone := repo.Local{Remote: repo.Remote{Name: "reports"}, Path: dir}
+	prj, err := maven.NewProject(context.Background(), clone)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	err = prj.Build(context.Background(), ioutil.Discard)
+
+	// then
+	assert.That(err != nil, t.Fatalf, "missing error")
+	for _, name := range []string{"com.example.UnitTest#fails", "com.example.IT#talksToDatabase"} {
+		assert.That(strings.Contains(err.Error(), name), t.Errorf, "broken test %s not named in error: %s", name, err)
+	}
+	assert.That(!strings.Contains(err.Error(), "passes"), t.Errorf, "passing test named in error: %s", err)
+	assert.That(len(prj.TestResults()) == 2, t.Errorf, "got %d suites, want 2", len(prj.TestResults()))
+}
+
+// _BrokenReportWrapper pretends to be a maven wrapper whose build succeeds, but leaves a report that cannot be read.
+const _BrokenReportWrapper = `#!/bin/sh
+mkdir -p target/surefire-reports
+echo '<testsuite' > target/surefire-reports/TEST-com.example.UnitTest.xml
+`
+
+func TestBuildWithoutALogWriterSurvivesUnreadableReports(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "reports")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	pom, err := ioutil.ReadFile(filepath.Join("testdata", "single", "pom.xml"))
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), pom, 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "mvnw"), []byte(_BrokenReportWrapper), 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	clone := repo.Local{Remote: repo.Remote{Name: "reports"}, Path: dir}
+	prj, err := maven.NewProject(context.Background(), clone)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	err = prj.Build(context.Background(), nil)
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(len(prj.TestResults()) == 0, t.Errorf, "got %d suites, want none", len(prj.TestResults()))
+}
+
+func TestTestReportsIgnoresReportsFromEarlierBuilds(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "reports")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	reports := filepath.Join(dir, "target", "surefire-reports")
+	err = os.MkdirAll(reports, 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	path := filepath.Join(reports, "TEST-Old.xml")
+	err = ioutil.WriteFile(path, []byte(`<testsuite name="Old" tests="1"><testcase name="x"/></testsuite>`), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	old := time.Now().Add(-time.Hour)
+	err = os.Chtimes(path, old, old)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	suites, err := maven.TestReports(dir, time.Now().Add(-time.Minute))
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(suites) == 0, t.Errorf, "got %d suites, want none", len(suites))
+}
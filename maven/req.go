@@ -8,18 +8,41 @@ import (
 	"github.com/szabba/unibuild"
 )
 
+// Ecosystem of the requirements of maven projects.
+// Anything else that consumes artifacts from maven repositories, like gradle, shares it.
+const Ecosystem = "maven"
+
+// RequirementID identifies the module as a requirement, irrespective of its version.
+func (id Identity) RequirementID() unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: id.GroupID + ":" + id.ArtifactID}
+}
+
 type Requirement struct {
-	id unibuild.RequirementIdentity
+	id   unibuild.RequirementIdentity
+	kind unibuild.RequirementKind
 }
 
 func NewRequirement(id Identity) Requirement {
 	return Requirement{
-		id: unibuild.RequirementIdentity{
-			Name: id.GroupID + ":" + id.ArtifactID,
-		},
+		id:   id.RequirementID(),
+		kind: unibuild.Compile,
+	}
+}
+
+// NewDependencyRequirement creates a requirement whose kind follows from the scope and optionality of dep.
+func NewDependencyRequirement(dep Dependency) Requirement {
+	req := NewRequirement(dep.Identity)
+	if dep.EffectiveScope() == "test" {
+		req.kind = unibuild.Test
 	}
+	if dep.IsOptional() {
+		req.kind = req.kind.Weaker(unibuild.Optional)
+	}
+	return req
 }
 
-var _ unibuild.Requirement = Requirement{}
+var _ unibuild.KindedRequirement = Requirement{}
 
 func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return req.kind }
@@ -0,0 +1,302 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// A VersionMap says which version each module, identified by its groupId:artifactId, should have.
+type VersionMap map[string]string
+
+// RewriteVersions changes the versions in a POM according to versions.
+//
+// That covers the version of the module itself, the version of its parent and the versions of dependencies
+// (managed or not), plugins and extensions.
+// Only literal versions are rewritten: ones referring to properties are left alone, see VersionProperties for those.
+// Apart from the versions, the POM is left exactly as it was, formatting and comments included.
+func RewriteVersions(pom []byte, versions VersionMap) ([]byte, error) {
+	spans, _, err := findVersionSpans(pom, versions)
+	if err != nil {
+		return nil, err
+	}
+	return replaceSpans(pom, spans), nil
+}
+
+// VersionProperties map the names of the properties that versions are set through to the versions they should be.
+type VersionProperties map[string]string
+
+var _SingleProperty = regexp.MustCompile(`^\$\{([^}]+)\}$`)
+
+// Collect adds the properties that the versions in a POM are set through to props, given the versions the modules
+// should have.
+//
+// Versions referring to the built-in project properties follow the version of the module on their own, so they are
+// left out.
+// A version set through anything but a single property cannot be rewritten, and neither can a property that would
+// need to be set to two different versions.
+func (props VersionProperties) Collect(pom []byte, versions VersionMap) error {
+	_, refs, err := findVersionSpans(pom, versions)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		m := _SingleProperty.FindStringSubmatch(ref.expr)
+		if m == nil {
+			return oops.Errorf("version %q of %s is not a single property, so it cannot be rewritten", ref.expr, ref.key)
+		}
+		name := m[1]
+		if isBuiltinProperty(name) {
+			continue
+		}
+		if prev, present := props[name]; present && prev != ref.version {
+			return oops.Errorf("property %s would need to be both %s and %s", name, prev, ref.version)
+		}
+		props[name] = ref.version
+	}
+	return nil
+}
+
+func isBuiltinProperty(name string) bool {
+	return name == "version" || strings.HasPrefix(name, "project.") || strings.HasPrefix(name, "pom.")
+}
+
+// Rewrite changes the values of the properties a POM defines according to props and reports which of them it defines.
+// Only the properties of the project itself are considered, not the ones of its profiles.
+// Apart from the values, the POM is left exactly as it was.
+func (props VersionProperties) Rewrite(pom []byte) ([]byte, []string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(pom))
+	var (
+		stack   []string
+		spans   []versionSpan
+		defined []string
+		text    *versionSpan
+	)
+	for {
+		before := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, oops.Wrapf(err, "cannot parse POM")
+		}
+		after := dec.InputOffset()
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, tok.Name.Local)
+			text = nil
+		case xml.CharData:
+			if len(stack) == 3 && stack[0] == "project" && stack[1] == "properties" {
+				lead := len(tok) - len(bytes.TrimLeft(tok, " \t\r\n"))
+				trail := len(tok) - len(bytes.TrimRight(tok, " \t\r\n"))
+				text = &versionSpan{from: int(before) + lead, upto: int(after) - trail}
+			}
+		case xml.EndElement:
+			name := stack[len(stack)-1]
+			version, present := props[name]
+			if len(stack) == 3 && stack[0] == "project" && stack[1] == "properties" && present {
+				span := versionSpan{from: int(before), upto: int(before), version: version}
+				if text != nil {
+					span.from, span.upto = text.from, text.upto
+				}
+				spans = append(spans, span)
+				defined = append(defined, name)
+			}
+			stack = stack[:len(stack)-1]
+			text = nil
+		}
+	}
+	return replaceSpans(pom, spans), defined, nil
+}
+
+func replaceSpans(pom []byte, spans []versionSpan) []byte {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+	out := new(bytes.Buffer)
+	last := 0
+	for _, s := range spans {
+		out.Write(pom[last:s.from])
+		xml.EscapeText(out, []byte(s.version))
+		last = s.upto
+	}
+	out.Write(pom[last:])
+	return out.Bytes()
+}
+
+// PomFiles lists the POM files in the tree rooted at dir.
+// Build output and VCS directories are skipped.
+func PomFiles(dir string) ([]string, error) {
+	var poms []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != dir && (info.Name() == "target" || info.Name()[0] == '.') {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == _PomFile {
+			poms = append(poms, path)
+		}
+		return nil
+	})
+	return poms, oops.Wrapf(err, "cannot list POM files in %s", dir)
+}
+
+type versionSpan struct {
+	from, upto int
+	version    string
+}
+
+// A versionRef is a version set through an expression, along with the version it should be.
+type versionRef struct {
+	key, expr, version string
+}
+
+// A coordHolder is an element with groupId, artifactId and version children, like <project> or <dependency>.
+type coordHolder struct {
+	kind         string
+	groupID      string
+	artifactID   string
+	versionSpan  [2]int
+	hasVersion   bool
+	versionExpr  string
+	inheritGroup func() string
+}
+
+type pomFrame struct {
+	name   string
+	holder *coordHolder
+}
+
+// findVersionSpans finds where the literal versions to rewrite are, as well as the versions set through expressions.
+func findVersionSpans(pom []byte, versions VersionMap) ([]versionSpan, []versionRef, error) {
+	dec := xml.NewDecoder(bytes.NewReader(pom))
+	var (
+		stack   []pomFrame
+		project *coordHolder
+		parent  *coordHolder
+		spans   []versionSpan
+		refs    []versionRef
+	)
+
+	for {
+		before := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, oops.Wrapf(err, "cannot parse POM")
+		}
+		after := dec.InputOffset()
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			frame := pomFrame{name: tok.Name.Local}
+			frame.holder = newHolder(stack, frame.name)
+			switch {
+			case frame.holder == nil:
+			case frame.holder.kind == "project":
+				project = frame.holder
+				project.inheritGroup = func() string {
+					if parent == nil {
+						return ""
+					}
+					return parent.groupID
+				}
+			case frame.holder.kind == "parent":
+				parent = frame.holder
+			}
+			stack = append(stack, frame)
+
+		case xml.CharData:
+			if len(stack) < 2 {
+				continue
+			}
+			field, holder := stack[len(stack)-1].name, stack[len(stack)-2].holder
+			if holder != nil {
+				holder.setField(field, tok, before, after)
+			}
+
+		case xml.EndElement:
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top.holder == nil {
+				continue
+			}
+			version, present := versions[top.holder.key()]
+			switch {
+			case !present:
+			case top.holder.hasVersion:
+				spans = append(spans, versionSpan{top.holder.versionSpan[0], top.holder.versionSpan[1], version})
+			case top.holder.versionExpr != "":
+				refs = append(refs, versionRef{top.holder.key(), top.holder.versionExpr, version})
+			}
+		}
+	}
+	return spans, refs, nil
+}
+
+func newHolder(stack []pomFrame, name string) *coordHolder {
+	if len(stack) == 0 {
+		if name == "project" {
+			return &coordHolder{kind: "project"}
+		}
+		return nil
+	}
+
+	parentName := stack[len(stack)-1].name
+	switch {
+	case name == "parent" && len(stack) == 1:
+		return &coordHolder{kind: "parent"}
+	case name == "dependency" && parentName == "dependencies",
+		name == "extension" && parentName == "extensions":
+		return &coordHolder{kind: name}
+	case name == "plugin" && parentName == "plugins":
+		return &coordHolder{kind: name, inheritGroup: func() string { return _DefaultPluginGroupID }}
+	}
+	return nil
+}
+
+func (h *coordHolder) setField(field string, text xml.CharData, from, upto int64) {
+	trimmed := bytes.TrimSpace(text)
+	switch field {
+	case "groupId":
+		h.groupID = string(trimmed)
+	case "artifactId":
+		h.artifactID = string(trimmed)
+	case "version":
+		if len(trimmed) == 0 {
+			return
+		}
+		if bytes.Contains(trimmed, []byte("${")) {
+			h.versionExpr = string(trimmed)
+			return
+		}
+		// The text might have been unescaped, so the span is found in terms of the surrounding whitespace.
+		lead := len(text) - len(bytes.TrimLeft(text, " \t\r\n"))
+		trail := len(text) - len(bytes.TrimRight(text, " \t\r\n"))
+		h.versionSpan = [2]int{int(from) + lead, int(upto) - trail}
+		h.hasVersion = true
+	}
+}
+
+func (h *coordHolder) key() string {
+	group := h.groupID
+	if group == "" && h.inheritGroup != nil {
+		group = h.inheritGroup()
+	}
+	return group + ":" + h.artifactID
+}
@@ -0,0 +1,184 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+)
+
+const _PomToRewrite = `<?xml version="1.0"?>
+<!-- kept as is -->
+<project>
+  <parent>
+    <groupId>org.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0-SNAPSHOT</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>  1.0.0-SNAPSHOT </version>
+  <dependencies>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>core</artifactId>
+      <version>2.1.0-SNAPSHOT</version>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>util</artifactId>
+      <version>${util.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.other</groupId>
+      <artifactId>core</artifactId>
+      <version>2.1.0-SNAPSHOT</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+func TestRewriteVersions(t *testing.T) {
+	// given
+	versions := maven.VersionMap{
+		"org.example:parent": "1.0.0",
+		"org.example:app":    "1.0.0",
+		"org.example:core":   "2.1.0",
+		"org.example:util":   "3.0.0",
+	}
+
+	// when
+	out, err := maven.RewriteVersions([]byte(_PomToRewrite), versions)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := `<?xml version="1.0"?>
+<!-- kept as is -->
+<project>
+  <parent>
+    <groupId>org.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <version>  1.0.0 </version>
+  <dependencies>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>core</artifactId>
+      <version>2.1.0</version>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>util</artifactId>
+      <version>${util.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.other</groupId>
+      <artifactId>core</artifactId>
+      <version>2.1.0-SNAPSHOT</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+	assert.That(string(out) == want, t.Errorf, "got\n%s\nwant\n%s", out, want)
+}
+
+func TestRewriteVersionsLeavesUnmentionedPomsAlone(t *testing.T) {
+	// given
+	versions := maven.VersionMap{"com.elsewhere:thing": "9.9.9"}
+
+	// when
+	out, err := maven.RewriteVersions([]byte(_PomToRewrite), versions)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(string(out) == _PomToRewrite, t.Errorf, "got\n%s\nwant it unchanged", out)
+}
+
+const _PomWithRevision = `<project>
+  <groupId>org.example</groupId>
+  <artifactId>app</artifactId>
+  <version>${revision}</version>
+  <properties>
+    <revision>1.0.0-SNAPSHOT</revision>
+    <core.version>2.1.0-SNAPSHOT</core.version>
+    <other.version>5</other.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>core</artifactId>
+      <version>${core.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>api</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+func TestVersionPropertiesAreRewritten(t *testing.T) {
+	// given
+	versions := maven.VersionMap{
+		"org.example:app":  "1.0.0",
+		"org.example:core": "2.1.0",
+		"org.example:api":  "1.0.0",
+	}
+	props := maven.VersionProperties{}
+
+	// when
+	collectErr := props.Collect([]byte(_PomWithRevision), versions)
+	out, defined, err := props.Rewrite([]byte(_PomWithRevision))
+
+	// then
+	assert.That(collectErr == nil, t.Fatalf, "unexpected error: %s", collectErr)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(props) == 2 && props["revision"] == "1.0.0" && props["core.version"] == "2.1.0", t.Errorf, "got properties %v", props)
+	assert.That(len(defined) == 2, t.Errorf, "got defined properties %q, want revision and core.version", defined)
+	want := strings.NewReplacer(
+		"<revision>1.0.0-SNAPSHOT</revision>", "<revision>1.0.0</revision>",
+		"<core.version>2.1.0-SNAPSHOT</core.version>", "<core.version>2.1.0</core.version>",
+	).Replace(_PomWithRevision)
+	assert.That(string(out) == want, t.Errorf, "got\n%s\nwant\n%s", out, want)
+}
+
+func TestVersionPropertiesRejectExpressions(t *testing.T) {
+	// given
+	pom := strings.Replace(_PomWithRevision, "<version>${revision}</version>", "<version>${revision}${changelist}</version>", 1)
+	props := maven.VersionProperties{}
+
+	// when
+	err := props.Collect([]byte(pom), maven.VersionMap{"org.example:app": "1.0.0"})
+
+	// then
+	assert.That(err != nil, t.Errorf, "got no error when one is expected")
+}
+
+func TestPomFiles(t *testing.T) {
+	// given
+	dir := filepath.Join("testdata", "multimodule")
+
+	// when
+	poms, err := maven.PomFiles(dir)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := []string{
+		filepath.Join(dir, "app", "pom.xml"),
+		filepath.Join(dir, "core", "pom.xml"),
+		filepath.Join(dir, "pom.xml"),
+	}
+	assert.That(len(poms) == len(want), t.Fatalf, "got %q, want %q", poms, want)
+	for i := range want {
+		assert.That(poms[i] == want[i], t.Errorf, "got %q, want %q", poms, want)
+	}
+}
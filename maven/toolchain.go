@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/szabba/unibuild/repo"
+)
+
+const (
+	_Wrapper          = "mvnw"
+	_DefaultMavenExec = "mvn"
+)
+
+// A Toolchain is what a project gets built with.
+type Toolchain struct {
+	// Executable is the maven wrapper shipped with the repository if there is one, mvn otherwise.
+	// Either applies the options from .mvn/jvm.config and .mvn/maven.config on its own.
+	Executable string
+	// JavaVersion is the Java release the project targets, if it could be determined.
+	JavaVersion string
+	// JavaHome is the JDK chosen for JavaVersion.
+	// When empty, the JAVA_HOME of the environment is used.
+	JavaHome string
+}
+
+// findToolchain determines the toolchain for the project in dir.
+// Unless the invocation says otherwise, the Java release is found in the root POM without running maven.
+func (cfg Config) findToolchain(dir string, inv Invocation) Toolchain {
+	tc := Toolchain{Executable: findExecutable(dir), JavaVersion: inv.JavaVersion}
+	if tc.JavaVersion == "" {
+		tc.JavaVersion = JavaVersionOf(rootModule(dir))
+	}
+	tc.JavaHome = cfg.JDKs[tc.JavaVersion]
+	return tc
+}
+
+// rootModule reads the root module of the project in dir as well as it can be without maven.
+// When the POMs cannot be resolved, the root POM is taken as it is written.
+func rootModule(dir string) EffectiveModule {
+	effPom, err := ReadPom(dir)
+	if err == nil {
+		return effPom.Projects[0]
+	}
+	var mod EffectiveModule
+	raw, err := ioutil.ReadFile(filepath.Join(dir, _PomFile))
+	if err == nil {
+		xml.Unmarshal(raw, &mod)
+	}
+	return mod
+}
+
+func findExecutable(dir string) string {
+	info, err := os.Stat(filepath.Join(dir, _Wrapper))
+	if err != nil || info.IsDir() {
+		return _DefaultMavenExec
+	}
+	return "./" + _Wrapper
+}
+
+// JavaVersionOf tells which Java release a module targets, based on the usual compiler plugin properties.
+// Legacy version numbers like 1.8 are normalized to 8.
+func JavaVersionOf(mod EffectiveModule) string {
+	keys := []string{"maven.compiler.release", "maven.compiler.target", "maven.compiler.source", "java.version"}
+	for _, k := range keys {
+		v := strings.TrimSpace(mod.Properties[k])
+		if v != "" && !_PropertyRef.MatchString(v) {
+			return strings.TrimPrefix(v, "1.")
+		}
+	}
+	return ""
+}
+
+// Command prepares a maven command to be run in clone using the toolchain.
+func (tc Toolchain) Command(ctx context.Context, clone repo.Local, out repo.Output, args ...string) *exec.Cmd {
+	cmd := clone.CommandTo(ctx, out, tc.executable(), args...)
+	if tc.JavaHome != "" {
+		cmd.Env = append(os.Environ(), "JAVA_HOME="+tc.JavaHome)
+	}
+	return cmd
+}
+
+// Describe formats the command line the toolchain runs maven with.
+func (tc Toolchain) Describe(args ...string) string {
+	cmd := formatCommand(tc.executable(), args)
+	if tc.JavaHome != "" {
+		cmd = formatCommand("JAVA_HOME="+tc.JavaHome, nil) + " " + cmd
+	}
+	return cmd
+}
+
+func (tc Toolchain) executable() string {
+	if tc.Executable == "" {
+		return _DefaultMavenExec
+	}
+	return tc.Executable
+}
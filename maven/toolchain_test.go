@@ -0,0 +1,121 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestToolchainPrefersTheWrapperAndPicksAJDK(t *testing.T) {
+	// given
+	cfg := maven.Config{JDKs: map[string]string{"8": "/opt/jdk8", "17": "/opt/jdk17"}}
+	clone := repo.Local{Remote: repo.Remote{Name: "wrapper"}, Path: "testdata/wrapper"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	tc := prj.Toolchain()
+	assert.That(tc.Executable == "./mvnw", t.Errorf, "got executable %q, want %q", tc.Executable, "./mvnw")
+	assert.That(tc.JavaVersion == "8", t.Errorf, "got java version %q, want %q", tc.JavaVersion, "8")
+	assert.That(tc.JavaHome == "/opt/jdk8", t.Errorf, "got JAVA_HOME %q, want %q", tc.JavaHome, "/opt/jdk8")
+
+	wantPlan := "JAVA_HOME=/opt/jdk8 ./mvnw -U -B clean deploy"
+	assert.That(prj.Plan() == wantPlan, t.Errorf, "got plan %q, want %q", prj.Plan(), wantPlan)
+}
+
+func TestToolchainJavaVersionCanBeOverridden(t *testing.T) {
+	// given
+	cfg := maven.Config{
+		JDKs:     map[string]string{"8": "/opt/jdk8", "17": "/opt/jdk17"},
+		Projects: map[string]maven.Invocation{"wrapper": {JavaVersion: "17"}},
+	}
+	clone := repo.Local{Remote: repo.Remote{Name: "wrapper"}, Path: "testdata/wrapper"}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	home := prj.Toolchain().JavaHome
+	assert.That(home == "/opt/jdk17", t.Errorf, "got JAVA_HOME %q, want %q", home, "/opt/jdk17")
+}
+
+func TestToolchainWithoutWrapperUsesMvn(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "single"}, Path: "testdata/single"}
+
+	// when
+	prj, err := maven.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	tc := prj.Toolchain()
+	assert.That(tc.Executable == "mvn", t.Errorf, "got executable %q, want %q", tc.Executable, "mvn")
+	assert.That(tc.JavaHome == "", t.Errorf, "got JAVA_HOME %q, want none", tc.JavaHome)
+}
+
+// _JavaHomeWrapper pretends to be a maven wrapper computing an effective POM that is just the POM itself.
+// It records the JAVA_HOME it was run with.
+const _JavaHomeWrapper = `#!/bin/sh
+echo "$JAVA_HOME" > java-home.txt
+for arg in "$@"; do
+  case "$arg" in
+    -Doutput=*) out="${arg#-Doutput=}" ;;
+  esac
+done
+cp pom.xml "$out"
+`
+
+const _Java17Pom = `<project>
+  <parent>
+    <groupId>com.acme</groupId>
+    <artifactId>corporate-parent</artifactId>
+    <version>7</version>
+  </parent>
+  <artifactId>billing</artifactId>
+  <version>1.0.0</version>
+  <properties>
+    <maven.compiler.release>17</maven.compiler.release>
+  </properties>
+</project>
+`
+
+func TestAnalysisRunsWithTheJDKOfTheBuild(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "toolchain")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), []byte(_Java17Pom), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	err = ioutil.WriteFile(filepath.Join(dir, "mvnw"), []byte(_JavaHomeWrapper), 0755)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	cfg := maven.Config{JDKs: map[string]string{"8": "/opt/jdk8", "17": "/opt/jdk17"}}
+	clone := repo.Local{Remote: repo.Remote{Name: "billing"}, Path: dir}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	home, err := ioutil.ReadFile(filepath.Join(dir, "java-home.txt"))
+	assert.That(err == nil, t.Fatalf, "mvn was not run: %s", err)
+	assert.That(strings.TrimSpace(string(home)) == "/opt/jdk17", t.Errorf, "analysis got JAVA_HOME %q, want %q", home, "/opt/jdk17")
+	assert.That(prj.Toolchain().JavaHome == "/opt/jdk17", t.Errorf, "build got JAVA_HOME %q, want %q", prj.Toolchain().JavaHome, "/opt/jdk17")
+}
@@ -0,0 +1,28 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package maven
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// Version reports the first line of mvn --version, which names the maven release in use.
+func Version(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "mvn", "-B", "--version").Output()
+	if err != nil {
+		return "", oops.Wrapf(err, "cannot determine maven version")
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	if !sc.Scan() {
+		return "", oops.Errorf("mvn --version printed nothing")
+	}
+	return strings.TrimSpace(sc.Text()), nil
+}
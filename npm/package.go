@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package npm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+const _Manifest = "package.json"
+
+var ErrNotNPM = errors.New("no package.json found")
+
+// A Package is what is declared in a package.json file.
+type Package struct {
+	// Dir the package.json is in.
+	Dir string `json:"-"`
+
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Private              bool              `json:"private"`
+	Workspaces           Workspaces        `json:"workspaces"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// Published tells whether the package can be published to a registry, and so used by others.
+func (pkg Package) Published() bool { return pkg.Name != "" && !pkg.Private }
+
+// Workspaces are the patterns matching the directories of workspace packages.
+// Both the npm form (a list) and the older yarn one (an object with a packages list) are understood.
+type Workspaces []string
+
+func (ws *Workspaces) UnmarshalJSON(raw []byte) error {
+	var patterns []string
+	if err := json.Unmarshal(raw, &patterns); err == nil {
+		*ws = patterns
+		return nil
+	}
+	var yarn struct {
+		Packages []string `json:"packages"`
+	}
+	err := json.Unmarshal(raw, &yarn)
+	*ws = yarn.Packages
+	return err
+}
+
+// ReadPackage reads the package.json in dir.
+func ReadPackage(dir string) (Package, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, _Manifest))
+	if os.IsNotExist(err) {
+		return Package{}, oops.Wrapf(ErrNotNPM, "in %s", dir)
+	}
+	if err != nil {
+		return Package{}, oops.Wrapf(err, "cannot read %s in %s", _Manifest, dir)
+	}
+	pkg := Package{Dir: dir}
+	err = json.Unmarshal(raw, &pkg)
+	return pkg, oops.Wrapf(err, "cannot parse %s in %s", _Manifest, dir)
+}
+
+// ReadWorkspace reads the package in dir along with its workspace packages.
+// The root package comes first, then the workspaces sorted by directory.
+func ReadWorkspace(dir string) ([]Package, error) {
+	root, err := ReadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := workspaceDirs(dir, root.Workspaces)
+	if err != nil {
+		return nil, oops.Wrapf(err, "cannot find the workspaces of %s", dir)
+	}
+	pkgs := []Package{root}
+	for _, d := range dirs {
+		pkg, err := ReadPackage(d)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// workspaceDirs finds the directories matching the workspace patterns that hold a package.json.
+// Patterns starting with ! exclude directories, and a trailing /** matches any directory below.
+func workspaceDirs(root string, patterns []string) ([]string, error) {
+	included := map[string]bool{}
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		matches, err := matchDirs(root, strings.TrimPrefix(pattern, "!"))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			included[m] = !exclude
+		}
+	}
+
+	var dirs []string
+	for d, in := range included {
+		if _, err := os.Stat(filepath.Join(d, _Manifest)); in && err == nil && d != filepath.Clean(root) {
+			dirs = append(dirs, d)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func matchDirs(root, pattern string) ([]string, error) {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	if !strings.HasSuffix(pattern, "/**") {
+		return filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+	}
+
+	bases, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(strings.TrimSuffix(pattern, "/**"))))
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, base := range bases {
+		err := filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			if info.IsDir() {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package npm_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/npm"
+)
+
+func TestReadWorkspace(t *testing.T) {
+	// when
+	pkgs, err := npm.ReadWorkspace("testdata/workspaces")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(pkgs) == 3, t.Fatalf, "got %d packages, want 3", len(pkgs))
+
+	root, core, web := pkgs[0], pkgs[1], pkgs[2]
+	assert.That(root.Name == "storefront" && !root.Published(), t.Errorf, "got root package %#v", root)
+	assert.That(core.Dir == filepath.Join("testdata", "workspaces", "packages", "core"), t.Errorf, "got core directory %s", core.Dir)
+	assert.That(core.Name == "@acme/storefront-core" && core.Version == "1.2.0", t.Errorf, "got core package %s %s", core.Name, core.Version)
+	assert.That(web.PeerDependencies["react-dom"] == ">=17", t.Errorf, "got web peer dependencies %v", web.PeerDependencies)
+}
+
+func TestReadWorkspaceInTheYarnFormat(t *testing.T) {
+	// when
+	pkgs, err := npm.ReadWorkspace("testdata/single")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(pkgs) == 1, t.Fatalf, "got %d packages, want 1", len(pkgs))
+	assert.That(pkgs[0].Workspaces != nil, t.Errorf, "workspaces not read")
+}
+
+func TestReadPackageOfSomethingElse(t *testing.T) {
+	// when
+	_, err := npm.ReadPackage("testdata")
+
+	// then
+	assert.That(oops.Cause(err) == npm.ErrNotNPM, t.Errorf, "got error %v, want %v", err, npm.ErrNotNPM)
+}
@@ -0,0 +1,190 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package npm lets unibuild build npm (and yarn) projects, including workspaces.
+//
+// The packages are analyzed by reading their package.json files.
+// Version ranges are kept, so a project in the suite only counts as providing a package when its version is in the
+// range a dependent asks for. Otherwise the dependent gets the package from the registry.
+package npm
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+// A Config controls how npm projects are built.
+type Config struct {
+	// Commands run one after another by the shell, in the root of the repository.
+	// By default the dependencies are installed and the packages built and published.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// DefaultCommands install the dependencies with whichever tool the lockfile is for, then run the build script (of
+// every workspace, if there are any) and publish whatever is not private.
+func DefaultCommands(pkgs []Package) []string {
+	root := pkgs[0]
+	install := "npm ci"
+	if _, err := os.Stat(filepath.Join(root.Dir, "yarn.lock")); err == nil {
+		install = "yarn install --frozen-lockfile"
+	}
+	cmds := []string{install}
+
+	if len(pkgs) == 1 {
+		cmds = append(cmds, "npm run build --if-present")
+		if root.Published() {
+			cmds = append(cmds, "npm publish")
+		}
+		return cmds
+	}
+
+	cmds = append(cmds, "npm run build --if-present --workspaces --include-workspace-root")
+	if root.Published() {
+		cmds = append(cmds, "npm publish")
+	}
+	for _, pkg := range pkgs[1:] {
+		if pkg.Published() {
+			return append(cmds, "npm publish --workspaces")
+		}
+	}
+	return cmds
+}
+
+// A Project that is built using npm.
+type Project struct {
+	name     string
+	version  string
+	clone    repo.Local
+	commands []string
+	uses     []unibuild.Requirement
+	builds   []unibuild.RequirementVersion
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
+
+// NewProject attempts to create an npm project given a locally cloned repository.
+func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds npm projects.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("npm", 20, ErrNotNPM, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create an npm project given a locally cloned repository.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	pkgs, err := ReadWorkspace(clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem reading npm packages in %s", clone.Path)
+	}
+
+	commands := cfg.Commands
+	if len(commands) == 0 {
+		commands = DefaultCommands(pkgs)
+	}
+	builds := findBuilds(pkgs)
+	prj := Project{
+		name:     clone.Name,
+		version:  findVersion(pkgs),
+		clone:    clone,
+		commands: commands,
+		uses:     findUses(pkgs),
+		builds:   builds,
+	}
+	return prj, nil
+}
+
+// findVersion takes the version of the root package, or of the first workspace package with one.
+func findVersion(pkgs []Package) string {
+	for _, pkg := range pkgs {
+		if pkg.Version != "" {
+			return pkg.Version
+		}
+	}
+	return ""
+}
+
+// findBuilds lists the packages that get published.
+// Private ones cannot be used outside of the project.
+func findBuilds(pkgs []Package) []unibuild.RequirementVersion {
+	var builds []unibuild.RequirementVersion
+	for _, pkg := range pkgs {
+		if pkg.Published() {
+			builds = append(builds, unibuild.RequirementVersion{ID: PackageID(pkg.Name), Version: pkg.Version})
+		}
+	}
+	return builds
+}
+
+// findUses collects the dependencies of all the packages that are not packages of the workspace themselves.
+// Development dependencies are compile requirements, since the build script usually needs them.
+func findUses(pkgs []Package) []unibuild.Requirement {
+	own := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		own[pkg.Name] = true
+	}
+
+	var all []unibuild.Requirement
+	add := func(deps map[string]string, kind unibuild.RequirementKind) {
+		for _, name := range sortedNames(deps) {
+			req, ok := NewRequirement(name, deps[name], kind)
+			if ok && !own[req.ID().Name] {
+				all = append(all, req)
+			}
+		}
+	}
+
+	for _, pkg := range pkgs {
+		add(pkg.Dependencies, unibuild.Compile)
+		add(pkg.PeerDependencies, unibuild.Compile)
+		add(pkg.DevDependencies, unibuild.Compile)
+		add(pkg.OptionalDependencies, unibuild.Optional)
+	}
+	return unibuild.MergeRequirements(all)
+}
+
+func sortedNames(deps map[string]string) []string {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{
+		Name:    prj.name,
+		Version: prj.version,
+	}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion { return prj.builds }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Plan shows the commands the project is built with.
+func (prj Project) Plan() string { return strings.Join(prj.commands, " && ") }
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	return prj.clone.RunShell(ctx, logTo, prj.commands...)
+}
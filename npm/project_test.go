@@ -0,0 +1,135 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package npm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/npm"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestProjectRequirements(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "storefront"}, Path: "testdata/workspaces"}
+
+	// when
+	prj, err := npm.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Info().Version == "1.2.0", t.Errorf, "got version %q", prj.Info().Version)
+
+	builds := prj.Builds()
+	assert.That(len(builds) == 1, t.Fatalf, "got builds %v, want just the core package", builds)
+	assert.That(builds[0].ID == npm.PackageID("@acme/storefront-core") && builds[0].Version == "1.2.0", t.Errorf, "got build %#v", builds[0])
+
+	want := map[string]unibuild.RequirementKind{
+		"@acme/design-tokens": unibuild.Compile,
+		"lodash":              unibuild.Compile,
+		"typescript":          unibuild.Compile,
+		"react":               unibuild.Compile,
+		"react-dom":           unibuild.Compile,
+		"fsevents":            unibuild.Optional,
+	}
+	assert.That(len(prj.Uses()) == len(want), t.Errorf, "got %d requirements, want %d", len(prj.Uses()), len(want))
+	for _, req := range prj.Uses() {
+		kind, known := want[req.ID().Name]
+		assert.That(known, t.Errorf, "unexpected requirement %s", req.ID())
+		got := unibuild.KindOf(req)
+		assert.That(got == kind, t.Errorf, "%s: got kind %s, want %s", req.ID(), got, kind)
+	}
+}
+
+func TestRequirementsAcceptTheVersionsOfTheirRanges(t *testing.T) {
+	for _, tt := range []struct {
+		spec, version string
+		want          bool
+	}{
+		{"^2.1.0", "2.4.1", true},
+		{"^2.1.0", "3.0.0", false},
+		{"workspace:^", "3.0.0", true},
+		{"latest", "3.0.0", true},
+		{"npm:@acme/color@^1.0.0", "1.1.0", true},
+		{"npm:@acme/color@^1.0.0", "2.0.0", false},
+	} {
+		// given
+		req, ok := npm.NewRequirement("tokens", tt.spec, unibuild.Compile)
+		assert.That(ok, t.Fatalf, "%q: no requirement", tt.spec)
+
+		// when
+		got := req.Accepts(tt.version)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "%q accepts %q: got %v, want %v", tt.spec, tt.version, got, tt.want)
+	}
+}
+
+func TestDependenciesFromOutsideTheRegistryAreNotRequirements(t *testing.T) {
+	for _, spec := range []string{"github:acme/tokens", "file:../tokens", "git+https://example.com/tokens.git", "acme/tokens"} {
+		// when
+		_, ok := npm.NewRequirement("tokens", spec, unibuild.Compile)
+
+		// then
+		assert.That(!ok, t.Errorf, "%q: got a requirement", spec)
+	}
+}
+
+func TestAliasesRequireThePackageTheyStandFor(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "design-tokens"}, Path: "testdata/single"}
+
+	// when
+	prj, err := npm.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(len(prj.Uses()) == 1, t.Fatalf, "got %d requirements, want 1", len(prj.Uses()))
+	assert.That(prj.Uses()[0].ID() == npm.PackageID("@acme/color"), t.Errorf, "got requirement %s", prj.Uses()[0].ID())
+}
+
+func TestProjectPlan(t *testing.T) {
+	for _, tt := range []struct {
+		cfg        npm.Config
+		path, want string
+	}{
+		{npm.Config{}, "testdata/single", "yarn install --frozen-lockfile && npm run build --if-present && npm publish"},
+		{npm.Config{}, "testdata/workspaces", "npm ci && npm run build --if-present --workspaces --include-workspace-root && npm publish --workspaces"},
+		{npm.Config{Commands: []string{"npm ci", "npm test"}}, "testdata/single", "npm ci && npm test"},
+	} {
+		// given
+		clone := repo.Local{Remote: repo.Remote{Name: "storefront"}, Path: tt.path}
+
+		// when
+		prj, err := tt.cfg.NewProject(context.Background(), clone)
+
+		// then
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+		assert.That(prj.Plan() == tt.want, t.Errorf, "got plan %q, want %q", prj.Plan(), tt.want)
+	}
+}
+
+func TestProjectIsOnlyBuiltAfterALibraryInTheVersionItAccepts(t *testing.T) {
+	// given
+	ctx := context.Background()
+	tokens, err := npm.NewProject(ctx, repo.Local{Remote: repo.Remote{Name: "design-tokens"}, Path: "testdata/single"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	storefront, err := npm.NewProject(ctx, repo.Local{Remote: repo.Remote{Name: "storefront"}, Path: "testdata/workspaces"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	ordSuite, err := unibuild.NewProjectSuite(storefront, tokens).ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	order := ordSuite.Order()
+	assert.That(len(order) == 2, t.Fatalf, "got %d projects, want 2", len(order))
+	assert.That(order[0].Info().Name == "design-tokens" && order[1].Info().Name == "storefront", t.Errorf,
+		"got order %s, %s", order[0].Info().Name, order[1].Info().Name)
+}
@@ -0,0 +1,103 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package npm
+
+import (
+	"strings"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/semver"
+)
+
+// Ecosystem of the requirements of npm projects, shared with yarn.
+const Ecosystem = "npm"
+
+// PackageID identifies a package as a requirement, irrespective of its version.
+func PackageID(name string) unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: name}
+}
+
+// A Requirement on a package from a registry, in the versions some ranges allow.
+// A requirement without ranges accepts any version.
+type Requirement struct {
+	id     unibuild.RequirementIdentity
+	kind   unibuild.RequirementKind
+	ranges []semver.Range
+}
+
+var _ interface {
+	unibuild.KindedRequirement
+	unibuild.ConstrainedRequirement
+	unibuild.MergingRequirement
+} = Requirement{}
+
+// NewRequirement creates a requirement on the named package out of the version spec it is declared with.
+// Specs pointing outside of the registry (like git repositories, URLs and local paths) do not make requirements.
+// Distribution tags (like latest) accept any version, as they may point to any.
+func NewRequirement(name, spec string, kind unibuild.RequirementKind) (Requirement, bool) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "workspace:") {
+		spec = strings.TrimPrefix(spec, "workspace:")
+		if spec == "^" || spec == "~" {
+			spec = "*"
+		}
+	}
+	if strings.HasPrefix(spec, "npm:") {
+		// An alias installs another package under the name.
+		alias := strings.TrimPrefix(spec, "npm:")
+		at := strings.LastIndex(alias, "@")
+		if at <= 0 {
+			name, spec = alias, "*"
+		} else {
+			name, spec = alias[:at], alias[at+1:]
+		}
+	}
+	if strings.ContainsAny(spec, ":/") {
+		return Requirement{}, false
+	}
+
+	req := Requirement{id: PackageID(name), kind: kind}
+	if rng, err := semver.ParseRange(spec); err == nil {
+		req.ranges = []semver.Range{rng}
+	}
+	return req, true
+}
+
+func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return req.kind }
+
+// Accepts tells whether the version is in any of the ranges of the requirement.
+func (req Requirement) Accepts(version string) bool {
+	if len(req.ranges) == 0 {
+		return true
+	}
+	v, err := semver.ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	for _, rng := range req.ranges {
+		if rng.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge combines requirements on the same package by different packages of a workspace.
+// The result has the stronger kind and accepts a version if either of them does, as each package gets its own copy.
+func (req Requirement) Merge(merged unibuild.Requirement) unibuild.Requirement {
+	other, ok := merged.(Requirement)
+	if !ok {
+		return req
+	}
+	req.kind = req.kind.Stronger(other.kind)
+	if len(req.ranges) == 0 || len(other.ranges) == 0 {
+		req.ranges = nil
+	} else {
+		req.ranges = append(append([]semver.Range{}, req.ranges...), other.ranges...)
+	}
+	return req
+}
@@ -0,0 +1,157 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package prefixio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Mux hands out line-prefixing writers that share a single destination.
+//
+// Each writer buffers output until it sees a newline and then emits whole lines at once,
+// so that lines coming from different writers never interleave.
+// A Mux and the writers it hands out are safe for concurrent use.
+type Mux struct {
+	style Style
+
+	mtx     sync.Mutex
+	dst     io.Writer
+	writers map[string]*LineWriter
+	order   []*LineWriter
+}
+
+// NewMux returns a new Mux writing to dst.
+func NewMux(dst io.Writer) *Mux {
+	return NewStyledMux(dst, Style{})
+}
+
+// NewStyledMux returns a new Mux writing to dst that prefixes the lines of named sources according to style.
+func NewStyledMux(dst io.Writer, style Style) *Mux {
+	return &Mux{
+		style:   style,
+		dst:     dst,
+		writers: map[string]*LineWriter{},
+	}
+}
+
+// Writer returns the writer for lines with the given prefix.
+// Repeated calls with the same prefix return the same writer.
+func (m *Mux) Writer(prefix string) *LineWriter {
+	return m.writer("prefix:"+prefix, func() PrefixFunc { return Static(prefix) })
+}
+
+// Source returns the writer for lines coming from the named source, prefixed according to the style of the Mux.
+// The source is considered started the first time it is requested, until Start is called for it.
+// Repeated calls with the same name return the same writer.
+func (m *Mux) Source(name string) *LineWriter {
+	return m.writer("source:"+name, func() PrefixFunc { return m.style.Prefix(name, time.Now()) })
+}
+
+// Start marks the named source as started now and returns its writer.
+// The elapsed time in the prefixes of its lines is counted from then on.
+func (m *Mux) Start(name string) *LineWriter {
+	w := m.Source(name)
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.prefix = m.style.Prefix(name, time.Now())
+	return w
+}
+
+func (m *Mux) writer(key string, prefix func() PrefixFunc) *LineWriter {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if w, present := m.writers[key]; present {
+		return w
+	}
+	w := &LineWriter{mux: m, prefix: prefix()}
+	m.writers[key] = w
+	m.order = append(m.order, w)
+	return w
+}
+
+// Flush emits the trailing partial lines of all the writers, terminating each with a newline.
+// It returns the first error encountered.
+func (m *Mux) Flush() error {
+	m.mtx.Lock()
+	ws := append([]*LineWriter{}, m.order...)
+	m.mtx.Unlock()
+
+	var firstErr error
+	for _, w := range ws {
+		err := w.Flush()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *Mux) emit(p []byte) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	_, err := m.dst.Write(p)
+	return err
+}
+
+// A LineWriter is a line-prefixing writer handed out by a Mux.
+type LineWriter struct {
+	mux    *Mux
+	prefix PrefixFunc
+
+	mtx     sync.Mutex
+	partial []byte
+	out     bytes.Buffer
+}
+
+var _ io.Writer = new(LineWriter)
+
+// Write buffers p and emits all the lines it completes.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	w.partial = append(w.partial, p...)
+	end := bytes.LastIndexByte(w.partial, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+
+	complete := w.partial[:end+1]
+	now := time.Now()
+	w.out.Reset()
+	for len(complete) > 0 {
+		i := bytes.IndexByte(complete, '\n')
+		w.out.WriteString(w.prefix(now))
+		w.out.Write(complete[:i+1])
+		complete = complete[i+1:]
+	}
+	w.partial = append(w.partial[:0], w.partial[end+1:]...)
+
+	err := w.mux.emit(w.out.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush emits the trailing partial line, if any, terminating it with a newline.
+func (w *LineWriter) Flush() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if len(w.partial) == 0 {
+		return nil
+	}
+	w.out.Reset()
+	w.out.WriteString(w.prefix(time.Now()))
+	w.out.Write(w.partial)
+	w.out.WriteByte('\n')
+	w.partial = w.partial[:0]
+	return w.mux.emit(w.out.Bytes())
+}
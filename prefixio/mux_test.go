@@ -0,0 +1,154 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package prefixio_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/prefixio"
+)
+
+func TestMuxHoldsBackPartialLines(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+	w := mux.Writer("> ")
+
+	// when
+	n, err := io.WriteString(w, "a")
+
+	// then
+	assert.That(n == 1, t.Errorf, "n: got %d, want %d", n, 1)
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == "", t.Errorf, "out: got %q, want %q", out.String(), "")
+}
+
+func TestMuxEmitsCompletedLines(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+	w := mux.Writer("> ")
+
+	_, err := io.WriteString(w, "a")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	n, err := io.WriteString(w, "b\nc\nd")
+
+	// then
+	assert.That(n == 5, t.Errorf, "n: got %d, want %d", n, 5)
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == "> ab\n> c\n", t.Errorf, "out: got %q, want %q", out.String(), "> ab\n> c\n")
+}
+
+func TestMuxWritersDoNotInterleaveMidLine(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+	a, b := mux.Writer("a | "), mux.Writer("b | ")
+
+	// when
+	io.WriteString(a, "one ")
+	io.WriteString(b, "two\n")
+	io.WriteString(a, "three\n")
+
+	// then
+	want := "b | two\na | one three\n"
+	assert.That(out.String() == want, t.Errorf, "out: got %q, want %q", out.String(), want)
+}
+
+func TestMuxReturnsTheSameWriterForAPrefix(t *testing.T) {
+	// given
+	mux := prefixio.NewMux(new(strings.Builder))
+
+	// when
+	a, b := mux.Writer("> "), mux.Writer("> ")
+
+	// then
+	assert.That(a == b, t.Errorf, "got different writers for the same prefix")
+}
+
+func TestMuxFlushTerminatesPartialLines(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+	io.WriteString(mux.Writer("a | "), "x")
+	io.WriteString(mux.Writer("b | "), "y\nz")
+
+	// when
+	err := mux.Flush()
+
+	// then
+	want := "b | y\na | x\nb | z\n"
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == want, t.Errorf, "out: got %q, want %q", out.String(), want)
+}
+
+func TestMuxFlushWithNothingBuffered(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+	io.WriteString(mux.Writer("a | "), "x\n")
+
+	// when
+	err := mux.Flush()
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == "a | x\n", t.Errorf, "out: got %q, want %q", out.String(), "a | x\n")
+}
+
+func TestMuxReturnsTheUnderlyingWritersError(t *testing.T) {
+	// given
+	err := errors.New("an error")
+	mux := prefixio.NewMux(_ErrWriter{err})
+
+	// when
+	_, gotErr := io.WriteString(mux.Writer("> "), "a\n")
+
+	// then
+	assert.That(gotErr == err, t.Fatalf, "got error %q, want %q", gotErr, err)
+}
+
+func TestMuxConcurrentWritersEmitWholeLines(t *testing.T) {
+	// given
+	const writers, lines = 8, 200
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+
+	// when
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := mux.Writer(fmt.Sprintf("%d | ", i))
+			for j := 0; j < lines; j++ {
+				// Split each line across writes to provoke interleaving.
+				io.WriteString(w, "line ")
+				io.WriteString(w, fmt.Sprintf("%d of %d\n", j, i))
+			}
+		}(i)
+	}
+	wg.Wait()
+	err := mux.Flush()
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.That(len(got) == writers*lines, t.Fatalf, "got %d lines, want %d", len(got), writers*lines)
+	for _, line := range got {
+		var i, j, k int
+		_, err := fmt.Sscanf(line, "%d | line %d of %d", &i, &j, &k)
+		assert.That(err == nil && i == k, t.Errorf, "mangled line %q", line)
+	}
+}
@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package prefixio
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"time"
+)
+
+// A PrefixFunc computes the prefix of a line that is about to be written at the given time.
+type PrefixFunc func(now time.Time) string
+
+// Static returns a PrefixFunc that always returns the same prefix.
+func Static(prefix string) PrefixFunc {
+	return func(time.Time) string { return prefix }
+}
+
+// A Style describes the prefixes used for lines coming from named sources, like projects.
+// The zero value produces prefixes of the form "name | ".
+type Style struct {
+	// Timestamp prepends the wall-clock time the line was written at.
+	Timestamp bool
+	// Elapsed prepends the time elapsed since the source started writing.
+	Elapsed bool
+	// Color renders the source name in a colour that is stable for a given name.
+	Color bool
+	// Width is the width that source names are right-padded to.
+	Width int
+}
+
+const (
+	_TimestampFormat = "15:04:05.000"
+	_Separator       = " | "
+)
+
+var _Colors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+// Prefix returns a PrefixFunc for the lines of the named source that started at start.
+func (s Style) Prefix(name string, start time.Time) PrefixFunc {
+	label := name
+	if pad := s.Width - len(name); pad > 0 {
+		label += strings.Repeat(" ", pad)
+	}
+	if s.Color {
+		label = fmt.Sprintf("\x1b[%dm%s\x1b[0m", ColorOf(name), label)
+	}
+
+	return func(now time.Time) string {
+		b := new(strings.Builder)
+		if s.Timestamp {
+			b.WriteString(now.Format(_TimestampFormat))
+			b.WriteString(" ")
+		}
+		if s.Elapsed {
+			b.WriteString(formatElapsed(now.Sub(start)))
+			b.WriteString(" ")
+		}
+		b.WriteString(label)
+		b.WriteString(_Separator)
+		return b.String()
+	}
+}
+
+func formatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	secs := int(d / time.Second)
+	return fmt.Sprintf("+%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+// ColorOf picks the ANSI foreground colour code used for a source name.
+func ColorOf(name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return _Colors[h.Sum32()%uint32(len(_Colors))]
+}
+
+// ColorEnabled reports whether it makes sense to write colour escape codes to f.
+// That is not the case when NO_COLOR is set, the terminal is dumb or f is not a terminal at all.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,133 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package prefixio_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/prefixio"
+)
+
+var _Start = time.Date(2018, 8, 20, 12, 0, 0, 0, time.UTC)
+
+func TestStyleZeroValue(t *testing.T) {
+	// given
+	style := prefixio.Style{}
+
+	// when
+	prefix := style.Prefix("lib", _Start)(_Start)
+
+	// then
+	assert.That(prefix == "lib | ", t.Errorf, "got %q, want %q", prefix, "lib | ")
+}
+
+func TestStylePadsNames(t *testing.T) {
+	// given
+	style := prefixio.Style{Width: 5}
+
+	// when
+	prefix := style.Prefix("lib", _Start)(_Start)
+
+	// then
+	assert.That(prefix == "lib   | ", t.Errorf, "got %q, want %q", prefix, "lib   | ")
+}
+
+func TestStyleTimestampAndElapsed(t *testing.T) {
+	// given
+	style := prefixio.Style{Timestamp: true, Elapsed: true}
+	now := _Start.Add(time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond)
+
+	// when
+	prefix := style.Prefix("lib", _Start)(now)
+
+	// then
+	want := "13:02:03.045 +01:02:03 lib | "
+	assert.That(prefix == want, t.Errorf, "got %q, want %q", prefix, want)
+}
+
+func TestStyleColorIsStableAndSkipsPadding(t *testing.T) {
+	// given
+	style := prefixio.Style{Color: true, Width: 4}
+
+	// when
+	first := style.Prefix("lib", _Start)(_Start)
+	second := style.Prefix("lib", _Start.Add(time.Hour))(_Start)
+
+	// then
+	want := fmt.Sprintf("\x1b[%dmlib \x1b[0m | ", prefixio.ColorOf("lib"))
+	assert.That(first == want, t.Errorf, "got %q, want %q", first, want)
+	assert.That(first == second, t.Errorf, "colour changed between calls: %q and %q", first, second)
+}
+
+func TestColorDisabledByNoColor(t *testing.T) {
+	// given
+	old, wasSet := os.LookupEnv("NO_COLOR")
+	os.Setenv("NO_COLOR", "1")
+	defer func() {
+		if wasSet {
+			os.Setenv("NO_COLOR", old)
+		} else {
+			os.Unsetenv("NO_COLOR")
+		}
+	}()
+
+	// when
+	enabled := prefixio.ColorEnabled(os.Stdout)
+
+	// then
+	assert.That(!enabled, t.Errorf, "colour enabled despite NO_COLOR")
+}
+
+func TestFuncWriterComputesPrefixPerLine(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	lineNo := 0
+	w := prefixio.NewFuncWriter(out, func(time.Time) string {
+		lineNo++
+		return fmt.Sprintf("%d> ", lineNo)
+	})
+
+	// when
+	n, err := io.WriteString(w, "a\nb\n")
+
+	// then
+	assert.That(n == 4, t.Errorf, "n: got %d, want %d", n, 4)
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == "1> a\n2> b\n", t.Errorf, "out: got %q, want %q", out.String(), "1> a\n2> b\n")
+}
+
+func TestMuxSourceUsesTheStyle(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewStyledMux(out, prefixio.Style{Width: 4})
+
+	// when
+	io.WriteString(mux.Source("lib"), "a\n")
+
+	// then
+	assert.That(out.String() == "lib  | a\n", t.Errorf, "out: got %q, want %q", out.String(), "lib  | a\n")
+}
+
+func TestMuxStartRestartsTheElapsedTime(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewStyledMux(out, prefixio.Style{Elapsed: true})
+	mux.Source("lib")
+	time.Sleep(1100 * time.Millisecond)
+
+	// when
+	io.WriteString(mux.Start("lib"), "a\n")
+
+	// then
+	want := "+00:00:00 lib | a\n"
+	assert.That(out.String() == want, t.Errorf, "out: got %q, want %q", out.String(), want)
+}
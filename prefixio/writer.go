@@ -9,12 +9,13 @@ import (
 	"bytes"
 	"io"
 	"regexp"
+	"time"
 )
 
 // Writer implements line-prefixing for an io.Writer object.
 type Writer struct {
-	dst        io.Writer
-	linePrefix []byte
+	dst    io.Writer
+	prefix PrefixFunc
 
 	buf       bytes.Buffer
 	keeps     [][2]int
@@ -23,9 +24,14 @@ type Writer struct {
 
 // NewWriter returns a new Writer with the given line prefix.
 func NewWriter(dst io.Writer, linePrefix string) *Writer {
+	return NewFuncWriter(dst, Static(linePrefix))
+}
+
+// NewFuncWriter returns a new Writer that computes the prefix of each line as it starts it.
+func NewFuncWriter(dst io.Writer, prefix PrefixFunc) *Writer {
 	return &Writer{
-		dst:        dst,
-		linePrefix: []byte(linePrefix),
+		dst:    dst,
+		prefix: prefix,
 	}
 }
 
@@ -51,9 +57,10 @@ func (w *Writer) bufferOutput(p []byte) {
 	if len(p) == 0 {
 		return
 	}
+	now := time.Now()
 	chunks := _LineChunk.FindAll(p, -1)
 	for _, c := range chunks {
-		w.bufferChunk(c)
+		w.bufferChunk(c, now)
 	}
 }
 
@@ -62,20 +69,20 @@ func (w *Writer) copy() (int, error) {
 	return w.translateOffset(int(n)), err
 }
 
-func (w *Writer) bufferChunk(c []byte) {
+func (w *Writer) bufferChunk(c []byte, now time.Time) {
+	prefixLen := 0
 	if !w.continued {
-		w.buf.Write(w.linePrefix)
+		prefix := w.prefix(now)
+		w.buf.WriteString(prefix)
+		prefixLen = len(prefix)
 	}
 	w.buf.Write(c)
-	w.keeps = append(w.keeps, w.keep(c))
+	w.keeps = append(w.keeps, w.keep(c, prefixLen))
 	w.continued = !_LineWithEnd.Match(c)
 }
 
-func (w *Writer) keep(c []byte) [2]int {
-	off := 0
-	if !w.continued {
-		off += len(w.linePrefix)
-	}
+func (w *Writer) keep(c []byte, prefixLen int) [2]int {
+	off := prefixLen
 	if len(w.keeps) != 0 {
 		last := len(w.keeps) - 1
 		off += w.keeps[last][1]
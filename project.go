@@ -20,3 +20,28 @@ type ProjectInfo struct {
 	Name    string
 	Version string
 }
+
+// A Planner can describe how a project is built without building it.
+type Planner interface {
+	Plan() string
+}
+
+// A Wrapper is a project that changes or adds to another one.
+type Wrapper interface {
+	Unwrap() Project
+}
+
+// Unwrap returns the project at the bottom of any wrappers around p.
+func Unwrap(p Project) Project {
+	for {
+		w, ok := p.(Wrapper)
+		if !ok {
+			return p
+		}
+		inner := w.Unwrap()
+		if inner == nil {
+			return p
+		}
+		p = inner
+	}
+}
@@ -13,37 +13,74 @@ import (
 
 type ProjectSuite struct {
 	projects []Project
+	soft     map[RequirementKind]bool
+	rules    Rules
+}
+
+// An Edge says that one project uses something another one builds.
+// Manual edges come from rules rather than from what the projects declare.
+type Edge struct {
+	From, To Project
+	Kind     RequirementKind
+	Manual   bool
+}
+
+// edge is an Edge between projects of the suite, given by their indices.
+type edge struct {
+	from, to int
+	kind     RequirementKind
+	manual   bool
+	soft     bool
 }
 
 func NewProjectSuite(projects ...Project) *ProjectSuite {
 	return &ProjectSuite{
 		projects: append([]Project{}, projects...),
+		soft:     map[RequirementKind]bool{},
+	}
+}
+
+// Soften makes requirements of the given kinds soft.
+// Soft requirements are reported as edges, but do not constrain the build order.
+func (ps *ProjectSuite) Soften(kinds ...RequirementKind) {
+	for _, k := range kinds {
+		ps.soft[k] = true
 	}
 }
 
+// AddRules makes the suite resolve the build order with the manual edges of the rules as well.
+func (ps *ProjectSuite) AddRules(rules Rules) {
+	ps.rules.Satisfies = append(ps.rules.Satisfies, rules.Satisfies...)
+	ps.rules.DependsOn = append(ps.rules.DependsOn, rules.DependsOn...)
+}
+
 func (ps *ProjectSuite) ResolveOrder() (OrderedProjectSuite, error) {
-	ixOrder, depGraph, err := ps.resolveOrder()
+	ixOrder, depGraph, edges, err := ps.resolveOrder()
 	if err != nil {
 		return OrderedProjectSuite{}, err
 	}
 	order := ps.orderProjects(ixOrder)
-	ordSuite := OrderedProjectSuite{ps.projects, depGraph, ixOrder, order}
+	ordSuite := OrderedProjectSuite{ps.projects, depGraph, ixOrder, order, edges}
 	return ordSuite, nil
 }
 
-func (ps *ProjectSuite) resolveOrder() ([]graph.NI, graph.Directed, error) {
+func (ps *ProjectSuite) resolveOrder() ([]graph.NI, graph.Directed, []edge, error) {
 	providers, err := ps.buildProviderMap()
 	if err != nil {
-		return nil, graph.Directed{}, oops.Wrapf(err, "problem building providers map")
+		return nil, graph.Directed{}, nil, oops.Wrapf(err, "problem building providers map")
+	}
+	manual, err := ps.buildManualProviderMap(providers)
+	if err != nil {
+		return nil, graph.Directed{}, nil, oops.Wrapf(err, "problem applying rules")
 	}
 
-	depGraph := ps.buildDepGraph(providers)
+	depGraph, edges := ps.buildDepGraph(providers, manual)
 	order, cycle := depGraph.Topological()
 	if len(cycle) > 0 {
 		pjsCycle := ps.orderProjects(cycle)
-		return nil, depGraph, NewDependencyCycleError(pjsCycle)
+		return nil, depGraph, edges, NewDependencyCycleError(pjsCycle)
 	}
-	return order, depGraph, nil
+	return order, depGraph, edges, nil
 }
 
 func (ps *ProjectSuite) buildProviderMap() (map[RequirementIdentity]int, error) {
@@ -65,30 +102,101 @@ func (ps *ProjectSuite) buildProviderMap() (map[RequirementIdentity]int, error)
 	return providers, nil
 }
 
-func (ps *ProjectSuite) buildDepGraph(providerIxs map[RequirementIdentity]int) graph.Directed {
+// buildManualProviderMap finds the projects that the satisfies rules make providers of what they do not build.
+func (ps *ProjectSuite) buildManualProviderMap(providers map[RequirementIdentity]int) (map[RequirementIdentity]int, error) {
+	manual := map[RequirementIdentity]int{}
+	for _, rule := range ps.rules.Satisfies {
+		ix, present := providers[rule.Built]
+		if !present {
+			log.Printf("no project builds %s, which a rule says provides %s", rule.Built, rule.Required)
+			continue
+		}
+		if prev, present := providers[rule.Required]; present && prev != ix {
+			return nil, oops.Errorf(
+				"%s builds %s, which a rule says %s provides",
+				ps.projects[prev].Info().Name, rule.Required, ps.projects[ix].Info().Name)
+		}
+		if prev, present := manual[rule.Required]; present && prev != ix {
+			return nil, oops.Errorf(
+				"rules say both %s and %s provide %s",
+				ps.projects[ix].Info().Name, ps.projects[prev].Info().Name, rule.Required)
+		}
+		manual[rule.Required] = ix
+	}
+	return manual, nil
+}
+
+func (ps *ProjectSuite) buildDepGraph(providerIxs, manualIxs map[RequirementIdentity]int) (graph.Directed, []edge) {
 	adjList := make(graph.AdjacencyList, len(ps.projects))
+	var edges []edge
 	for i, p := range ps.projects {
-		adjList[i] = ps.edgeEnds(p, providerIxs)
+		edges = append(edges, ps.edgesFrom(i, p, providerIxs, manualIxs)...)
+	}
+	edges = append(edges, ps.dependsOnEdges()...)
+	for i, e := range edges {
+		edges[i].soft = ps.soft[e.kind]
+		if !edges[i].soft {
+			adjList[e.from] = append(adjList[e.from], graph.NI(e.to))
+		}
 	}
-	inverse := graph.Directed{adjList}
+	inverse := graph.Directed{AdjacencyList: adjList}
 	depGraph, _ := inverse.Transpose()
-	return depGraph
+	return depGraph, edges
 }
 
-func (ps *ProjectSuite) edgeEnds(p Project, providerIxs map[RequirementIdentity]int) []graph.NI {
-	uses := p.Uses()
-	ends := make([]graph.NI, 0, len(uses))
-	for _, req := range uses {
+func (ps *ProjectSuite) edgesFrom(i int, p Project, providerIxs, manualIxs map[RequirementIdentity]int) []edge {
+	var edges []edge
+	for _, req := range p.Uses() {
 
 		ix, present := providerIxs[req.ID()]
 		if !present {
+			if ix, manual := manualIxs[req.ID()]; manual {
+				edges = append(edges, edge{from: i, to: ix, kind: KindOf(req), manual: true})
+				continue
+			}
 			log.Printf("no provider for %#v", req.ID())
 			continue
 		}
-		ends = append(ends, graph.NI(ix))
+		if !buildsSatisfying(ps.projects[ix], req) {
+			// Some other version is used, which comes from outside of the suite.
+			log.Printf("%s requires %s in a version %s does not build", p.Info().Name, req.ID(), ps.projects[ix].Info().Name)
+			continue
+		}
+		edges = append(edges, edge{from: i, to: ix, kind: KindOf(req)})
+
+	}
+	return edges
+}
+
+// dependsOnEdges makes the edges of the depends on rules, between projects found by name.
+func (ps *ProjectSuite) dependsOnEdges() []edge {
+	ixs := map[string]int{}
+	for i, p := range ps.projects {
+		if _, present := ixs[p.Info().Name]; !present {
+			ixs[p.Info().Name] = i
+		}
+	}
+	var edges []edge
+	for _, rule := range ps.rules.DependsOn {
+		from, fromPresent := ixs[rule.Project]
+		to, toPresent := ixs[rule.On]
+		if !fromPresent || !toPresent {
+			log.Printf("a rule says %s depends on %s, but there is no such project", rule.Project, rule.On)
+			continue
+		}
+		edges = append(edges, edge{from: from, to: to, kind: rule.Kind, manual: true})
+	}
+	return edges
+}
 
+// buildsSatisfying tells whether the project builds what the requirement asks for in an acceptable version.
+func buildsSatisfying(p Project, req Requirement) bool {
+	for _, b := range p.Builds() {
+		if Satisfies(b, req) {
+			return true
+		}
 	}
-	return ends
+	return false
 }
 
 func (ps *ProjectSuite) orderProjects(order []graph.NI) []Project {
@@ -104,12 +212,37 @@ type OrderedProjectSuite struct {
 	depGraph graph.Directed
 	ixOrder  []graph.NI
 	order    []Project
+	edges    []edge
 }
 
 func (ops OrderedProjectSuite) Order() []Project {
 	return append([]Project{}, ops.order...)
 }
 
+// Edges returns all the edges between the projects, soft ones included.
+func (ops OrderedProjectSuite) Edges() []Edge {
+	edges := make([]Edge, 0, len(ops.edges))
+	for _, e := range ops.edges {
+		edges = append(edges, ops.export(e))
+	}
+	return edges
+}
+
+// SoftEdges returns the edges that were left out when resolving the build order.
+func (ops OrderedProjectSuite) SoftEdges() []Edge {
+	edges := []Edge{}
+	for _, e := range ops.edges {
+		if e.soft {
+			edges = append(edges, ops.export(e))
+		}
+	}
+	return edges
+}
+
+func (ops OrderedProjectSuite) export(e edge) Edge {
+	return Edge{From: ops.projects[e.from], To: ops.projects[e.to], Kind: e.kind, Manual: e.manual}
+}
+
 func (ops OrderedProjectSuite) Filter(fs ...Filter) FilteredProjectSuite {
 	include := make([]bool, len(ops.projects))
 	for _, f := range fs {
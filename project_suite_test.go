@@ -98,3 +98,133 @@ func TestIndirectCycleIsDetected(t *testing.T) {
 	// then
 	assert.That(err != nil, t.Errorf, "got no error when one is expected")
 }
+
+func TestTestCycleIsDetectedUnlessSoftened(t *testing.T) {
+	// given
+	idA := unibuild.RequirementIdentity{Name: "a"}
+	idB := unibuild.RequirementIdentity{Name: "b"}
+
+	var prjA unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "a"},
+		Uses_:   []unibuild.Requirement{Requirement{ID_: idB, Kind_: unibuild.Test}},
+		Builds_: []unibuild.RequirementVersion{{ID: idA}},
+	}
+	var prjB unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "b"},
+		Uses_:   []unibuild.Requirement{Requirement{ID_: idA}},
+		Builds_: []unibuild.RequirementVersion{{ID: idB}},
+	}
+
+	hardSuite := unibuild.NewProjectSuite(prjA, prjB)
+	softSuite := unibuild.NewProjectSuite(prjA, prjB)
+	softSuite.Soften(unibuild.Test)
+
+	// when
+	_, hardErr := hardSuite.ResolveOrder()
+	ordSuite, softErr := softSuite.ResolveOrder()
+
+	// then
+	assert.That(hardErr != nil, t.Errorf, "got no error when one is expected")
+	assert.That(softErr == nil, t.Fatalf, "unexpected error reported: %s", softErr)
+
+	order := ordSuite.Order()
+	assert.That(len(order) == 2, t.Fatalf, "got %d projects in order, want %d", len(order), 2)
+	assert.That(order[0] == prjA, t.Errorf, "got 0-th project %#v, want %#v", order[0].Info(), prjA.Info())
+
+	soft := ordSuite.SoftEdges()
+	assert.That(len(soft) == 1, t.Fatalf, "got %d soft edges, want %d", len(soft), 1)
+	want := unibuild.Edge{From: prjA, To: prjB, Kind: unibuild.Test}
+	assert.That(soft[0] == want, t.Errorf, "got soft edge %#v, want %#v", soft[0], want)
+}
+
+func TestOptionalRequirementsConstrainTheOrderUnlessSoftened(t *testing.T) {
+	// given
+	libID := unibuild.RequirementIdentity{Name: "lib"}
+
+	var app unibuild.Project = &Project{
+		Info_: unibuild.ProjectInfo{Name: "app"},
+		Uses_: []unibuild.Requirement{
+			Requirement{ID_: libID, Kind_: unibuild.Optional},
+		},
+	}
+	var lib unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "lib"},
+		Builds_: []unibuild.RequirementVersion{{ID: libID}},
+	}
+
+	hardSuite := unibuild.NewProjectSuite(app, lib)
+	softSuite := unibuild.NewProjectSuite(app, lib)
+	softSuite.Soften(unibuild.Optional)
+
+	// when
+	hardOrdSuite, hardErr := hardSuite.ResolveOrder()
+	softOrdSuite, softErr := softSuite.ResolveOrder()
+
+	// then
+	assert.That(hardErr == nil, t.Fatalf, "unexpected error reported: %s", hardErr)
+	order := hardOrdSuite.Order()
+	assert.That(len(order) == 2 && order[0] == lib, t.Errorf, "got order %v, want lib first", order)
+	assert.That(len(hardOrdSuite.SoftEdges()) == 0, t.Errorf, "got %d soft edges, want none", len(hardOrdSuite.SoftEdges()))
+
+	assert.That(softErr == nil, t.Fatalf, "unexpected error reported: %s", softErr)
+	assert.That(len(softOrdSuite.Order()) == 2, t.Errorf, "got %d projects in order, want %d", len(softOrdSuite.Order()), 2)
+	assert.That(len(softOrdSuite.SoftEdges()) == 1, t.Errorf, "got %d soft edges, want %d", len(softOrdSuite.SoftEdges()), 1)
+}
+
+func TestSofteningTestsLeavesRequirementsAlsoOptionalHard(t *testing.T) {
+	// given
+	libID := unibuild.RequirementIdentity{Name: "lib"}
+
+	var app unibuild.Project = &Project{
+		Info_: unibuild.ProjectInfo{Name: "app"},
+		Uses_: unibuild.MergeRequirements([]unibuild.Requirement{
+			Requirement{ID_: libID, Kind_: unibuild.Test},
+			Requirement{ID_: libID, Kind_: unibuild.Optional},
+		}),
+	}
+	var lib unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "lib"},
+		Builds_: []unibuild.RequirementVersion{{ID: libID}},
+	}
+	suite := unibuild.NewProjectSuite(app, lib)
+	suite.Soften(unibuild.Test)
+
+	// when
+	ordSuite, err := suite.ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error reported: %s", err)
+	order := ordSuite.Order()
+	assert.That(len(order) == 2 && order[0] == lib, t.Errorf, "got order %v, want lib first", order)
+	assert.That(len(ordSuite.SoftEdges()) == 0, t.Errorf, "got %d soft edges, want none", len(ordSuite.SoftEdges()))
+}
+
+func TestProjectBuildingAnUnacceptedVersionIsNotAProvider(t *testing.T) {
+	// given
+	appID := unibuild.RequirementIdentity{Ecosystem: "npm", Name: "app"}
+	libID := unibuild.RequirementIdentity{Ecosystem: "npm", Name: "lib"}
+
+	var app unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "app"},
+		Builds_: []unibuild.RequirementVersion{{ID: appID, Version: "1.0.0"}},
+		Uses_: []unibuild.Requirement{
+			VersionedRequirement{Requirement: Requirement{ID_: libID}, Versions: []string{"1.0.0"}},
+		},
+	}
+	var lib unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "lib"},
+		Builds_: []unibuild.RequirementVersion{{ID: libID, Version: "2.0.0"}},
+		Uses_:   []unibuild.Requirement{Requirement{ID_: appID}},
+	}
+
+	suite := unibuild.NewProjectSuite(app, lib)
+
+	// when
+	ordSuite, err := suite.ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error reported: %s", err)
+	order := ordSuite.Order()
+	assert.That(len(order) == 2, t.Fatalf, "got %d projects in order, want %d", len(order), 2)
+	assert.That(order[0] == app, t.Errorf, "got 0-th project %#v, want %#v", order[0].Info(), app.Info())
+}
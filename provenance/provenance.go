@@ -0,0 +1,243 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package provenance records what went into a unibuild run.
+//
+// The record is an in-toto statement carrying a SLSA provenance (v0.2) predicate.
+// See https://slsa.dev/provenance/v0.2 for the meaning of the fields.
+//
+// unibuild does not know the digests of the artifacts it builds, so the statement has no subjects: the steps list
+// what each project produced instead.
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samsarahq/go/oops"
+)
+
+const (
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	PredicateType = "https://slsa.dev/provenance/v0.2"
+	BuildType     = "https://github.com/szabba/unibuild/Build@v1"
+	BuilderID     = "https://github.com/szabba/unibuild"
+)
+
+// A Statement is an in-toto statement about the artifacts produced by a build.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// A Subject is an artifact produced by the build.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// A Predicate describes how the subjects were produced.
+type Predicate struct {
+	Builder     Builder     `json:"builder"`
+	BuildType   string      `json:"buildType"`
+	Invocation  Invocation  `json:"invocation"`
+	BuildConfig BuildConfig `json:"buildConfig"`
+	Metadata    Metadata    `json:"metadata"`
+	Materials   []Material  `json:"materials"`
+}
+
+// A Builder identifies the unibuild binary that ran the build.
+type Builder struct {
+	ID     string            `json:"id"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// An Invocation captures the parameters and environment the build was started with.
+type Invocation struct {
+	Parameters  Parameters        `json:"parameters"`
+	Environment map[string]string `json:"environment"`
+}
+
+// Parameters are the user-controlled inputs of a unibuild run.
+type Parameters struct {
+	BaseURL  string   `json:"baseURL"`
+	Group    string   `json:"group"`
+	Branches []string `json:"branches"`
+	Filter   []string `json:"filter"`
+}
+
+// A BuildConfig lists the steps unibuild took, in the order it took them.
+type BuildConfig struct {
+	Steps []Step `json:"steps"`
+}
+
+// A Step is the build of a single project.
+type Step struct {
+	Project  string   `json:"project"`
+	Version  string   `json:"version"`
+	Command  string   `json:"command,omitempty"`
+	Produces []string `json:"produces"`
+}
+
+// Metadata about the build as a whole.
+type Metadata struct {
+	BuildStartedOn  time.Time    `json:"buildStartedOn"`
+	BuildFinishedOn time.Time    `json:"buildFinishedOn"`
+	Completeness    Completeness `json:"completeness"`
+	Reproducible    bool         `json:"reproducible"`
+}
+
+// Completeness tells which parts of the record are known to be exhaustive.
+type Completeness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+// A Material is a source repository the build consumed.
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// A Recorder collects provenance while a build is running.
+// It is safe for concurrent use.
+type Recorder struct {
+	mtx  sync.Mutex
+	stmt Statement
+}
+
+// NewRecorder starts recording the provenance of a build run by the binary with the given SHA-256 hash.
+func NewRecorder(binHash [32]byte, params Parameters) *Recorder {
+	params.Branches = copyStrings(params.Branches)
+	params.Filter = copyStrings(params.Filter)
+	return &Recorder{
+		stmt: Statement{
+			Type:          StatementType,
+			PredicateType: PredicateType,
+			Subject:       []Subject{},
+			Predicate: Predicate{
+				Builder: Builder{
+					ID:     BuilderID,
+					Digest: map[string]string{"sha256": fmt.Sprintf("%x", binHash)},
+				},
+				BuildType: BuildType,
+				Invocation: Invocation{
+					Parameters:  params,
+					Environment: map[string]string{},
+				},
+				BuildConfig: BuildConfig{Steps: []Step{}},
+				Metadata: Metadata{
+					BuildStartedOn: time.Now().UTC(),
+					Completeness:   Completeness{Parameters: true},
+				},
+				Materials: []Material{},
+			},
+		},
+	}
+}
+
+// Toolchain records the version of a tool used during the build.
+func (rec *Recorder) Toolchain(tool, version string) {
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	rec.stmt.Predicate.Invocation.Environment[tool] = version
+}
+
+// Source records a repository checked out at the given ref and commit.
+func (rec *Recorder) Source(remoteURL, ref, commit string) {
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	rec.stmt.Predicate.Materials = append(rec.stmt.Predicate.Materials, Material{
+		URI:    fmt.Sprintf("git+%s@refs/heads/%s", remoteURL, ref),
+		Digest: map[string]string{"sha1": commit},
+	})
+}
+
+// Built records a project that was built, how it was built and the coordinates it produced.
+func (rec *Recorder) Built(step Step) {
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	pred := &rec.stmt.Predicate
+	step.Produces = copyStrings(step.Produces)
+	pred.BuildConfig.Steps = append(pred.BuildConfig.Steps, step)
+}
+
+// Statement returns a copy of the provenance recorded so far, marking the build as finished now.
+func (rec *Recorder) Statement() Statement {
+	rec.mtx.Lock()
+	defer rec.mtx.Unlock()
+	stmt := rec.stmt
+	stmt.Subject = []Subject{}
+
+	pred := &stmt.Predicate
+	pred.Builder.Digest = copyMap(pred.Builder.Digest)
+	pred.Invocation.Parameters.Branches = copyStrings(pred.Invocation.Parameters.Branches)
+	pred.Invocation.Parameters.Filter = copyStrings(pred.Invocation.Parameters.Filter)
+	pred.Invocation.Environment = copyMap(pred.Invocation.Environment)
+	pred.BuildConfig.Steps = make([]Step, 0, len(rec.stmt.Predicate.BuildConfig.Steps))
+	for _, step := range rec.stmt.Predicate.BuildConfig.Steps {
+		step.Produces = copyStrings(step.Produces)
+		pred.BuildConfig.Steps = append(pred.BuildConfig.Steps, step)
+	}
+	pred.Metadata.BuildFinishedOn = time.Now().UTC()
+	pred.Materials = make([]Material, 0, len(rec.stmt.Predicate.Materials))
+	for _, m := range rec.stmt.Predicate.Materials {
+		pred.Materials = append(pred.Materials, Material{URI: m.URI, Digest: copyMap(m.Digest)})
+	}
+	sort.Slice(pred.Materials, func(i, j int) bool {
+		return pred.Materials[i].URI < pred.Materials[j].URI
+	})
+	return stmt
+}
+
+func copyMap(digest map[string]string) map[string]string {
+	if digest == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(digest))
+	for k, v := range digest {
+		copied[k] = v
+	}
+	return copied
+}
+
+// copyStrings copies a slice, keeping it nil when it is.
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+// WriteTo writes the provenance as indented JSON.
+func (rec *Recorder) WriteTo(w io.Writer) (int64, error) {
+	out, err := json.MarshalIndent(rec.Statement(), "", "  ")
+	if err != nil {
+		return 0, oops.Wrapf(err, "cannot encode provenance")
+	}
+	n, err := w.Write(append(out, '\n'))
+	return int64(n), oops.Wrapf(err, "cannot write provenance")
+}
+
+// WriteFile writes the provenance to the file at path.
+func (rec *Recorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return oops.Wrapf(err, "cannot create provenance file %s", path)
+	}
+	_, err = rec.WriteTo(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return oops.Wrapf(f.Close(), "cannot close provenance file %s", path)
+}
@@ -0,0 +1,131 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package provenance_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/provenance"
+)
+
+var _Params = provenance.Parameters{
+	BaseURL:  "https://gitlab.example.com",
+	Group:    "acme",
+	Branches: []string{"develop", "master"},
+	Filter:   []string{"api"},
+}
+
+func TestStatementHasTheInTotoLayout(t *testing.T) {
+	// given
+	rec := provenance.NewRecorder([32]byte{0xab, 0xcd}, _Params)
+
+	// when
+	stmt := rec.Statement()
+
+	// then
+	assert.That(stmt.Type == provenance.StatementType, t.Errorf, "type: got %q, want %q", stmt.Type, provenance.StatementType)
+	assert.That(stmt.PredicateType == provenance.PredicateType, t.Errorf, "predicate type: got %q, want %q", stmt.PredicateType, provenance.PredicateType)
+	builder := stmt.Predicate.Builder
+	assert.That(builder.ID == provenance.BuilderID, t.Errorf, "builder id: got %q, want %q", builder.ID, provenance.BuilderID)
+	wantDigest := "abcd" + strings.Repeat("00", 30)
+	assert.That(builder.Digest["sha256"] == wantDigest, t.Errorf, "builder digest: got %q, want %q", builder.Digest["sha256"], wantDigest)
+	assert.That(stmt.Predicate.Invocation.Parameters.Group == "acme", t.Errorf, "parameters: got %#v", stmt.Predicate.Invocation.Parameters)
+	assert.That(!stmt.Predicate.Metadata.BuildFinishedOn.Before(stmt.Predicate.Metadata.BuildStartedOn), t.Errorf,
+		"build finished on %s, before it started on %s", stmt.Predicate.Metadata.BuildFinishedOn, stmt.Predicate.Metadata.BuildStartedOn)
+}
+
+func TestRecorderCollectsMaterialsAndSteps(t *testing.T) {
+	// given
+	rec := provenance.NewRecorder([32]byte{}, _Params)
+
+	// when
+	rec.Toolchain("mvn", "3.9.6")
+	rec.Source("https://gitlab.example.com/acme/web.git", "develop", "2222")
+	rec.Source("https://gitlab.example.com/acme/api.git", "master", "1111")
+	rec.Built(provenance.Step{Project: "api", Version: "1.0.0", Produces: []string{"com.acme:api:1.0.0", "com.acme:api-client:1.0.0"}})
+
+	// then
+	stmt := rec.Statement()
+	assert.That(stmt.Predicate.Invocation.Environment["mvn"] == "3.9.6", t.Errorf, "environment: got %v", stmt.Predicate.Invocation.Environment)
+
+	materials := stmt.Predicate.Materials
+	assert.That(len(materials) == 2, t.Fatalf, "got materials %v, want 2", materials)
+	want := "git+https://gitlab.example.com/acme/api.git@refs/heads/master"
+	assert.That(materials[0].URI == want, t.Errorf, "first material: got %q, want %q", materials[0].URI, want)
+	assert.That(materials[0].Digest["sha1"] == "1111", t.Errorf, "first material digest: got %v", materials[0].Digest)
+
+	steps := stmt.Predicate.BuildConfig.Steps
+	assert.That(len(steps) == 1 && steps[0].Project == "api", t.Fatalf, "got steps %v", steps)
+	assert.That(len(steps[0].Produces) == 2, t.Errorf, "got produced %v, want 2", steps[0].Produces)
+	assert.That(len(stmt.Subject) == 0, t.Errorf, "got subjects %v without digests", stmt.Subject)
+}
+
+func TestStatementDoesNotShareStateWithTheRecorder(t *testing.T) {
+	// given
+	rec := provenance.NewRecorder([32]byte{}, _Params)
+	rec.Toolchain("mvn", "3.9.6")
+	rec.Source("https://gitlab.example.com/acme/api.git", "master", "1111")
+	rec.Built(provenance.Step{Project: "api", Version: "1.0.0", Produces: []string{"com.acme:api:1.0.0"}})
+	stmt := rec.Statement()
+
+	// when
+	stmt.Predicate.Invocation.Environment["mvn"] = "changed"
+	stmt.Predicate.BuildConfig.Steps[0].Produces[0] = "changed"
+	stmt.Predicate.Materials[0].Digest["sha1"] = "changed"
+	stmt.Predicate.Invocation.Parameters.Branches[0] = "changed"
+	rec.Toolchain("go", "1.16")
+
+	// then
+	again := rec.Statement()
+	pred := again.Predicate
+	assert.That(pred.Invocation.Environment["mvn"] == "3.9.6", t.Errorf, "environment changed to %v", pred.Invocation.Environment)
+	assert.That(pred.BuildConfig.Steps[0].Produces[0] == "com.acme:api:1.0.0", t.Errorf, "step changed to %v", pred.BuildConfig.Steps[0])
+	assert.That(pred.Materials[0].Digest["sha1"] == "1111", t.Errorf, "material changed to %v", pred.Materials[0])
+	assert.That(pred.Invocation.Parameters.Branches[0] == "develop", t.Errorf, "parameters changed to %v", pred.Invocation.Parameters)
+	assert.That(len(stmt.Predicate.Invocation.Environment) == 1, t.Errorf, "statement sees later toolchains: %v", stmt.Predicate.Invocation.Environment)
+}
+
+func TestRecorderIsSafeForConcurrentUse(t *testing.T) {
+	// given
+	rec := provenance.NewRecorder([32]byte{}, _Params)
+	var wg sync.WaitGroup
+
+	// when
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec.Source("https://gitlab.example.com/acme/api.git", "master", "1111")
+		}()
+	}
+	wg.Wait()
+
+	// then
+	materials := rec.Statement().Predicate.Materials
+	assert.That(len(materials) == 10, t.Errorf, "got %d materials, want 10", len(materials))
+}
+
+func TestWriteToEncodesTheStatementAsJSON(t *testing.T) {
+	// given
+	rec := provenance.NewRecorder([32]byte{}, _Params)
+	rec.Built(provenance.Step{Project: "api", Version: "1.0.0", Command: "mvn deploy", Produces: []string{"com.acme:api:1.0.0"}})
+	out := new(strings.Builder)
+
+	// when
+	_, err := rec.WriteTo(out)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	var decoded map[string]interface{}
+	err = json.Unmarshal([]byte(out.String()), &decoded)
+	assert.That(err == nil, t.Fatalf, "cannot decode the written provenance: %s", err)
+	assert.That(decoded["_type"] == provenance.StatementType, t.Errorf, "_type: got %v", decoded["_type"])
+	assert.That(strings.Contains(out.String(), `"command": "mvn deploy"`), t.Errorf, "the command is missing from\n%s", out.String())
+}
@@ -0,0 +1,281 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/toml"
+)
+
+const (
+	PyProject = "pyproject.toml"
+	SetupCfg  = "setup.cfg"
+)
+
+var ErrNotPython = errors.New("no " + PyProject + " or " + SetupCfg + " with a distribution name found")
+
+// Metadata of a distribution, as declared statically by a project.
+type Metadata struct {
+	// Source is the file the metadata was read from.
+	Source  string
+	Name    string
+	Version string
+	// Requires lists the requirements in the order they were declared, each distribution once.
+	Requires []Requirement
+}
+
+// ReadMetadata reads the metadata of the project in dir.
+// It is taken from the [project] table of pyproject.toml, or from Poetry's [tool.poetry] table, and otherwise from
+// setup.cfg. Projects that only declare their metadata in setup.py cannot be analyzed.
+func ReadMetadata(dir string) (Metadata, error) {
+	md, err := readPyProject(dir)
+	if err == nil || oops.Cause(err) != ErrNotPython {
+		return md, err
+	}
+	return readSetupCfg(dir)
+}
+
+type pyProject struct {
+	BuildSystem struct {
+		Requires []string `json:"requires"`
+	} `json:"build-system"`
+
+	Project *struct {
+		Name                 string              `json:"name"`
+		Version              string              `json:"version"`
+		Dependencies         []string            `json:"dependencies"`
+		OptionalDependencies map[string][]string `json:"optional-dependencies"`
+	} `json:"project"`
+
+	// DependencyGroups (from PEP 735) may also include other groups, which are tables and so get skipped.
+	DependencyGroups map[string][]interface{} `json:"dependency-groups"`
+
+	Tool struct {
+		Poetry *struct {
+			Name            string                 `json:"name"`
+			Version         string                 `json:"version"`
+			Dependencies    map[string]interface{} `json:"dependencies"`
+			DevDependencies map[string]interface{} `json:"dev-dependencies"`
+			Group           map[string]poetryGroup `json:"group"`
+		} `json:"poetry"`
+	} `json:"tool"`
+}
+
+type poetryGroup struct {
+	Dependencies map[string]interface{} `json:"dependencies"`
+}
+
+func readPyProject(dir string) (Metadata, error) {
+	path := filepath.Join(dir, PyProject)
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Metadata{}, oops.Wrapf(ErrNotPython, "in %s", dir)
+	}
+	if err != nil {
+		return Metadata{}, oops.Wrapf(err, "cannot read %s", path)
+	}
+	var pp pyProject
+	if err := toml.Unmarshal(raw, &pp); err != nil {
+		return Metadata{}, oops.Wrapf(err, "cannot parse %s", path)
+	}
+
+	md := Metadata{Source: PyProject}
+	reqs := requirements{}
+	poetry := pp.Tool.Poetry
+	switch {
+	case pp.Project != nil && pp.Project.Name != "":
+		md.Name, md.Version = pp.Project.Name, pp.Project.Version
+		reqs.addPEP508(pp.Project.Dependencies, unibuild.Compile)
+		for _, extra := range sortedGroups(pp.Project.OptionalDependencies) {
+			reqs.addPEP508(pp.Project.OptionalDependencies[extra], unibuild.Optional)
+		}
+	case poetry != nil && poetry.Name != "":
+		md.Name, md.Version = poetry.Name, poetry.Version
+		reqs.addPoetry(poetry.Dependencies, unibuild.Compile)
+	default:
+		return Metadata{}, oops.Wrapf(ErrNotPython, "%s has no [project] or [tool.poetry] name", path)
+	}
+	reqs.addPEP508(pp.BuildSystem.Requires, unibuild.Compile)
+
+	if poetry != nil {
+		reqs.addPoetry(poetry.DevDependencies, unibuild.Test)
+		for _, name := range sortedPoetryGroups(poetry.Group) {
+			reqs.addPoetry(poetry.Group[name].Dependencies, unibuild.Test)
+		}
+	}
+	groups := map[string][]string{}
+	for name, items := range pp.DependencyGroups {
+		for _, item := range items {
+			if line, ok := item.(string); ok {
+				groups[name] = append(groups[name], line)
+			}
+		}
+	}
+	for _, name := range sortedGroups(groups) {
+		reqs.addPEP508(groups[name], unibuild.Test)
+	}
+
+	md.Requires = reqs.merged()
+	return md, nil
+}
+
+func readSetupCfg(dir string) (Metadata, error) {
+	path := filepath.Join(dir, SetupCfg)
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Metadata{}, oops.Wrapf(ErrNotPython, "in %s", dir)
+	}
+	if err != nil {
+		return Metadata{}, oops.Wrapf(err, "cannot read %s", path)
+	}
+	cfg := parseINI(string(raw))
+	md := Metadata{
+		Source:  SetupCfg,
+		Name:    cfg["metadata"]["name"],
+		Version: cfg["metadata"]["version"],
+	}
+	if md.Name == "" {
+		return Metadata{}, oops.Wrapf(ErrNotPython, "%s has no [metadata] name", path)
+	}
+	if strings.HasPrefix(md.Version, "attr:") || strings.HasPrefix(md.Version, "file:") {
+		// The version is only known once setuptools runs.
+		md.Version = ""
+	}
+
+	reqs := requirements{}
+	reqs.addPEP508(lines(cfg["options"]["install_requires"]), unibuild.Compile)
+	reqs.addPEP508(lines(cfg["options"]["setup_requires"]), unibuild.Compile)
+	extras := cfg["options.extras_require"]
+	for _, extra := range sortedOptions(extras) {
+		reqs.addPEP508(lines(extras[extra]), unibuild.Optional)
+	}
+	reqs.addPEP508(lines(cfg["options"]["tests_require"]), unibuild.Test)
+	md.Requires = reqs.merged()
+	return md, nil
+}
+
+// parseINI parses the configparser format setup.cfg is in, into the values of keys within sections.
+// Indented lines continue the value of the previous key.
+func parseINI(src string) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	section, key := "", ""
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			if key != "" {
+				sections[section][key] += "\n" + trimmed
+			}
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section, key = strings.TrimSpace(trimmed[1:len(trimmed)-1]), ""
+			if sections[section] == nil {
+				sections[section] = map[string]string{}
+			}
+		default:
+			sep := strings.IndexAny(trimmed, "=:")
+			if sep < 0 || sections[section] == nil {
+				key = ""
+				continue
+			}
+			key = strings.TrimSpace(trimmed[:sep])
+			sections[section][key] = strings.TrimSpace(trimmed[sep+1:])
+		}
+	}
+	return sections
+}
+
+// lines splits a multi-line setup.cfg value into its entries.
+func lines(value string) []string {
+	var out []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// requirements collects the requirements of a project.
+// A distribution required more than once keeps the requirement of the strongest kind.
+type requirements struct {
+	list []unibuild.Requirement
+}
+
+func (reqs *requirements) add(req Requirement) {
+	reqs.list = append(reqs.list, req)
+}
+
+// merged lists the requirements in the order they were declared, each distribution once.
+func (reqs requirements) merged() []Requirement {
+	merged := unibuild.MergeRequirements(reqs.list)
+	out := make([]Requirement, 0, len(merged))
+	for _, req := range merged {
+		out = append(out, req.(Requirement))
+	}
+	return out
+}
+
+func (reqs *requirements) addPEP508(lines []string, kind unibuild.RequirementKind) {
+	for _, line := range lines {
+		if req, ok := ParseRequirement(line, kind); ok {
+			reqs.add(req)
+		}
+	}
+}
+
+func (reqs *requirements) addPoetry(deps map[string]interface{}, kind unibuild.RequirementKind) {
+	for _, name := range sortedNames(deps) {
+		if req, ok := NewPoetryRequirement(name, deps[name], kind); ok {
+			reqs.add(req)
+		}
+	}
+}
+
+func sortedNames(deps map[string]interface{}) []string {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedGroups(groups map[string][]string) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedPoetryGroups(groups map[string]poetryGroup) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedOptions(options map[string]string) []string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python_test
+
+import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/python"
+)
+
+func TestNamesAreNormalised(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"requests", "requests"},
+		{"Acme_Features", "acme-features"},
+		{"acme.utils", "acme-utils"},
+		{"zope__.-interface", "zope-interface"},
+	} {
+		// when
+		got := python.NormalizeName(tt.in)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "%q: got %q, want %q", tt.in, got, tt.want)
+	}
+}
+
+func TestRequirementsAreParsed(t *testing.T) {
+	for _, tt := range []struct {
+		line    string
+		ok      bool
+		name    string
+		kind    unibuild.RequirementKind
+		version string
+		accepts bool
+	}{
+		{"requests", true, "requests", unibuild.Compile, "2.31.0", true},
+		{"requests[socks] >=2.8.1, <3", true, "requests", unibuild.Compile, "3.0", false},
+		{"Pillow (>=9)", true, "pillow", unibuild.Compile, "10.0", true},
+		{`numpy>=1.24; python_version >= "3.9"`, true, "numpy", unibuild.Compile, "1.26", true},
+		{`pytest-cov; extra == "test"`, true, "pytest-cov", unibuild.Optional, "4.1", true},
+		{"private @ git+https://git.acme.example/private.git", false, "", 0, "", false},
+		{"# a comment", false, "", 0, "", false},
+	} {
+		// when
+		req, ok := python.ParseRequirement(tt.line, unibuild.Compile)
+
+		// then
+		assert.That(ok == tt.ok, t.Errorf, "%q: got ok %v, want %v", tt.line, ok, tt.ok)
+		if !ok {
+			continue
+		}
+		assert.That(req.ID() == python.DistributionID(tt.name), t.Errorf, "%q: got %s, want %s", tt.line, req.ID(), tt.name)
+		assert.That(req.Kind() == tt.kind, t.Errorf, "%q: got kind %s, want %s", tt.line, req.Kind(), tt.kind)
+		got := req.Accepts(tt.version)
+		assert.That(got == tt.accepts, t.Errorf, "%q accepts %s: got %v, want %v", tt.line, tt.version, got, tt.accepts)
+	}
+}
+
+func TestExtrasOfTestRequirementsStayTestRequirements(t *testing.T) {
+	// when
+	req, ok := python.ParseRequirement(`pytest-cov; extra == "test"`, unibuild.Test)
+
+	// then
+	assert.That(ok, t.Fatalf, "requirement not parsed")
+	assert.That(req.Kind() == unibuild.Test, t.Errorf, "got kind %s, want %s", req.Kind(), unibuild.Test)
+}
+
+func TestMetadataOfPEP621Projects(t *testing.T) {
+	// when
+	md, err := python.ReadMetadata("testdata/pep621")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(md.Source == python.PyProject, t.Errorf, "got source %q, want %q", md.Source, python.PyProject)
+	assert.That(md.Name == "Acme_Features", t.Errorf, "got name %q", md.Name)
+	assert.That(md.Version == "2.3.0", t.Errorf, "got version %q", md.Version)
+	assertRequires(t, md, map[string]unibuild.RequirementKind{
+		"acme-utils": unibuild.Compile,
+		"pandas":     unibuild.Compile,
+		"numpy":      unibuild.Compile,
+		"matplotlib": unibuild.Optional,
+		"click":      unibuild.Optional,
+		"setuptools": unibuild.Compile,
+		"wheel":      unibuild.Compile,
+		"pytest":     unibuild.Test,
+		"ruff":       unibuild.Test,
+	})
+}
+
+func TestMetadataOfPoetryProjects(t *testing.T) {
+	// when
+	md, err := python.ReadMetadata("testdata/poetry")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(md.Name == "acme-warehouse", t.Errorf, "got name %q", md.Name)
+	assert.That(md.Version == "0.4.1", t.Errorf, "got version %q", md.Version)
+	assertRequires(t, md, map[string]unibuild.RequirementKind{
+		"acme-features": unibuild.Compile,
+		"sqlalchemy":    unibuild.Compile,
+		"pyarrow":       unibuild.Optional,
+		"poetry-core":   unibuild.Compile,
+		"pytest":        unibuild.Test,
+	})
+}
+
+func TestMetadataFallsBackToSetupCfg(t *testing.T) {
+	// when
+	md, err := python.ReadMetadata("testdata/setupcfg")
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(md.Source == python.SetupCfg, t.Errorf, "got source %q, want %q", md.Source, python.SetupCfg)
+	assert.That(md.Name == "acme.utils", t.Errorf, "got name %q", md.Name)
+	assert.That(md.Version == "1.6.2", t.Errorf, "got version %q", md.Version)
+	assertRequires(t, md, map[string]unibuild.RequirementKind{
+		"requests":          unibuild.Compile,
+		"typing-extensions": unibuild.Compile,
+		"click":             unibuild.Optional,
+		"pytest":            unibuild.Test,
+	})
+}
+
+func TestMetadataIsNotFoundInToolConfiguration(t *testing.T) {
+	// when
+	_, err := python.ReadMetadata("testdata/toolsonly")
+
+	// then
+	assert.That(oops.Cause(err) == python.ErrNotPython, t.Errorf, "got error %v, want %v", err, python.ErrNotPython)
+}
+
+func assertRequires(t *testing.T, md python.Metadata, want map[string]unibuild.RequirementKind) {
+	t.Helper()
+	assert.That(len(md.Requires) == len(want), t.Errorf, "got %d requirements, want %d", len(md.Requires), len(want))
+	for _, req := range md.Requires {
+		kind, known := want[req.ID().Name]
+		assert.That(known, t.Errorf, "unexpected requirement %s", req.ID())
+		assert.That(req.Kind() == kind, t.Errorf, "%s: got kind %s, want %s", req.ID(), req.Kind(), kind)
+	}
+}
@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package python lets unibuild build Python projects that publish distributions, like wheels, to a package index.
+//
+// The projects are analyzed by reading the metadata they declare statically, in pyproject.toml or setup.cfg.
+// Distribution names are normalised as in PEP 503, and version specifiers are kept, so a project in the suite only
+// counts as providing a distribution when its version is one a dependent accepts.
+package python
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/repo"
+)
+
+// DefaultCommands build the source distribution and wheel, then upload them with twine.
+var DefaultCommands = []string{
+	"python -m build",
+	"python -m twine upload dist/*",
+}
+
+// A Config controls how Python projects are built.
+type Config struct {
+	// Commands run one after another by the shell, in the root of the repository.
+	// When empty, the DefaultCommands are used.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// A Project that publishes a Python distribution.
+type Project struct {
+	name     string
+	version  string
+	clone    repo.Local
+	commands []string
+	uses     []unibuild.Requirement
+	builds   []unibuild.RequirementVersion
+}
+
+var (
+	_ unibuild.Project = Project{}
+	_ unibuild.Planner = Project{}
+)
+
+// NewProject attempts to create a Python project given a locally cloned repository.
+func NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	return Config{}.NewProject(ctx, clone)
+}
+
+// Detector finds Python projects.
+func (cfg Config) Detector() unibuild.Detector {
+	return unibuild.NewDetector("python", 15, ErrNotPython, func(ctx context.Context, clone repo.Local) (unibuild.Project, error) {
+		return cfg.NewProject(ctx, clone)
+	})
+}
+
+// NewProject attempts to create a Python project given a locally cloned repository.
+func (cfg Config) NewProject(ctx context.Context, clone repo.Local) (Project, error) {
+	md, err := ReadMetadata(clone.Path)
+	if err != nil {
+		return Project{}, oops.Wrapf(err, "problem reading Python metadata in %s", clone.Path)
+	}
+
+	commands := cfg.Commands
+	if len(commands) == 0 {
+		commands = DefaultCommands
+	}
+	id := DistributionID(md.Name)
+	uses := make([]unibuild.Requirement, 0, len(md.Requires))
+	for _, req := range md.Requires {
+		if req.ID() != id {
+			uses = append(uses, req)
+		}
+	}
+	prj := Project{
+		name:     clone.Name,
+		version:  md.Version,
+		clone:    clone,
+		commands: commands,
+		uses:     uses,
+		builds:   []unibuild.RequirementVersion{{ID: id, Version: md.Version}},
+	}
+	return prj, nil
+}
+
+func (prj Project) Info() unibuild.ProjectInfo {
+	return unibuild.ProjectInfo{
+		Name:    prj.name,
+		Version: prj.version,
+	}
+}
+
+func (prj Project) Uses() []unibuild.Requirement { return prj.uses }
+
+func (prj Project) Builds() []unibuild.RequirementVersion { return prj.builds }
+
+// Clone the project lives in.
+func (prj Project) Clone() repo.Local { return prj.clone }
+
+// Plan shows the commands the project is built with.
+func (prj Project) Plan() string { return strings.Join(prj.commands, " && ") }
+
+func (prj Project) Build(ctx context.Context, logTo io.Writer) error {
+	return prj.clone.RunShell(ctx, logTo, prj.commands...)
+}
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/python"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestProjectBuildsItsDistribution(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "features"}, Path: "testdata/pep621"}
+
+	// when
+	prj, err := python.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Info().Name == "features", t.Errorf, "got name %q", prj.Info().Name)
+	assert.That(prj.Info().Version == "2.3.0", t.Errorf, "got version %q", prj.Info().Version)
+
+	builds := prj.Builds()
+	want := unibuild.RequirementVersion{ID: unibuild.RequirementIdentity{Ecosystem: "pypi", Name: "acme-features"}, Version: "2.3.0"}
+	assert.That(len(builds) == 1 && builds[0] == want, t.Errorf, "got builds %v, want %v", builds, want)
+	assert.That(prj.Plan() == "python -m build && python -m twine upload dist/*", t.Errorf, "got plan %q", prj.Plan())
+}
+
+func TestProjectDoesNotUseItself(t *testing.T) {
+	// given
+	clone := repo.Local{Remote: repo.Remote{Name: "warehouse"}, Path: "testdata/poetry"}
+
+	// when
+	prj, err := python.NewProject(context.Background(), clone)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	for _, req := range prj.Uses() {
+		assert.That(req.ID() != prj.Builds()[0].ID, t.Errorf, "project uses its own distribution %s", req.ID())
+	}
+}
+
+func TestProjectUsesTheProviderVersionItAccepts(t *testing.T) {
+	// given
+	warehouse, err := python.NewProject(context.Background(), repo.Local{Path: "testdata/poetry"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	var features unibuild.Requirement
+	for _, req := range warehouse.Uses() {
+		if req.ID() == python.DistributionID("Acme.Features") {
+			features = req
+		}
+	}
+	assert.That(features != nil, t.Fatalf, "acme-features is not used")
+
+	for _, tt := range []struct {
+		version string
+		want    bool
+	}{
+		{"2.3.0", true},
+		{"3.0.0", false},
+	} {
+		// when
+		got := unibuild.Satisfies(unibuild.RequirementVersion{ID: features.ID(), Version: tt.version}, features)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "version %s satisfies %s: got %v, want %v", tt.version, features.ID(), got, tt.want)
+	}
+}
+
+func TestProjectCommandsCanBeConfigured(t *testing.T) {
+	// given
+	cfg := python.Config{Commands: []string{"make dist", "make upload"}}
+
+	// when
+	prj, err := cfg.NewProject(context.Background(), repo.Local{Path: "testdata/setupcfg"})
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(prj.Plan() == "make dist && make upload", t.Errorf, "got plan %q", prj.Plan())
+}
+
+func TestDetectorDeclinesRepositoriesWithoutMetadata(t *testing.T) {
+	// given
+	detector := python.Config{}.Detector()
+
+	// when
+	prjs, err := detector.Detect(context.Background(), repo.Local{Path: "testdata/toolsonly"})
+
+	// then
+	assert.That(oops.Cause(err) == unibuild.ErrDeclined, t.Errorf, "got error %v, want %v", err, unibuild.ErrDeclined)
+	assert.That(len(prjs) == 0, t.Errorf, "got projects %v", prjs)
+}
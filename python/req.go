@@ -0,0 +1,137 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/szabba/unibuild"
+)
+
+// Ecosystem of the requirements of Python projects, named after the package index.
+const Ecosystem = "pypi"
+
+var _Separators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName normalises a distribution name as PEP 503 does, so that differently spelled names of the same
+// distribution (like Acme_Utils and acme.utils) match.
+func NormalizeName(name string) string {
+	return _Separators.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+}
+
+// DistributionID identifies a distribution as a requirement, irrespective of its version.
+func DistributionID(name string) unibuild.RequirementIdentity {
+	return unibuild.RequirementIdentity{Ecosystem: Ecosystem, Name: NormalizeName(name)}
+}
+
+// A Requirement on a distribution from a package index, in the versions a specifier allows.
+type Requirement struct {
+	id   unibuild.RequirementIdentity
+	kind unibuild.RequirementKind
+	spec Specifier
+}
+
+var _ interface {
+	unibuild.KindedRequirement
+	unibuild.ConstrainedRequirement
+} = Requirement{}
+
+var _Name = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?`)
+
+// ParseRequirement creates a requirement out of a PEP 508 dependency specification, like
+// requests[socks]>=2.8.1,<3; python_version >= "3.8".
+// Direct references (name @ url) and lines that are not specifications do not make requirements.
+// Dependencies only needed for an extra are optional, unless the kind given is already weaker.
+// A specifier that cannot be parsed accepts any version.
+func ParseRequirement(line string, kind unibuild.RequirementKind) (Requirement, bool) {
+	line = strings.TrimSpace(line)
+	marker := ""
+	if semi := strings.IndexByte(line, ';'); semi >= 0 {
+		line, marker = strings.TrimSpace(line[:semi]), line[semi+1:]
+	}
+	name := _Name.FindString(line)
+	if name == "" {
+		return Requirement{}, false
+	}
+	rest := strings.TrimSpace(line[len(name):])
+	if strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return Requirement{}, false
+		}
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+	if strings.HasPrefix(rest, "@") {
+		return Requirement{}, false
+	}
+	rest = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(rest, "("), ")"))
+
+	if strings.Contains(marker, "extra") {
+		kind = kind.Weaker(unibuild.Optional)
+	}
+	req := Requirement{id: DistributionID(name), kind: kind}
+	if spec, err := ParseSpecifier(rest); err == nil {
+		req.spec = spec
+	}
+	return req, true
+}
+
+// NewPoetryRequirement creates a requirement out of a Poetry dependency, which is either a version constraint or a
+// table with one.
+// Dependencies on paths, git repositories and URLs do not make requirements, and neither does python itself.
+func NewPoetryRequirement(name string, dep interface{}, kind unibuild.RequirementKind) (Requirement, bool) {
+	if NormalizeName(name) == "python" {
+		return Requirement{}, false
+	}
+	constraint := ""
+	switch dep := dep.(type) {
+	case string:
+		constraint = dep
+	case map[string]interface{}:
+		for _, key := range []string{"path", "git", "url", "file"} {
+			if _, present := dep[key]; present {
+				return Requirement{}, false
+			}
+		}
+		constraint, _ = dep["version"].(string)
+		if opt, _ := dep["optional"].(bool); opt {
+			kind = kind.Weaker(unibuild.Optional)
+		}
+	case []interface{}:
+		// Multiple constraints apply to different environments, so any of them can be met.
+		var alts []string
+		for _, d := range dep {
+			if table, ok := d.(map[string]interface{}); ok {
+				if v, ok := table["version"].(string); ok {
+					alts = append(alts, v)
+				}
+			}
+		}
+		constraint = strings.Join(alts, "||")
+	default:
+		return Requirement{}, false
+	}
+
+	req := Requirement{id: DistributionID(name), kind: kind}
+	if spec, err := ParsePoetryConstraint(constraint); err == nil {
+		req.spec = spec
+	}
+	return req, true
+}
+
+func (req Requirement) ID() unibuild.RequirementIdentity { return req.id }
+
+func (req Requirement) Kind() unibuild.RequirementKind { return req.kind }
+
+// Accepts tells whether the specifier of the requirement allows the version.
+// Versions PEP 440 does not describe are only accepted by requirements without a specifier.
+func (req Requirement) Accepts(version string) bool {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return req.spec.unconstrained()
+	}
+	return req.spec.Contains(v)
+}
@@ -0,0 +1,368 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+var (
+	ErrBadVersion   = errors.New("not a PEP 440 version")
+	ErrBadSpecifier = errors.New("not a version specifier")
+)
+
+// A Version as described by PEP 440.
+// Local version labels are dropped, as they do not matter for what an index serves.
+type Version struct {
+	Epoch   int
+	Release []int
+	// PrePhase is the pre-release phase (a, b or rc), numbered by Pre.
+	PrePhase string
+	Pre      int
+	// Post and Dev are -1 when missing.
+	Post, Dev int
+}
+
+var _VersionPattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+// ParseVersion parses a version, normalising the spellings PEP 440 allows.
+func ParseVersion(s string) (Version, error) {
+	m := _VersionPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return Version{}, oops.Wrapf(ErrBadVersion, "cannot parse %q", s)
+	}
+	v := Version{Epoch: atoi(m[1]), Post: -1, Dev: -1}
+	for _, part := range strings.Split(m[2], ".") {
+		v.Release = append(v.Release, atoi(part))
+	}
+	if m[3] != "" {
+		v.PrePhase, v.Pre = normalPhase(m[3]), atoi(m[4])
+	}
+	switch {
+	case m[5] != "":
+		v.Post = atoi(m[5])
+	case m[6] != "":
+		v.Post = atoi(m[7])
+	}
+	if m[8] != "" {
+		v.Dev = atoi(m[9])
+	}
+	return v, nil
+}
+
+func normalPhase(phase string) string {
+	switch phase {
+	case "alpha":
+		return "a"
+	case "beta":
+		return "b"
+	case "c", "pre", "preview":
+		return "rc"
+	default:
+		return phase
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// IsPrerelease tells whether the version is a pre-release or a development release.
+func (v Version) IsPrerelease() bool { return v.PrePhase != "" || v.Dev >= 0 }
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater than other.
+func (v Version) Compare(other Version) int {
+	if c := compareInts(v.Epoch, other.Epoch); c != 0 {
+		return c
+	}
+	if c := compareRelease(v.Release, other.Release); c != 0 {
+		return c
+	}
+	for _, pair := range [][2]int{
+		{v.preKey(), other.preKey()},
+		{v.Pre, other.Pre},
+		{v.Post, other.Post},
+		{v.devKey(), other.devKey()},
+	} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// preKey orders the pre-release phases, with development releases of the final version before all of them.
+func (v Version) preKey() int {
+	switch {
+	case v.PrePhase == "" && v.Post < 0 && v.Dev >= 0:
+		return -1
+	case v.PrePhase == "":
+		return 3
+	default:
+		return strings.Index("a b rc", v.PrePhase) / 2
+	}
+}
+
+// devKey puts development releases before the release they lead up to.
+func (v Version) devKey() int {
+	if v.Dev < 0 {
+		return math.MaxInt32
+	}
+	return v.Dev
+}
+
+func compareRelease(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if c := compareInts(part(a, i), part(b, i)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// part gets a release segment, padding the release with zeroes.
+func part(release []int, i int) int {
+	if i < len(release) {
+		return release[i]
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// A Specifier of the versions a requirement accepts.
+// It is a union of clause sets, each of which is an intersection, so that Poetry constraints fit as well.
+type Specifier struct {
+	sets [][]clause
+}
+
+type clause struct {
+	op       string
+	version  Version
+	raw      string
+	wildcard bool
+}
+
+// ParseSpecifier parses a PEP 440 version specifier, like >=1.2,<2 or ~=1.4.
+// An empty specifier accepts any version.
+func ParseSpecifier(s string) (Specifier, error) {
+	set, err := parseClauses(s, false)
+	if err != nil {
+		return Specifier{}, oops.Wrapf(err, "cannot parse specifier %q", s)
+	}
+	return Specifier{sets: [][]clause{set}}, nil
+}
+
+// ParsePoetryConstraint parses a Poetry version constraint, which may also use ^, ~, bare versions and ||.
+func ParsePoetryConstraint(s string) (Specifier, error) {
+	var spec Specifier
+	for _, alt := range strings.Split(s, "||") {
+		set, err := parseClauses(alt, true)
+		if err != nil {
+			return Specifier{}, oops.Wrapf(err, "cannot parse constraint %q", s)
+		}
+		spec.sets = append(spec.sets, set)
+	}
+	return spec, nil
+}
+
+func parseClauses(s string, poetry bool) ([]clause, error) {
+	var set []clause
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" || raw == "*" {
+			continue
+		}
+		cs, err := parseClause(raw, poetry)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cs...)
+	}
+	return set, nil
+}
+
+var _Operators = []string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"}
+
+func parseClause(raw string, poetry bool) ([]clause, error) {
+	op := ""
+	for _, candidate := range _Operators {
+		if strings.HasPrefix(raw, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" && poetry && (strings.HasPrefix(raw, "^") || strings.HasPrefix(raw, "~")) {
+		op = raw[:1]
+	}
+	if op == "" && !poetry {
+		return nil, oops.Wrapf(ErrBadSpecifier, "no operator in %q", raw)
+	}
+	text := strings.TrimSpace(raw[len(op):])
+	if op == "" {
+		op = "=="
+	}
+	if op == "===" {
+		return []clause{{op: op, raw: text}}, nil
+	}
+
+	wildcard := strings.HasSuffix(text, ".*")
+	if wildcard && op != "==" && op != "!=" {
+		return nil, oops.Wrapf(ErrBadSpecifier, "wildcard with %s in %q", op, raw)
+	}
+	v, err := ParseVersion(strings.TrimSuffix(text, ".*"))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "~=":
+		if len(v.Release) < 2 {
+			return nil, oops.Wrapf(ErrBadSpecifier, "~= needs at least two release segments in %q", raw)
+		}
+		prefix := Version{Release: v.Release[:len(v.Release)-1], Post: -1, Dev: -1}
+		return []clause{{op: ">=", version: v}, {op: "==", version: prefix, wildcard: true}}, nil
+	case "^":
+		return []clause{{op: ">=", version: v}, {op: "<", version: caretBound(v)}}, nil
+	case "~":
+		return []clause{{op: ">=", version: v}, {op: "<", version: tildeBound(v)}}, nil
+	default:
+		return []clause{{op: op, version: v, wildcard: wildcard}}, nil
+	}
+}
+
+// caretBound is the lowest version a Poetry ^ constraint excludes: the next change of the leftmost non-zero segment.
+func caretBound(v Version) Version {
+	i := 0
+	for i < len(v.Release)-1 && v.Release[i] == 0 {
+		i++
+	}
+	return bump(v, i)
+}
+
+// tildeBound is the lowest version a Poetry ~ constraint excludes: the next minor one, or major when only it is given.
+func tildeBound(v Version) Version {
+	if len(v.Release) == 1 {
+		return bump(v, 0)
+	}
+	return bump(v, 1)
+}
+
+func bump(v Version, i int) Version {
+	release := append([]int{}, v.Release[:i+1]...)
+	release[i]++
+	// The lowest possible release of the bumped version, so its pre-releases are excluded as well.
+	return Version{Epoch: v.Epoch, Release: release, Post: -1, Dev: 0}
+}
+
+func (c clause) matches(v Version) bool {
+	switch c.op {
+	case "===":
+		return strings.EqualFold(c.raw, v.String())
+	case "==":
+		return c.equal(v)
+	case "!=":
+		return !c.equal(v)
+	}
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func (c clause) equal(v Version) bool {
+	if !c.wildcard {
+		return v.Compare(c.version) == 0
+	}
+	if v.Epoch != c.version.Epoch {
+		return false
+	}
+	for i, n := range c.version.Release {
+		if part(v.Release, i) != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains tells whether the version is accepted.
+// Pre-releases are only accepted by specifiers that mention one, as pip does not install them otherwise.
+func (spec Specifier) Contains(v Version) bool {
+	for _, set := range spec.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return len(spec.sets) == 0
+}
+
+// unconstrained tells whether the specifier accepts any version.
+func (spec Specifier) unconstrained() bool {
+	for _, set := range spec.sets {
+		if len(set) == 0 {
+			return true
+		}
+	}
+	return len(spec.sets) == 0
+}
+
+func setContains(set []clause, v Version) bool {
+	pre := false
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+		pre = pre || c.op != "<" && c.version.IsPrerelease()
+	}
+	return !v.IsPrerelease() || pre
+}
+
+func (v Version) String() string {
+	parts := make([]string, 0, len(v.Release))
+	for _, n := range v.Release {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	s := strings.Join(parts, ".")
+	if v.Epoch > 0 {
+		s = strconv.Itoa(v.Epoch) + "!" + s
+	}
+	if v.PrePhase != "" {
+		s += v.PrePhase + strconv.Itoa(v.Pre)
+	}
+	if v.Post >= 0 {
+		s += ".post" + strconv.Itoa(v.Post)
+	}
+	if v.Dev >= 0 {
+		s += ".dev" + strconv.Itoa(v.Dev)
+	}
+	return s
+}
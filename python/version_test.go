@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python_test
+
+import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/python"
+)
+
+func TestVersionsAreNormalised(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"1.0", "1.0"},
+		{"v1.2.3", "1.2.3"},
+		{"1.0-alpha.2", "1.0a2"},
+		{"1.0.RC1", "1.0rc1"},
+		{"1.0c1", "1.0rc1"},
+		{"1.0-1", "1.0.post1"},
+		{"1.0.post", "1.0.post0"},
+		{"1.0-dev3", "1.0.dev3"},
+		{"2!1.0+local.7", "2!1.0"},
+	} {
+		// when
+		v, err := python.ParseVersion(tt.in)
+
+		// then
+		assert.That(err == nil, t.Errorf, "%q: unexpected error: %s", tt.in, err)
+		assert.That(v.String() == tt.want, t.Errorf, "%q: got %q, want %q", tt.in, v, tt.want)
+	}
+}
+
+func TestVersionsAreOrdered(t *testing.T) {
+	// given
+	ordered := []string{
+		"1.0.dev1", "1.0a1.dev1", "1.0a1", "1.0a2", "1.0b1", "1.0rc1", "1.0", "1.0.post1.dev1", "1.0.post1",
+		"1.0.1", "1.1", "2!0.1",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			// when
+			a, _ := python.ParseVersion(ordered[i])
+			b, _ := python.ParseVersion(ordered[j])
+			got := a.Compare(b)
+
+			// then
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			assert.That(got == want, t.Errorf, "comparing %s with %s: got %d, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestMalformedVersionsAreRejected(t *testing.T) {
+	// when
+	_, err := python.ParseVersion("one.two")
+
+	// then
+	assert.That(oops.Cause(err) == python.ErrBadVersion, t.Errorf, "got error %v, want %v", err, python.ErrBadVersion)
+}
+
+func TestSpecifiersContainVersions(t *testing.T) {
+	for _, tt := range []struct {
+		spec, version string
+		want          bool
+	}{
+		{"", "3.0", true},
+		{"", "3.0b1", false},
+		{">=1.4,<2", "1.9.9", true},
+		{">=1.4,<2", "2.0", false},
+		{">=1.4,<2", "1.3", false},
+		{">=1.4, <2", "2.0a1", false},
+		{"~=2.1", "2.9", true},
+		{"~=2.1", "3.0", false},
+		{"~=2.1.3", "2.1.9", true},
+		{"~=2.1.3", "2.2.0", false},
+		{"==1.4.*", "1.4.7", true},
+		{"==1.4.*", "1.5", false},
+		{"!=1.4.*", "1.5", true},
+		{"==1.4", "1.4.0", true},
+		{">1.0", "1.0.post1", true},
+		{">=1.0b1", "1.0rc1", true},
+		{"===1.0", "1.0", true},
+	} {
+		// given
+		spec, err := python.ParseSpecifier(tt.spec)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.spec, err)
+		v, err := python.ParseVersion(tt.version)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.version, err)
+
+		// when
+		got := spec.Contains(v)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "%q contains %s: got %v, want %v", tt.spec, v, got, tt.want)
+	}
+}
+
+func TestPoetryConstraintsContainVersions(t *testing.T) {
+	for _, tt := range []struct {
+		constraint, version string
+		want                bool
+	}{
+		{"*", "7.1", true},
+		{"^2.1", "2.9.3", true},
+		{"^2.1", "3.0", false},
+		{"^2.1", "3.0a1", false},
+		{"^0.4.1", "0.4.9", true},
+		{"^0.4.1", "0.5.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9", true},
+		{"2.1.*", "2.1.5", true},
+		{"1.4.2", "1.4.2", true},
+		{"1.4.2", "1.4.3", false},
+		{"^1.0 || ^3.0", "3.2", true},
+		{"^1.0 || ^3.0", "2.2", false},
+		{">=2.0,<2.1", "2.0.7", true},
+	} {
+		// given
+		spec, err := python.ParsePoetryConstraint(tt.constraint)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.constraint, err)
+		v, err := python.ParseVersion(tt.version)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.version, err)
+
+		// when
+		got := spec.Contains(v)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "%q contains %s: got %v, want %v", tt.constraint, v, got, tt.want)
+	}
+}
+
+func TestSpecifiersNeedOperators(t *testing.T) {
+	// when
+	_, err := python.ParseSpecifier("1.4")
+
+	// then
+	assert.That(oops.Cause(err) == python.ErrBadSpecifier, t.Errorf, "got error %v, want %v", err, python.ErrBadSpecifier)
+}
@@ -0,0 +1,182 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package release implements coordinated releases of maven projects living in separate repositories.
+package release
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/maven"
+)
+
+// A Step of a release takes a single project from one version to another.
+type Step struct {
+	// Project as it was given to the plan, wrappers included.
+	Project  unibuild.Project
+	From, To string
+	maven    maven.Project
+}
+
+// Tag is the name of the tag the release of the step is marked with.
+func (step Step) Tag() string { return "v" + step.To }
+
+// A Plan of a release lists the steps in the order they need to be taken in.
+type Plan struct {
+	Steps []Step
+}
+
+// NewPlan plans releasing the projects, which should already be in build order.
+func NewPlan(prjs []unibuild.Project, part Part) (Plan, error) {
+	steps := make([]Step, 0, len(prjs))
+	for _, p := range prjs {
+		mvnPrj, ok := unibuild.Unwrap(p).(maven.Project)
+		if !ok {
+			return Plan{}, oops.Errorf("cannot release %s: only maven projects can be released", p.Info().Name)
+		}
+
+		from := p.Info().Version
+		to, err := NextVersion(from, part)
+		if err != nil {
+			return Plan{}, oops.Wrapf(err, "cannot release %s", p.Info().Name)
+		}
+		steps = append(steps, Step{Project: p, From: from, To: to, maven: mvnPrj})
+	}
+	return Plan{Steps: steps}, nil
+}
+
+// Versions maps every module released according to the plan to the version it is released with.
+func (plan Plan) Versions() maven.VersionMap {
+	versions := maven.VersionMap{}
+	for _, step := range plan.Steps {
+		for _, b := range step.Project.Builds() {
+			versions[b.ID.Name] = step.To
+		}
+	}
+	return versions
+}
+
+// Options of a release run.
+type Options struct {
+	// Build builds the project of a step once its POMs have been rewritten.
+	Build func(ctx context.Context, step Step) error
+	// Push makes the release commits and tags get pushed once all the projects are released.
+	Push bool
+}
+
+// Run takes the steps of the plan in order.
+// For each project it rewrites the versions in all its POMs, builds it, then commits the changes and tags the commit.
+// Nothing is pushed unless all the projects were released successfully.
+func (plan Plan) Run(ctx context.Context, opts Options) error {
+	versions := plan.Versions()
+	for _, step := range plan.Steps {
+		err := plan.release(ctx, step, versions, opts)
+		if err != nil {
+			return oops.Wrapf(err, "problem releasing %s %s", step.Project.Info().Name, step.To)
+		}
+	}
+
+	if !opts.Push {
+		return nil
+	}
+	for _, step := range plan.Steps {
+		err := step.maven.Clone().Push(ctx, step.Tag())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (plan Plan) release(ctx context.Context, step Step, versions maven.VersionMap, opts Options) error {
+	clone := step.maven.Clone()
+	err := rewritePoms(clone.Path, versions)
+	if err != nil {
+		return err
+	}
+
+	if opts.Build != nil {
+		err = opts.Build(ctx, step)
+		if err != nil {
+			// The rewritten POMs would otherwise be left behind for the next run to trip over.
+			if resetErr := clone.Reset(ctx); resetErr != nil {
+				clone.Log().Printf("cannot restore the POMs: %s", resetErr)
+			}
+			return err
+		}
+	}
+
+	message := fmt.Sprintf("Release %s %s", step.Project.Info().Name, step.To)
+	err = clone.CommitAll(ctx, message)
+	if err != nil {
+		return err
+	}
+	return clone.Tag(ctx, step.Tag(), message)
+}
+
+// rewritePoms rewrites the versions in all the POMs under dir, including the properties versions are set through.
+// Nothing is written unless all the POMs can be rewritten.
+func rewritePoms(dir string, versions maven.VersionMap) error {
+	poms, err := maven.PomFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	raws := make([][]byte, len(poms))
+	props := maven.VersionProperties{}
+	for i, path := range poms {
+		raws[i], err = ioutil.ReadFile(path)
+		if err != nil {
+			return oops.Wrapf(err, "cannot read %s", path)
+		}
+		err = props.Collect(raws[i], versions)
+		if err != nil {
+			return oops.Wrapf(err, "cannot rewrite versions in %s", path)
+		}
+	}
+
+	defined := map[string]bool{}
+	for i, path := range poms {
+		raws[i], err = maven.RewriteVersions(raws[i], versions)
+		if err != nil {
+			return oops.Wrapf(err, "cannot rewrite versions in %s", path)
+		}
+		var names []string
+		raws[i], names, err = props.Rewrite(raws[i])
+		if err != nil {
+			return oops.Wrapf(err, "cannot rewrite properties in %s", path)
+		}
+		for _, name := range names {
+			defined[name] = true
+		}
+	}
+	for _, name := range sortedNames(props) {
+		if !defined[name] {
+			return oops.Errorf("versions are set through the %s property, which none of the POMs in %s define", name, dir)
+		}
+	}
+
+	for i, path := range poms {
+		err := ioutil.WriteFile(path, raws[i], 0644)
+		if err != nil {
+			return oops.Wrapf(err, "cannot write %s", path)
+		}
+	}
+	return nil
+}
+
+func sortedNames(props maven.VersionProperties) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,224 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package release_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/maven"
+	"github.com/szabba/unibuild/release"
+	"github.com/szabba/unibuild/repo"
+)
+
+const (
+	_CorePom = `<project>
+  <groupId>org.example</groupId>
+  <artifactId>core</artifactId>
+  <version>1.0.0-SNAPSHOT</version>
+</project>
+`
+	_AppPom = `<project>
+  <groupId>org.example</groupId>
+  <artifactId>app</artifactId>
+  <version>0.3.0-SNAPSHOT</version>
+  <dependencies>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>core</artifactId>
+      <version>1.0.0-SNAPSHOT</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+)
+
+func TestRunReleasesProjectsInOrder(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	core := newClone(t, dir, "core", _CorePom)
+	app := newClone(t, dir, "app", _AppPom)
+
+	plan, err := release.NewPlan([]unibuild.Project{core, app}, release.Current)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	var built []string
+	opts := release.Options{Build: func(ctx context.Context, step release.Step) error {
+		built = append(built, step.Project.Info().Name)
+		return nil
+	}}
+
+	// when
+	err = plan.Run(context.Background(), opts)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(strings.Join(built, ",") == "core,app", t.Errorf, "got build order %q, want %q", built, []string{"core", "app"})
+
+	appPom := readFile(t, filepath.Join(app.Clone().Path, "pom.xml"))
+	assert.That(strings.Count(appPom, "-SNAPSHOT") == 0, t.Errorf, "snapshot versions left in app POM:\n%s", appPom)
+	assert.That(strings.Contains(appPom, "<version>0.3.0</version>"), t.Errorf, "app version not rewritten:\n%s", appPom)
+	assert.That(strings.Contains(appPom, "<version>1.0.0</version>"), t.Errorf, "core dependency version not rewritten:\n%s", appPom)
+
+	assert.That(git(t, core.Clone().Path, "tag", "--list") == "v1.0.0", t.Errorf, "core not tagged")
+	assert.That(git(t, app.Clone().Path, "tag", "--list") == "v0.3.0", t.Errorf, "app not tagged")
+	assert.That(git(t, app.Clone().Path, "status", "--porcelain") == "", t.Errorf, "app changes left uncommitted")
+	assert.That(git(t, filepath.Join(dir, "app.git"), "tag", "--list") == "", t.Errorf, "tags pushed without being asked to")
+}
+
+func TestRunPushesWhenAskedTo(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	core := newClone(t, dir, "core", _CorePom)
+
+	plan, err := release.NewPlan([]unibuild.Project{core}, release.Minor)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	err = plan.Run(context.Background(), release.Options{Push: true})
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	tags := git(t, filepath.Join(dir, "core.git"), "tag", "--list")
+	assert.That(tags == "v1.1.0", t.Errorf, "got remote tags %q, want %q", tags, "v1.1.0")
+}
+
+func TestRunStopsOnBuildFailure(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	core := newClone(t, dir, "core", _CorePom)
+	app := newClone(t, dir, "app", _AppPom)
+
+	plan, err := release.NewPlan([]unibuild.Project{core, app}, release.Patch)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	opts := release.Options{
+		Push: true,
+		Build: func(ctx context.Context, step release.Step) error {
+			if step.Project.Info().Name == "app" {
+				return os.ErrInvalid
+			}
+			return nil
+		},
+	}
+
+	// when
+	err = plan.Run(context.Background(), opts)
+
+	// then
+	assert.That(err != nil, t.Fatalf, "missing error")
+	assert.That(git(t, app.Clone().Path, "tag", "--list") == "", t.Errorf, "app tagged despite failing to build")
+	assert.That(git(t, filepath.Join(dir, "core.git"), "tag", "--list") == "", t.Errorf, "core pushed despite the release failing")
+	appPom := readFile(t, filepath.Join(app.Clone().Path, "pom.xml"))
+	assert.That(appPom == _AppPom, t.Errorf, "app POM left rewritten:\n%s", appPom)
+}
+
+const _RevisionPom = `<project>
+  <groupId>org.example</groupId>
+  <artifactId>core</artifactId>
+  <version>${revision}</version>
+  <properties>
+    <revision>1.0.0-SNAPSHOT</revision>
+  </properties>
+</project>
+`
+
+func TestRunRewritesVersionProperties(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	core := newClone(t, dir, "core", _RevisionPom)
+
+	plan, err := release.NewPlan([]unibuild.Project{core}, release.Current)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	err = plan.Run(context.Background(), release.Options{})
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	corePom := readFile(t, filepath.Join(core.Clone().Path, "pom.xml"))
+	assert.That(strings.Contains(corePom, "<revision>1.0.0</revision>"), t.Errorf, "revision not rewritten:\n%s", corePom)
+	assert.That(git(t, core.Clone().Path, "tag", "--list") == "v1.0.0", t.Errorf, "core not tagged")
+}
+
+func TestRunFailsBeforeBuildingWhenAVersionPropertyIsNotDefined(t *testing.T) {
+	// given
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	core := newClone(t, dir, "core", _RevisionPom)
+	// The property is only given to maven from the outside from now on.
+	pom := strings.Replace(_RevisionPom, "<revision>1.0.0-SNAPSHOT</revision>", "", 1)
+	err := ioutil.WriteFile(filepath.Join(core.Clone().Path, "pom.xml"), []byte(pom), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	plan, err := release.NewPlan([]unibuild.Project{core}, release.Current)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	built := false
+	opts := release.Options{Build: func(ctx context.Context, step release.Step) error {
+		built = true
+		return nil
+	}}
+
+	// when
+	err = plan.Run(context.Background(), opts)
+
+	// then
+	assert.That(err != nil, t.Fatalf, "got no error when one is expected")
+	assert.That(!built, t.Errorf, "core built despite its version not being rewritable")
+	assert.That(readFile(t, filepath.Join(core.Clone().Path, "pom.xml")) == pom, t.Errorf, "POM changed despite the release failing")
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "release")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return dir
+}
+
+// newClone creates a bare repository with a single commit adding the POM and returns a project in a clone of it.
+func newClone(t *testing.T, dir, name, pom string) maven.Project {
+	bare := filepath.Join(dir, name+".git")
+	path := filepath.Join(dir, name)
+	git(t, dir, "init", "--quiet", "--bare", bare)
+	git(t, dir, "clone", "--quiet", bare, path)
+	git(t, path, "config", "user.email", "release@example.com")
+	git(t, path, "config", "user.name", "Release Test")
+
+	err := ioutil.WriteFile(filepath.Join(path, "pom.xml"), []byte(pom), 0644)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	git(t, path, "add", "pom.xml")
+	git(t, path, "commit", "--quiet", "--message", "Initial commit")
+	git(t, path, "push", "--quiet", "origin", "HEAD")
+
+	clone := repo.Local{Remote: repo.Remote{Name: name, URL: bare}, Path: path}
+	prj, err := maven.NewProject(context.Background(), clone)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return prj
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	assert.That(err == nil, t.Fatalf, "git %s: %s\n%s", strings.Join(args, " "), err, out)
+	return strings.TrimSpace(string(out))
+}
+
+func readFile(t *testing.T, path string) string {
+	raw, err := ioutil.ReadFile(path)
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	return string(raw)
+}
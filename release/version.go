@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package release
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+var (
+	ErrUnknownPart = errors.New("unknown version part")
+	ErrBadVersion  = errors.New("version is not of the form major[.minor[.patch]][-SNAPSHOT]")
+)
+
+const _SnapshotSuffix = "-SNAPSHOT"
+
+// A Part of a version that gets bumped when releasing.
+type Part int
+
+const (
+	// Current releases a snapshot version as it is, without the -SNAPSHOT suffix.
+	Current Part = iota
+	Patch
+	Minor
+	Major
+)
+
+var _PartNames = []string{"current", "patch", "minor", "major"}
+
+// ParsePart parses the name of a Part.
+func ParsePart(name string) (Part, error) {
+	for i, n := range _PartNames {
+		if n == name {
+			return Part(i), nil
+		}
+	}
+	return 0, oops.Wrapf(ErrUnknownPart, "cannot parse %q", name)
+}
+
+func (part Part) String() string {
+	if part < 0 || int(part) >= len(_PartNames) {
+		return fmt.Sprintf("Part(%d)", int(part))
+	}
+	return _PartNames[part]
+}
+
+// NextVersion computes the version to release a project currently at the given version with.
+// Any -SNAPSHOT suffix is dropped and then the given part is bumped.
+func NextVersion(current string, part Part) (string, error) {
+	base := strings.TrimSuffix(current, _SnapshotSuffix)
+	if part == Current {
+		if base == current {
+			return "", oops.Errorf("%s is not a snapshot version, so it is released already", current)
+		}
+		return base, nil
+	}
+
+	nums, err := parseNumbers(base)
+	if err != nil {
+		return "", oops.Wrapf(err, "cannot bump version %s", current)
+	}
+	switch part {
+	case Patch:
+		nums[2]++
+	case Minor:
+		nums[1], nums[2] = nums[1]+1, 0
+	case Major:
+		nums[0], nums[1], nums[2] = nums[0]+1, 0, 0
+	default:
+		return "", oops.Wrapf(ErrUnknownPart, "cannot bump %s", part)
+	}
+	return fmt.Sprintf("%d.%d.%d", nums[0], nums[1], nums[2]), nil
+}
+
+func parseNumbers(version string) ([3]int, error) {
+	var nums [3]int
+	parts := strings.Split(version, ".")
+	if len(parts) > len(nums) {
+		return nums, ErrBadVersion
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nums, ErrBadVersion
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
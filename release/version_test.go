@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package release_test
+
+import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/release"
+)
+
+func TestNextVersion(t *testing.T) {
+	cases := []struct {
+		current string
+		part    release.Part
+		want    string
+	}{
+		{"1.4.2-SNAPSHOT", release.Current, "1.4.2"},
+		{"1.4.2-SNAPSHOT", release.Patch, "1.4.3"},
+		{"1.4.2-SNAPSHOT", release.Minor, "1.5.0"},
+		{"1.4.2-SNAPSHOT", release.Major, "2.0.0"},
+		{"1.4.2", release.Patch, "1.4.3"},
+		{"1.4", release.Patch, "1.4.1"},
+		{"3", release.Minor, "3.1.0"},
+	}
+	for _, c := range cases {
+		// when
+		got, err := release.NextVersion(c.current, c.part)
+
+		// then
+		assert.That(err == nil, t.Errorf, "%s %s: unexpected error: %s", c.current, c.part, err)
+		assert.That(got == c.want, t.Errorf, "%s %s: got %q, want %q", c.current, c.part, got, c.want)
+	}
+}
+
+func TestNextVersionCannotReleaseCurrentNonSnapshot(t *testing.T) {
+	// when
+	_, err := release.NextVersion("1.4.2", release.Current)
+
+	// then
+	assert.That(err != nil, t.Errorf, "missing error")
+}
+
+func TestNextVersionRejectsUnbumpableVersions(t *testing.T) {
+	for _, v := range []string{"1.2.3.4", "1.x.0", "1.2.3-beta"} {
+		// when
+		_, err := release.NextVersion(v, release.Patch)
+
+		// then
+		assert.That(oops.Cause(err) == release.ErrBadVersion, t.Errorf, "%s: got error %v, want %v", v, err, release.ErrBadVersion)
+	}
+}
+
+func TestParsePart(t *testing.T) {
+	for _, want := range []release.Part{release.Current, release.Patch, release.Minor, release.Major} {
+		// when
+		got, err := release.ParsePart(want.String())
+
+		// then
+		assert.That(err == nil, t.Errorf, "%s: unexpected error: %s", want, err)
+		assert.That(got == want, t.Errorf, "got %s, want %s", got, want)
+	}
+
+	_, err := release.ParsePart("huge")
+	assert.That(oops.Cause(err) == release.ErrUnknownPart, t.Errorf, "got error %v, want %v", err, release.ErrUnknownPart)
+}
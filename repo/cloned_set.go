@@ -8,6 +8,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/samsarahq/go/oops"
 )
@@ -96,6 +97,16 @@ func (set *ClonedSet) Clear() error {
 
 func (set *ClonedSet) Size() int { return len(set.repos) }
 
+// Locals lists the clones in the set, ordered by name.
+func (set *ClonedSet) Locals() []Local {
+	locals := make([]Local, 0, len(set.repos))
+	for _, l := range set.repos {
+		locals = append(locals, l)
+	}
+	sort.Slice(locals, func(i, j int) bool { return locals[i].Name < locals[j].Name })
+	return locals
+}
+
 func (set *ClonedSet) Each(f func(Local)) {
 	for _, l := range set.repos {
 		f(l)
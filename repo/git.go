@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package repo
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+// GitVersion reports the version of the git binary found in PATH.
+func GitVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "--version").Output()
+	if err != nil {
+		return "", oops.Wrapf(err, "cannot determine git version")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
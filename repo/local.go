@@ -6,7 +6,9 @@ package repo
 
 import (
 	"context"
+	"io"
 	"os/exec"
+	"runtime"
 
 	"github.com/samsarahq/go/oops"
 )
@@ -31,32 +33,88 @@ func (l Local) Checkout(ctx context.Context, ref string) error {
 	return oops.Wrapf(err, "in repository at %s, failed to checkout %s", l.Path, ref)
 }
 
-func (l Local) CheckoutFirst(ctx context.Context, ref string, otherRefs ...string) error {
+// CheckoutFirst checks out the first of the refs that exists and reports which one it was.
+func (l Local) CheckoutFirst(ctx context.Context, ref string, otherRefs ...string) (string, error) {
 	allRefs := append([]string{ref}, otherRefs...)
 	for _, ref := range allRefs {
 		err := l.Checkout(ctx, ref)
 		if err == nil {
-			return nil
+			return ref, nil
 		}
 	}
-	return oops.Errorf("in repository at %s, none of the refs %q could be checked out", l.Path, allRefs)
+	return "", oops.Errorf("in repository at %s, none of the refs %q could be checked out", l.Path, allRefs)
+}
+
+// CommitAll commits all the changes to tracked files.
+func (l Local) CommitAll(ctx context.Context, message string) error {
+	err := l.Run(ctx, "git", "commit", "--all", "--message", message)
+	return oops.Wrapf(err, "in repository at %s, failed to commit", l.Path)
+}
+
+// Tag creates an annotated tag pointing at the current commit.
+func (l Local) Tag(ctx context.Context, name, message string) error {
+	err := l.Run(ctx, "git", "tag", "--annotate", name, "--message", message)
+	return oops.Wrapf(err, "in repository at %s, failed to create tag %s", l.Path, name)
+}
+
+// Push pushes the current branch and the given tags to origin.
+func (l Local) Push(ctx context.Context, tags ...string) error {
+	args := []string{"push", "origin", "HEAD"}
+	for _, t := range tags {
+		args = append(args, "refs/tags/"+t)
+	}
+	err := l.Run(ctx, "git", args...)
+	return oops.Wrapf(err, "in repository at %s, failed to push", l.Path)
 }
 
 func (l Local) CurrentHash(ctx context.Context) (string, error) {
-	cmd := l.Command(ctx, "git", "show", "--format", "format:%H", "-s")
+	cmd := l.Command(ctx, "git", "show", "--format=format:%H", "-s")
+	cmd.Stderr = l.Out()
 	out, err := cmd.Output()
 	if err != nil {
-		l.Out().Write(out)
 		return "", oops.Wrapf(err, "cannot get current commit hash of repo at %s", l.Path)
 	}
 	return string(out), nil
 }
 
+// An Output says where the standard output and error of a command go.
+// Nil writers discard the corresponding stream.
+type Output struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// CombinedOutput sends both the standard output and error of a command to w.
+func CombinedOutput(w io.Writer) Output { return Output{Stdout: w, Stderr: w} }
+
+// Run runs a command in the repository, streaming its output to the repository's Out as it runs.
 func (l Local) Run(ctx context.Context, cmdName string, args ...string) error {
-	cmd := l.Command(ctx, cmdName, args...)
-	out, err := cmd.CombinedOutput()
-	l.Out().Write(out)
-	return err
+	return l.RunTo(ctx, CombinedOutput(l.Out()), cmdName, args...)
+}
+
+// RunTo runs a command in the repository, streaming its output to out as it runs.
+func (l Local) RunTo(ctx context.Context, out Output, cmdName string, args ...string) error {
+	cmd := l.CommandTo(ctx, out, cmdName, args...)
+	return cmd.Run()
+}
+
+// RunShell runs the commands in the repository with the shell of the platform, one after another, stopping at the
+// first one that fails. The combined output of all of them goes to logTo.
+//
+// That is sh everywhere but on Windows, where it is cmd. The default commands of the ecosystems are written for sh,
+// so on Windows only commands configured with cmd in mind can be run.
+func (l Local) RunShell(ctx context.Context, logTo io.Writer, commands ...string) error {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	for _, command := range commands {
+		err := l.RunTo(ctx, CombinedOutput(logTo), shell, flag, command)
+		if err != nil {
+			return oops.Wrapf(err, "in repository at %s, %q failed", l.Path, command)
+		}
+	}
+	return nil
 }
 
 func (l Local) Command(ctx context.Context, cmdName string, args ...string) *exec.Cmd {
@@ -64,3 +122,11 @@ func (l Local) Command(ctx context.Context, cmdName string, args ...string) *exe
 	cmd.Dir = l.Path
 	return cmd
 }
+
+// CommandTo prepares a command to be run in the repository with its output going to out.
+func (l Local) CommandTo(ctx context.Context, out Output, cmdName string, args ...string) *exec.Cmd {
+	cmd := l.Command(ctx, cmdName, args...)
+	cmd.Stdout = out.Stdout
+	cmd.Stderr = out.Stderr
+	return cmd
+}
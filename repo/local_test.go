@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package repo_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/prefixio"
+	"github.com/szabba/unibuild/repo"
+)
+
+func TestCurrentHash(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "repo")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	l := repo.Local{Remote: repo.Remote{Name: "test"}, Path: dir}
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "--quiet", "--allow-empty", "--message", "Empty"},
+	} {
+		err := l.Run(ctx, "git", args...)
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	}
+	out, err := l.Command(ctx, "git", "rev-parse", "HEAD").Output()
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := strings.TrimSpace(string(out))
+
+	// when
+	hash, err := l.CurrentHash(ctx)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(hash == want, t.Errorf, "got hash %q, want %q", hash, want)
+}
+
+func TestRunToSendsOutputToTheGivenWriters(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "repo")
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	defer os.RemoveAll(dir)
+
+	l := repo.Local{Remote: repo.Remote{Name: "test"}, Path: dir}
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+
+	// when
+	err = l.RunTo(context.Background(), repo.Output{Stdout: stdout, Stderr: stderr}, "sh", "-c", "pwd; echo oops >&2")
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(strings.TrimSpace(stdout.String()) != "", t.Errorf, "nothing written to stdout")
+	assert.That(stderr.String() == "oops\n", t.Errorf, "stderr: got %q, want %q", stderr.String(), "oops\n")
+}
+
+func TestRunToStreamsCombinedOutputInOrder(t *testing.T) {
+	// given
+	l := repo.Local{Remote: repo.Remote{Name: "test"}, Path: "."}
+	out := new(strings.Builder)
+
+	// when
+	err := l.RunTo(context.Background(), repo.CombinedOutput(out), "sh", "-c", "echo a; echo b >&2; echo c")
+
+	// then
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(out.String() == "a\nb\nc\n", t.Errorf, "out: got %q, want %q", out.String(), "a\nb\nc\n")
+}
+
+func TestRunToReportsFailure(t *testing.T) {
+	// given
+	l := repo.Local{Remote: repo.Remote{Name: "test"}, Path: "."}
+
+	// when
+	err := l.RunTo(context.Background(), repo.Output{}, "sh", "-c", "exit 3")
+
+	// then
+	assert.That(err != nil, t.Errorf, "got no error when one is expected")
+}
+
+func TestRunShellStopsAtTheFirstFailingCommand(t *testing.T) {
+	// given
+	l := repo.Local{Remote: repo.Remote{Name: "test"}, Path: "."}
+	out := new(strings.Builder)
+
+	// when
+	err := l.RunShell(context.Background(), out, "echo a && echo b >&2", "exit 3", "echo c")
+
+	// then
+	assert.That(err != nil, t.Fatalf, "got no error when one is expected")
+	assert.That(strings.Contains(err.Error(), `"exit 3" failed`), t.Errorf, "the error does not name the failing command: %s", err)
+	assert.That(out.String() == "a\nb\n", t.Errorf, "out: got %q, want %q", out.String(), "a\nb\n")
+}
+
+func TestRepositoriesWriteToTheOutputOfTheirSet(t *testing.T) {
+	// given
+	out := new(strings.Builder)
+	mux := prefixio.NewMux(out)
+	set := repo.NewSet()
+	err := set.Add(repo.Remote{Name: "lib"})
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+	// when
+	set.WithOutput(mux).Each(func(r repo.Remote) {
+		r.Log().Printf("hello")
+	})
+
+	// then
+	err = mux.Flush()
+	assert.That(err == nil, t.Errorf, "unexpected error: %s", err)
+	assert.That(strings.Contains(out.String(), "hello"), t.Errorf, "got output %q, want the logged line", out.String())
+}
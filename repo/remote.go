@@ -18,11 +18,17 @@ import (
 type Remote struct {
 	Name string
 	URL  string
-	out  io.Writer
-	log  *log.Logger
+	// Output the repository writes its lines to, as a source named after it.
+	// When nil, the lines go straight to the standard output.
+	Output *prefixio.Mux
+	out    io.Writer
+	log    *log.Logger
 }
 
 func (r Remote) Out() io.Writer {
+	if r.out == nil && r.Output != nil {
+		r.out = r.Output.Source(r.Name)
+	}
 	if r.out == nil {
 		r.out = prefixio.NewWriter(os.Stdout, r.Name+" | ")
 	}
@@ -39,8 +45,9 @@ func (r Remote) Log() *log.Logger {
 func (r Remote) Clone(ctx context.Context, dir string) (Local, error) {
 	cmd := r.Command(ctx, "git", "clone", r.URL)
 	cmd.Dir = dir
-	out, err := cmd.CombinedOutput()
-	r.Out().Write(out)
+	cmd.Stdout = r.Out()
+	cmd.Stderr = cmd.Stdout
+	err := cmd.Run()
 	if err != nil {
 		return Local{}, err
 	}
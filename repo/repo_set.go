@@ -6,6 +6,8 @@ package repo
 
 import (
 	"errors"
+
+	"github.com/szabba/unibuild/prefixio"
 )
 
 var ErrDuplicateName = errors.New("duplicate repository name")
@@ -36,8 +38,29 @@ func (set *Set) Copy() *Set {
 	return cp
 }
 
+// WithOutput returns a copy of the set in which all the repositories write to out.
+func (set *Set) WithOutput(out *prefixio.Mux) *Set {
+	cp := NewSet()
+	for _, ri := range set.repos {
+		ri.Output = out
+		cp.Add(ri)
+	}
+	return cp
+}
+
 func (set *Set) Size() int { return len(set.repos) }
 
+// LongestName returns the length of the longest repository name in the set.
+func (set *Set) LongestName() int {
+	longest := 0
+	for name := range set.repos {
+		if len(name) > longest {
+			longest = len(name)
+		}
+	}
+	return longest
+}
+
 func (set *Set) Each(f func(Remote)) {
 	for _, r := range set.repos {
 		f(r)
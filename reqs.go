@@ -4,31 +4,169 @@
 
 package unibuild
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
 
 var (
 	ErrWrongVersion  = errors.New("wrong version")
 	ErrCannotSatisfy = errors.New("cannot satisfy")
+	ErrBadIdentity   = errors.New("requirement identity is not of the form ecosystem:name")
+	ErrBadKind       = errors.New("requirement kind is not compile, test or optional")
 )
 
+// A RequirementIdentity names something a project can build or use.
+// Names are only meaningful within an ecosystem, which is shared by all the tools that can consume the same
+// artifacts (like maven and gradle).
 type RequirementIdentity struct {
-	// TODO: To handle multi-ecosystem builds.
-	// Ecosystem string
-	Name string
+	Ecosystem string
+	Name      string
+}
+
+// String formats the identity as the ecosystem and name separated by a colon.
+func (id RequirementIdentity) String() string {
+	if id.Ecosystem == "" {
+		return id.Name
+	}
+	return id.Ecosystem + ":" + id.Name
+}
+
+// ParseRequirementIdentity parses an identity in the format produced by RequirementIdentity.String.
+// The ecosystem is everything up to the first colon, so names may contain colons themselves.
+func ParseRequirementIdentity(s string) (RequirementIdentity, error) {
+	colon := strings.Index(s, ":")
+	if colon <= 0 || colon == len(s)-1 {
+		return RequirementIdentity{}, oops.Wrapf(ErrBadIdentity, "cannot parse %q", s)
+	}
+	return RequirementIdentity{Ecosystem: s[:colon], Name: s[colon+1:]}, nil
 }
 
 type Requirement interface {
 	ID() RequirementIdentity
-	// TODO: Handle versioning
-	// Accepts(v Version) bool
 }
 
+// A ConstrainedRequirement only accepts some versions of what it requires.
+type ConstrainedRequirement interface {
+	Requirement
+	Accepts(version string) bool
+}
+
+// A RequirementKind tells what a project needs a requirement for.
+// Kinds are ordered from the strongest to the weakest.
+type RequirementKind int
+
+const (
+	// Compile requirements are needed to build a project at all.
+	Compile RequirementKind = iota
+	// Optional requirements are not passed on to the users of a project, though it may still need them to build.
+	Optional
+	// Test requirements are only needed to run the tests of a project.
+	Test
+)
+
+func (kind RequirementKind) String() string {
+	switch kind {
+	case Compile:
+		return "compile"
+	case Test:
+		return "test"
+	case Optional:
+		return "optional"
+	default:
+		return fmt.Sprintf("RequirementKind(%d)", int(kind))
+	}
+}
+
+// ParseRequirementKind parses a kind in the format produced by RequirementKind.String.
+func ParseRequirementKind(s string) (RequirementKind, error) {
+	for _, kind := range []RequirementKind{Compile, Optional, Test} {
+		if s == kind.String() {
+			return kind, nil
+		}
+	}
+	return Compile, oops.Wrapf(ErrBadKind, "cannot parse %q", s)
+}
+
+// Stronger returns the stronger of the two kinds.
+func (kind RequirementKind) Stronger(other RequirementKind) RequirementKind {
+	if other < kind {
+		return other
+	}
+	return kind
+}
+
+// Weaker returns the weaker of the two kinds.
+func (kind RequirementKind) Weaker(other RequirementKind) RequirementKind {
+	if other > kind {
+		return other
+	}
+	return kind
+}
+
+// A KindedRequirement knows what kind of requirement it is.
+type KindedRequirement interface {
+	Requirement
+	Kind() RequirementKind
+}
+
+// KindOf returns the kind of a requirement.
+// Requirements that do not say what kind they are are assumed to be Compile ones.
+func KindOf(req Requirement) RequirementKind {
+	if kinded, ok := req.(KindedRequirement); ok {
+		return kinded.Kind()
+	}
+	return Compile
+}
+
+// A MergingRequirement knows how to combine with another requirement on the same thing.
+type MergingRequirement interface {
+	Requirement
+	Merge(other Requirement) Requirement
+}
+
+// MergeRequirements leaves a single requirement for each identity, in the order the identities first appear in.
+// Of the requirements on the same thing, the one of the strongest kind is kept, or the first one when there is no
+// stronger one.
+// Requirements that are MergingRequirements get merged instead.
+func MergeRequirements(reqs []Requirement) []Requirement {
+	index := make(map[RequirementIdentity]int, len(reqs))
+	merged := make([]Requirement, 0, len(reqs))
+	for _, req := range reqs {
+		i, seen := index[req.ID()]
+		if !seen {
+			index[req.ID()] = len(merged)
+			merged = append(merged, req)
+			continue
+		}
+		if mergeable, ok := merged[i].(MergingRequirement); ok {
+			merged[i] = mergeable.Merge(req)
+		} else if KindOf(req) < KindOf(merged[i]) {
+			merged[i] = req
+		}
+	}
+	return merged
+}
+
+// A RequirementVersion is something a project builds, in the version it builds it in.
 type RequirementVersion struct {
 	ID RequirementIdentity
-	// TODO: Handle versioning
-	// Version Version
+	// Version is left empty when it is not known.
+	Version string
 }
 
+// Satisfies tells whether reqver is what req requires.
+// Versions are only compared when both the version and a constraint on it are known.
 func Satisfies(reqver RequirementVersion, req Requirement) bool {
-	return reqver.ID == req.ID()
+	if reqver.ID != req.ID() {
+		return false
+	}
+	constrained, ok := req.(ConstrainedRequirement)
+	if !ok || reqver.Version == "" {
+		return true
+	}
+	return constrained.Accepts(reqver.Version)
 }
@@ -5,15 +5,132 @@
 package unibuild_test
 
 import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
 	"github.com/szabba/unibuild"
 )
 
 type Requirement struct {
-	ID_ unibuild.RequirementIdentity
+	ID_   unibuild.RequirementIdentity
+	Kind_ unibuild.RequirementKind
 }
 
-var _ unibuild.Requirement = Requirement{}
+var _ unibuild.KindedRequirement = Requirement{}
 
 func (req Requirement) ID() unibuild.RequirementIdentity {
 	return req.ID_
 }
+
+func (req Requirement) Kind() unibuild.RequirementKind {
+	return req.Kind_
+}
+
+func TestRequirementIdentityRoundTrip(t *testing.T) {
+	// given
+	id := unibuild.RequirementIdentity{Ecosystem: "maven", Name: "com.acme:billing"}
+
+	// when
+	parsed, err := unibuild.ParseRequirementIdentity(id.String())
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(id.String() == "maven:com.acme:billing", t.Errorf, "got %q", id.String())
+	assert.That(parsed == id, t.Errorf, "got %#v, want %#v", parsed, id)
+}
+
+func TestParseRequirementIdentityNeedsAnEcosystemAndAName(t *testing.T) {
+	for _, s := range []string{"", "billing", ":billing", "maven:"} {
+		// when
+		_, err := unibuild.ParseRequirementIdentity(s)
+
+		// then
+		assert.That(oops.Cause(err) == unibuild.ErrBadIdentity, t.Errorf, "%q: got error %v, want %v", s, err, unibuild.ErrBadIdentity)
+	}
+}
+
+func TestRequirementsOnlyMatchWithinAnEcosystem(t *testing.T) {
+	// given
+	built := unibuild.RequirementVersion{ID: unibuild.RequirementIdentity{Ecosystem: "npm", Name: "core"}}
+	req := Requirement{ID_: unibuild.RequirementIdentity{Ecosystem: "cargo", Name: "core"}}
+
+	// when
+	ok := unibuild.Satisfies(built, req)
+
+	// then
+	assert.That(!ok, t.Errorf, "requirement from another ecosystem satisfied")
+}
+
+// A VersionedRequirement accepts only the listed versions.
+type VersionedRequirement struct {
+	Requirement
+	Versions []string
+}
+
+var _ unibuild.ConstrainedRequirement = VersionedRequirement{}
+
+func (req VersionedRequirement) Accepts(version string) bool {
+	for _, v := range req.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConstrainedRequirementsCheckTheVersion(t *testing.T) {
+	// given
+	id := unibuild.RequirementIdentity{Ecosystem: "npm", Name: "core"}
+	req := VersionedRequirement{Requirement: Requirement{ID_: id}, Versions: []string{"1.0.0"}}
+
+	for version, want := range map[string]bool{"1.0.0": true, "2.0.0": false, "": true} {
+		// when
+		ok := unibuild.Satisfies(unibuild.RequirementVersion{ID: id, Version: version}, req)
+
+		// then
+		assert.That(ok == want, t.Errorf, "version %q: got %v, want %v", version, ok, want)
+	}
+}
+
+func TestMergeRequirementsKeepsTheStrongestKind(t *testing.T) {
+	// given
+	lib := unibuild.RequirementIdentity{Name: "lib"}
+	tool := unibuild.RequirementIdentity{Name: "tool"}
+	reqs := []unibuild.Requirement{
+		Requirement{ID_: lib, Kind_: unibuild.Optional},
+		Requirement{ID_: tool, Kind_: unibuild.Test},
+		Requirement{ID_: lib, Kind_: unibuild.Compile},
+		Requirement{ID_: tool, Kind_: unibuild.Optional},
+	}
+
+	// when
+	merged := unibuild.MergeRequirements(reqs)
+
+	// then
+	want := []unibuild.Requirement{
+		Requirement{ID_: lib, Kind_: unibuild.Compile},
+		Requirement{ID_: tool, Kind_: unibuild.Optional},
+	}
+	assert.That(len(merged) == len(want), t.Fatalf, "got %v, want %v", merged, want)
+	for i := range want {
+		assert.That(merged[i] == want[i], t.Errorf, "requirement #%d: got %v, want %v", i, merged[i], want[i])
+	}
+}
+
+func TestMergingAnOptionalUseWithATestUseKeepsItOptional(t *testing.T) {
+	// given
+	lib := unibuild.RequirementIdentity{Name: "lib"}
+	reqs := []unibuild.Requirement{
+		Requirement{ID_: lib, Kind_: unibuild.Test},
+		Requirement{ID_: lib, Kind_: unibuild.Optional},
+	}
+
+	// when
+	merged := unibuild.MergeRequirements(reqs)
+
+	// then
+	want := Requirement{ID_: lib, Kind_: unibuild.Optional}
+	assert.That(len(merged) == 1 && merged[0] == want, t.Errorf, "got %v, want %v", merged, want)
+}
@@ -0,0 +1,24 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package unibuild
+
+// Rules declare the edges between projects that no ecosystem can see, like an API spec built by one project and
+// consumed by another one, or a jar copied into a docker image.
+// The edges they make are manual.
+type Rules struct {
+	Satisfies []SatisfiesRule
+	DependsOn []DependsOnRule
+}
+
+// A SatisfiesRule says that the project building one thing also provides another, which some project requires.
+type SatisfiesRule struct {
+	Built, Required RequirementIdentity
+}
+
+// A DependsOnRule says that one project depends on another outright.
+type DependsOnRule struct {
+	Project, On string
+	Kind        RequirementKind
+}
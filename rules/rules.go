@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package rules reads the files declaring the edges between projects that no ecosystem can see.
+//
+// A rule can say that whatever project builds one thing also provides another, or that one project depends on
+// another outright:
+//
+//	[[satisfies]]
+//	built = "maven:com.acme:api"
+//	required = "npm:@acme/api-client-source"
+//
+//	[[depends]]
+//	project = "orders-image"
+//	on = "orders"
+//	kind = "compile"
+//
+// The kind of a depends rule is compile when not given.
+package rules
+
+import (
+	"errors"
+	"io/ioutil"
+
+	"github.com/samsarahq/go/oops"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/toml"
+)
+
+var ErrBadRule = errors.New("malformed rule")
+
+type file struct {
+	Satisfies []struct {
+		Built    string `json:"built"`
+		Required string `json:"required"`
+	} `json:"satisfies"`
+	Depends []struct {
+		Project string `json:"project"`
+		On      string `json:"on"`
+		Kind    string `json:"kind"`
+	} `json:"depends"`
+}
+
+// Read reads the rules in the file at path.
+func Read(path string) (unibuild.Rules, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return unibuild.Rules{}, oops.Wrapf(err, "cannot read rules from %s", path)
+	}
+	rules, err := Parse(raw)
+	return rules, oops.Wrapf(err, "in %s", path)
+}
+
+// Parse parses rules out of a TOML document.
+func Parse(raw []byte) (unibuild.Rules, error) {
+	var f file
+	if err := toml.Unmarshal(raw, &f); err != nil {
+		return unibuild.Rules{}, err
+	}
+
+	var rules unibuild.Rules
+	for i, s := range f.Satisfies {
+		built, err := unibuild.ParseRequirementIdentity(s.Built)
+		if err != nil {
+			return unibuild.Rules{}, oops.Wrapf(err, "bad built in satisfies rule #%d", i+1)
+		}
+		required, err := unibuild.ParseRequirementIdentity(s.Required)
+		if err != nil {
+			return unibuild.Rules{}, oops.Wrapf(err, "bad required in satisfies rule #%d", i+1)
+		}
+		rules.Satisfies = append(rules.Satisfies, unibuild.SatisfiesRule{Built: built, Required: required})
+	}
+
+	for i, d := range f.Depends {
+		if d.Project == "" || d.On == "" {
+			return unibuild.Rules{}, oops.Wrapf(ErrBadRule, "depends rule #%d needs both a project and what it is on", i+1)
+		}
+		kind := unibuild.Compile
+		if d.Kind != "" {
+			var err error
+			kind, err = unibuild.ParseRequirementKind(d.Kind)
+			if err != nil {
+				return unibuild.Rules{}, oops.Wrapf(err, "bad kind in depends rule #%d", i+1)
+			}
+		}
+		rules.DependsOn = append(rules.DependsOn, unibuild.DependsOnRule{Project: d.Project, On: d.On, Kind: kind})
+	}
+	return rules, nil
+}
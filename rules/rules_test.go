@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package rules_test
+
+import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+	"github.com/szabba/unibuild/rules"
+)
+
+func TestRulesAreParsed(t *testing.T) {
+	// given
+	src := `
+# the client generator reads the spec the api build attaches
+[[satisfies]]
+built = "maven:com.acme:api"
+required = "npm:@acme/api-client-source"
+
+[[depends]]
+project = "orders-image"
+on = "orders"
+
+[[depends]]
+project = "docs"
+on = "api"
+kind = "optional"
+`
+
+	// when
+	got, err := rules.Parse([]byte(src))
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	wantSatisfies := unibuild.SatisfiesRule{
+		Built:    unibuild.RequirementIdentity{Ecosystem: "maven", Name: "com.acme:api"},
+		Required: unibuild.RequirementIdentity{Ecosystem: "npm", Name: "@acme/api-client-source"},
+	}
+	assert.That(len(got.Satisfies) == 1 && got.Satisfies[0] == wantSatisfies, t.Errorf, "got satisfies rules %v, want %v", got.Satisfies, wantSatisfies)
+
+	wantDependsOn := []unibuild.DependsOnRule{
+		{Project: "orders-image", On: "orders", Kind: unibuild.Compile},
+		{Project: "docs", On: "api", Kind: unibuild.Optional},
+	}
+	assert.That(len(got.DependsOn) == len(wantDependsOn), t.Fatalf, "got depends rules %v, want %v", got.DependsOn, wantDependsOn)
+	for i := range wantDependsOn {
+		assert.That(got.DependsOn[i] == wantDependsOn[i], t.Errorf, "depends rule #%d: got %v, want %v", i, got.DependsOn[i], wantDependsOn[i])
+	}
+}
+
+func TestMalformedRulesAreRejected(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		cause     error
+	}{
+		{"bad identity", "[[satisfies]]\nbuilt = \"api\"\nrequired = \"npm:client\"\n", unibuild.ErrBadIdentity},
+		{"bad kind", "[[depends]]\nproject = \"a\"\non = \"b\"\nkind = \"runtime\"\n", unibuild.ErrBadKind},
+		{"missing project", "[[depends]]\non = \"b\"\n", rules.ErrBadRule},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			_, err := rules.Parse([]byte(tt.src))
+
+			// then
+			assert.That(oops.Cause(err) == tt.cause, t.Errorf, "got error %v, want %v", err, tt.cause)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package unibuild_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild"
+)
+
+var (
+	_APIID    = unibuild.RequirementIdentity{Ecosystem: "maven", Name: "com.acme:api"}
+	_SourceID = unibuild.RequirementIdentity{Ecosystem: "npm", Name: "@acme/api-client-source"}
+)
+
+func TestSatisfiesRuleMakesTheBuilderAProvider(t *testing.T) {
+	// given
+	var client unibuild.Project = &Project{
+		Info_: unibuild.ProjectInfo{Name: "api-client"},
+		Uses_: []unibuild.Requirement{Requirement{ID_: _SourceID}},
+	}
+	var api unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "api"},
+		Builds_: []unibuild.RequirementVersion{{ID: _APIID, Version: "1.0.0"}},
+	}
+	suite := unibuild.NewProjectSuite(client, api)
+	suite.AddRules(unibuild.Rules{Satisfies: []unibuild.SatisfiesRule{{Built: _APIID, Required: _SourceID}}})
+
+	// when
+	ordSuite, err := suite.ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error reported: %s", err)
+	order := ordSuite.Order()
+	assert.That(len(order) == 2 && order[0] == api, t.Errorf, "got order %v, want api first", order)
+
+	edges := ordSuite.Edges()
+	want := unibuild.Edge{From: client, To: api, Kind: unibuild.Compile, Manual: true}
+	assert.That(len(edges) == 1 && edges[0] == want, t.Errorf, "got edges %#v, want %#v", edges, want)
+}
+
+func TestDependsOnRuleAddsAnEdge(t *testing.T) {
+	// given
+	var image unibuild.Project = &Project{Info_: unibuild.ProjectInfo{Name: "orders-image"}}
+	var service unibuild.Project = &Project{Info_: unibuild.ProjectInfo{Name: "orders"}}
+	suite := unibuild.NewProjectSuite(image, service)
+	suite.AddRules(unibuild.Rules{DependsOn: []unibuild.DependsOnRule{
+		{Project: "orders-image", On: "orders", Kind: unibuild.Compile},
+		{Project: "orders-image", On: "no-such-project", Kind: unibuild.Compile},
+	}})
+
+	// when
+	ordSuite, err := suite.ResolveOrder()
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error reported: %s", err)
+	order := ordSuite.Order()
+	assert.That(len(order) == 2 && order[0] == service, t.Errorf, "got order %v, want orders first", order)
+	edges := ordSuite.Edges()
+	assert.That(len(edges) == 1 && edges[0].Manual, t.Errorf, "got edges %#v, want a single manual one", edges)
+}
+
+func TestRulesCannotContradictWhatProjectsBuild(t *testing.T) {
+	// given
+	var api unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "api"},
+		Builds_: []unibuild.RequirementVersion{{ID: _APIID}},
+	}
+	var source unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "api-source"},
+		Builds_: []unibuild.RequirementVersion{{ID: _SourceID}},
+	}
+	suite := unibuild.NewProjectSuite(api, source)
+	suite.AddRules(unibuild.Rules{Satisfies: []unibuild.SatisfiesRule{{Built: _APIID, Required: _SourceID}}})
+
+	// when
+	_, err := suite.ResolveOrder()
+
+	// then
+	assert.That(err != nil, t.Errorf, "got no error when one is expected")
+}
+
+func TestGraphLabelsManualEdges(t *testing.T) {
+	// given
+	libID := unibuild.RequirementIdentity{Name: "lib"}
+	var lib unibuild.Project = &Project{
+		Info_:   unibuild.ProjectInfo{Name: "lib"},
+		Builds_: []unibuild.RequirementVersion{{ID: libID}, {ID: _APIID}},
+	}
+	var app unibuild.Project = &Project{
+		Info_: unibuild.ProjectInfo{Name: "app"},
+		Uses_: []unibuild.Requirement{
+			Requirement{ID_: libID},
+			Requirement{ID_: _SourceID, Kind_: unibuild.Test},
+		},
+	}
+	suite := unibuild.NewProjectSuite(app, lib)
+	suite.Soften(unibuild.Test)
+	suite.AddRules(unibuild.Rules{Satisfies: []unibuild.SatisfiesRule{{Built: _APIID, Required: _SourceID}}})
+	ordSuite, err := suite.ResolveOrder()
+	assert.That(err == nil, t.Fatalf, "unexpected error reported: %s", err)
+
+	// when
+	var buf bytes.Buffer
+	err = ordSuite.WriteDOT(&buf)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error reported: %s", err)
+	want := strings.Join([]string{
+		`digraph unibuild {`,
+		`	"lib";`,
+		`	"app";`,
+		`	"app" -> "lib" [label="compile", style=solid];`,
+		`	"app" -> "lib" [label="manual test", style=dashed];`,
+		`}`,
+		``,
+	}, "\n")
+	assert.That(buf.String() == want, t.Errorf, "got graph\n%s\nwant\n%s", buf.String(), want)
+}
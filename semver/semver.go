@@ -0,0 +1,358 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package semver compares semantic versions and tells whether they fall into the version ranges npm understands.
+package semver
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+var (
+	ErrBadVersion = errors.New("not a semantic version")
+	ErrBadRange   = errors.New("not a version range")
+)
+
+// A Version is a semantic version, as used by npm.
+// Build metadata is dropped, as it does not take part in comparisons.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          []string
+}
+
+// ParseVersion parses a full semantic version, allowing for a leading v or =.
+func ParseVersion(s string) (Version, error) {
+	p, err := parsePartial(strings.TrimSpace(s))
+	if err != nil || p.minor < 0 || p.patch < 0 {
+		return Version{}, oops.Wrapf(ErrBadVersion, "cannot parse %q", s)
+	}
+	return p.version(), nil
+}
+
+func (v Version) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater than other.
+func (v Version) Compare(other Version) int {
+	for _, d := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c := compareInts(d[0], d[1]); c != 0 {
+			return c
+		}
+	}
+	// A prerelease comes before the release itself.
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifiers(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.Prerelease), len(other.Prerelease))
+}
+
+func (v Version) sameRelease(other Version) bool {
+	return v.Major == other.Major && v.Minor == other.Minor && v.Patch == other.Patch
+}
+
+// compareIdentifiers orders numeric prerelease identifiers numerically and before the alphanumeric ones.
+func compareIdentifiers(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// A Range of versions, in the syntax of node-semver.
+// It is a union of comparator sets, each of which is an intersection.
+type Range struct {
+	sets [][]comparator
+}
+
+type comparator struct {
+	op string
+	v  Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// ParseRange parses a range like ^1.2.3, ~1.2, 1.x, >=1.0.0 <2.0.0, 1.0.0 - 1.4 or several joined with ||.
+func ParseRange(s string) (Range, error) {
+	var rng Range
+	for _, alt := range strings.Split(s, "||") {
+		set, err := parseComparatorSet(strings.TrimSpace(alt))
+		if err != nil {
+			return Range{}, oops.Wrapf(err, "cannot parse range %q", s)
+		}
+		rng.sets = append(rng.sets, set)
+	}
+	return rng, nil
+}
+
+// Contains tells whether the version is in the range.
+// Prereleases are only in a range that mentions a prerelease of the same major, minor and patch version.
+func (rng Range) Contains(v Version) bool {
+	for _, set := range rng.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setContains(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if len(v.Prerelease) == 0 {
+		return true
+	}
+	for _, c := range set {
+		if len(c.v.Prerelease) > 0 && c.v.sameRelease(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseComparatorSet(s string) ([]comparator, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 3 && fields[1] == "-" {
+		return parseHyphenRange(fields[0], fields[2])
+	}
+
+	var set []comparator
+	for i := 0; i < len(fields); i++ {
+		term := fields[i]
+		// Operators may be separated from their versions, as in >= 1.2.3.
+		if strings.Trim(term, "<>=~^") == "" && i+1 < len(fields) {
+			i++
+			term += fields[i]
+		}
+		cs, err := parseTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cs...)
+	}
+	if len(set) == 0 {
+		return anyVersion(), nil
+	}
+	return set, nil
+}
+
+func anyVersion() []comparator { return []comparator{{">=", Version{}}} }
+
+func parseHyphenRange(from, to string) ([]comparator, error) {
+	lo, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+	set := []comparator{{">=", lo.version()}}
+	if hi.major < 0 {
+		return set, nil
+	}
+	if hi.patch < 0 {
+		return append(set, comparator{"<", hi.next()}), nil
+	}
+	return append(set, comparator{"<=", hi.version()}), nil
+}
+
+// parseTerm turns a single term of a comparator set into the plain comparators it stands for.
+func parseTerm(term string) ([]comparator, error) {
+	op := term[:len(term)-len(strings.TrimLeft(term, "<>=~^"))]
+	p, err := parsePartial(term[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	lo := p.version()
+
+	switch op {
+	case "^":
+		return caretRange(p), nil
+	case "~", "~>":
+		if p.major < 0 {
+			return anyVersion(), nil
+		}
+		hi := partial{major: p.major, minor: p.minor, patch: -1}
+		return []comparator{{">=", lo}, {"<", hi.next()}}, nil
+	case "", "=":
+		if p.major < 0 {
+			return anyVersion(), nil
+		}
+		if p.patch < 0 {
+			return []comparator{{">=", lo}, {"<", p.next()}}, nil
+		}
+		return []comparator{{"=", lo}}, nil
+	case ">", ">=", "<", "<=":
+		return inequality(op, p), nil
+	default:
+		return nil, oops.Wrapf(ErrBadRange, "unknown operator %q", op)
+	}
+}
+
+// caretRange allows changes that do not touch the leftmost non-zero part of the version.
+func caretRange(p partial) []comparator {
+	lo := p.version()
+	var hi Version
+	switch {
+	case p.major < 0:
+		return anyVersion()
+	case p.major > 0 || p.minor < 0:
+		hi = Version{Major: p.major + 1}
+	case p.minor > 0 || p.patch < 0:
+		hi = Version{Minor: p.minor + 1}
+	default:
+		hi = Version{Patch: p.patch + 1}
+	}
+	hi.Prerelease = []string{"0"}
+	return []comparator{{">=", lo}, {"<", hi}}
+}
+
+func inequality(op string, p partial) []comparator {
+	if p.major < 0 {
+		if op == ">" || op == "<" {
+			// Nothing is above or below every version.
+			return []comparator{{"<", Version{Prerelease: []string{"0"}}}}
+		}
+		return anyVersion()
+	}
+	if p.patch >= 0 {
+		return []comparator{{op, p.version()}}
+	}
+	switch op {
+	case ">":
+		v := p.next()
+		v.Prerelease = nil
+		return []comparator{{">=", v}}
+	case "<=":
+		return []comparator{{"<", p.next()}}
+	case "<":
+		v := p.version()
+		v.Prerelease = []string{"0"}
+		return []comparator{{"<", v}}
+	default:
+		return []comparator{{">=", p.version()}}
+	}
+}
+
+// A partial version may leave out trailing parts or replace them with wildcards, which are stored as -1.
+type partial struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+func parsePartial(s string) (partial, error) {
+	s = strings.TrimLeft(strings.TrimPrefix(strings.TrimSpace(s), "v"), "=v")
+	if plus := strings.Index(s, "+"); plus >= 0 {
+		s = s[:plus]
+	}
+	p := partial{major: -1, minor: -1, patch: -1}
+	if dash := strings.Index(s, "-"); dash >= 0 {
+		p.prerelease = strings.Split(s[dash+1:], ".")
+		s = s[:dash]
+		for _, id := range p.prerelease {
+			if id == "" {
+				return partial{}, oops.Wrapf(ErrBadVersion, "empty prerelease identifier in %q", s)
+			}
+		}
+	}
+	if s == "" {
+		return p, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return partial{}, oops.Wrapf(ErrBadVersion, "too many parts in %q", s)
+	}
+	nums := []*int{&p.major, &p.minor, &p.patch}
+	wild := false
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			wild = true
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if wild || err != nil || n < 0 {
+			return partial{}, oops.Wrapf(ErrBadVersion, "bad part %q in %q", part, s)
+		}
+		*nums[i] = n
+	}
+	return p, nil
+}
+
+// version fills in the missing parts with zeroes.
+func (p partial) version() Version {
+	v := Version{Major: p.major, Minor: p.minor, Patch: p.patch, Prerelease: p.prerelease}
+	for _, n := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		if *n < 0 {
+			*n = 0
+		}
+	}
+	return v
+}
+
+// next is the lowest version above everything the partial version matches.
+func (p partial) next() Version {
+	v := Version{Major: p.major, Prerelease: []string{"0"}}
+	if p.minor < 0 {
+		v.Major++
+	} else {
+		v.Minor = p.minor + 1
+	}
+	return v
+}
@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/semver"
+)
+
+func TestRangeContains(t *testing.T) {
+	for _, tt := range []struct {
+		rng, version string
+		want         bool
+	}{
+		{"^1.2.3", "1.2.3", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "1.2.2", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1", "1.9.9", true},
+		{"1.x", "1.4.0", true},
+		{"1.2.*", "1.3.0", false},
+		{"*", "3.1.4", true},
+		{"", "3.1.4", true},
+		{"1.2.3", "1.2.3", true},
+		{"=1.2.3", "1.2.4", false},
+		{">=1.0.0 <2.0.0", "1.5.0", true},
+		{">= 1.0.0 < 2.0.0", "2.0.0", false},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<1.2", "1.1.9", true},
+		{"1.0.0 - 1.4", "1.4.7", true},
+		{"1.0.0 - 1.4.2", "1.4.3", false},
+		{"^1.0.0 || ^3.0.0", "3.2.0", true},
+		{"^1.0.0 || ^3.0.0", "2.2.0", false},
+		{"^1.2.3", "1.3.0-beta.1", false},
+		{"^1.3.0-beta.1", "1.3.0-beta.2", true},
+		{"^1.3.0-beta.1", "1.3.0-alpha.9", false},
+		{"^1.3.0-beta.1", "1.3.0", true},
+		{"v1.2.3", "1.2.3", true},
+	} {
+		// given
+		rng, err := semver.ParseRange(tt.rng)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.rng, err)
+		v, err := semver.ParseVersion(tt.version)
+		assert.That(err == nil, t.Fatalf, "%q: unexpected error: %s", tt.version, err)
+
+		// when
+		got := rng.Contains(v)
+
+		// then
+		assert.That(got == tt.want, t.Errorf, "%q contains %q: got %v, want %v", tt.rng, tt.version, got, tt.want)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0", "1.0.1", "1.10.0"}
+	for i := 1; i < len(ordered); i++ {
+		// given
+		lo, err := semver.ParseVersion(ordered[i-1])
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+		hi, err := semver.ParseVersion(ordered[i])
+		assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+
+		// when
+		cmp := lo.Compare(hi)
+
+		// then
+		assert.That(cmp < 0, t.Errorf, "%s compared to %s: got %d, want -1", lo, hi, cmp)
+	}
+}
+
+func TestParseVersionNeedsAllParts(t *testing.T) {
+	for _, s := range []string{"", "1", "1.2", "1.x.0", "a.b.c", "1.2.3.4"} {
+		// when
+		_, err := semver.ParseVersion(s)
+
+		// then
+		assert.That(oops.Cause(err) == semver.ErrBadVersion, t.Errorf, "%q: got error %v, want %v", s, err, semver.ErrBadVersion)
+	}
+}
@@ -0,0 +1,449 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package toml reads the parts of TOML that manifests commonly use.
+//
+// Documents are parsed into the same kind of values encoding/json produces, so they can be decoded into structs
+// using json tags. Dates and times are kept as strings.
+package toml
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/samsarahq/go/oops"
+)
+
+var ErrSyntax = errors.New("toml syntax error")
+
+// Unmarshal parses a document and stores it in the value pointed to by v, as encoding/json would.
+func Unmarshal(data []byte, v interface{}) error {
+	doc, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return oops.Wrapf(err, "cannot convert the document")
+	}
+	return oops.Wrapf(json.Unmarshal(raw, v), "cannot decode the document")
+}
+
+// Parse parses a document into a map.
+// Values are strings, int64s, float64s, bools, []interface{}s and map[string]interface{}s.
+func Parse(src string) (map[string]interface{}, error) {
+	p := &parser{src: src, line: 1, tables: map[uintptr]tableKind{}}
+	doc, err := p.document()
+	if err != nil {
+		return nil, oops.Wrapf(ErrSyntax, "line %d: %s", p.line, err)
+	}
+	return doc, nil
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+	// tables says how the tables of the document came to be, so that none gets defined twice.
+	tables map[uintptr]tableKind
+}
+
+// A tableKind says how a table got defined.
+type tableKind int
+
+const (
+	// implicit tables only exist because a header names a table inside them.
+	implicit tableKind = iota
+	// defined tables have a header or get created by a dotted key.
+	defined
+	// inline tables are complete as written.
+	inline
+)
+
+func (p *parser) kindOf(table map[string]interface{}) tableKind {
+	return p.tables[reflect.ValueOf(table).Pointer()]
+}
+
+func (p *parser) mark(table map[string]interface{}, kind tableKind) {
+	p.tables[reflect.ValueOf(table).Pointer()] = kind
+}
+
+func (p *parser) document() (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	current := root
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return root, nil
+		}
+
+		var err error
+		if p.peek() == '[' {
+			current, err = p.header(root)
+		} else {
+			err = p.keyValue(current)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := p.lineEnd(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// header reads a table header and returns the table it opens.
+func (p *parser) header(root map[string]interface{}) (map[string]interface{}, error) {
+	p.pos++
+	array := p.consume("[")
+	p.skipBlank(false)
+	path, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank(false)
+	if !p.consume("]") || array && !p.consume("]") {
+		return nil, errors.New("unterminated table header")
+	}
+
+	parent, err := p.descend(root, path[:len(path)-1], implicit)
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	existing, present := parent[last]
+	table := map[string]interface{}{}
+	p.mark(table, defined)
+	if array {
+		tables, ok := existing.([]interface{})
+		if present && !ok {
+			return nil, errors.New("key " + strings.Join(path, ".") + " is not an array of tables")
+		}
+		parent[last] = append(tables, table)
+		return table, nil
+	}
+	switch existing := existing.(type) {
+	case nil:
+		parent[last] = table
+		return table, nil
+	case map[string]interface{}:
+		if p.kindOf(existing) != implicit {
+			return nil, errors.New("table " + strings.Join(path, ".") + " defined twice")
+		}
+		p.mark(existing, defined)
+		return existing, nil
+	default:
+		return nil, errors.New("key " + strings.Join(path, ".") + " is not a table")
+	}
+}
+
+// descend finds the table at path, creating the missing ones as the given kind.
+// The last table of an array of tables stands for the array. Inline tables cannot be added to.
+func (p *parser) descend(table map[string]interface{}, path []string, create tableKind) (map[string]interface{}, error) {
+	for _, k := range path {
+		switch next := table[k].(type) {
+		case nil:
+			created := map[string]interface{}{}
+			p.mark(created, create)
+			table[k] = created
+			table = created
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+			if len(next) == 0 {
+				return nil, errors.New("key " + k + " is not a table")
+			}
+			last, ok := next[len(next)-1].(map[string]interface{})
+			if !ok {
+				return nil, errors.New("key " + k + " is not a table")
+			}
+			table = last
+		default:
+			return nil, errors.New("key " + k + " is not a table")
+		}
+		if p.kindOf(table) == inline {
+			return nil, errors.New("inline table " + k + " cannot be extended")
+		}
+	}
+	return table, nil
+}
+
+func (p *parser) keyValue(table map[string]interface{}) error {
+	path, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipBlank(false)
+	if !p.consume("=") {
+		return errors.New("expected = after key " + strings.Join(path, "."))
+	}
+	p.skipBlank(false)
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.descend(table, path[:len(path)-1], defined)
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	if _, present := parent[last]; present {
+		return errors.New("key " + strings.Join(path, ".") + " defined twice")
+	}
+	parent[last] = v
+	return nil
+}
+
+// key reads a possibly dotted key.
+func (p *parser) key() ([]string, error) {
+	var path []string
+	for {
+		p.skipBlank(false)
+		var part string
+		switch {
+		case p.eof():
+			return nil, errors.New("expected a key")
+		case p.peek() == '"' || p.peek() == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyByte(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, errors.New("expected a key")
+			}
+			part = p.src[start:p.pos]
+		}
+		path = append(path, part)
+
+		p.skipBlank(false)
+		if !p.consume(".") {
+			return path, nil
+		}
+	}
+}
+
+func isBareKeyByte(c byte) bool {
+	return c == '_' || c == '-' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func (p *parser) value() (interface{}, error) {
+	if p.eof() {
+		return nil, errors.New("expected a value")
+	}
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += len("true")
+		return true, nil
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += len("false")
+		return false, nil
+	default:
+		return p.scalar()
+	}
+}
+
+// scalar reads a number, or a date or time, which is kept as it is written.
+func (p *parser) scalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	// Dates and times may have a space between them.
+	if p.pos-start == 10 && strings.Count(p.src[start:p.pos], "-") == 2 && p.pos+1 < len(p.src) &&
+		p.src[p.pos] == ' ' && '0' <= p.src[p.pos+1] && p.src[p.pos+1] <= '9' {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+	}
+	text := p.src[start:p.pos]
+	plain := strings.Replace(text, "_", "", -1)
+	if n, err := strconv.ParseInt(plain, 0, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(plain, 64); err == nil {
+		return f, nil
+	}
+	if len(text) > 0 && '0' <= text[0] && text[0] <= '9' && strings.ContainsAny(text, "-:") {
+		return text, nil
+	}
+	return nil, errors.New("unexpected value " + strconv.Quote(text))
+}
+
+func (p *parser) array() ([]interface{}, error) {
+	p.pos++
+	arr := []interface{}{}
+	for {
+		p.skipBlank(true)
+		if p.consume("]") {
+			return arr, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		p.skipBlank(true)
+		if p.consume("]") {
+			return arr, nil
+		}
+		if !p.consume(",") {
+			return nil, errors.New("expected , or ] in array")
+		}
+	}
+}
+
+func (p *parser) inlineTable() (map[string]interface{}, error) {
+	p.pos++
+	table := map[string]interface{}{}
+	p.skipBlank(false)
+	if p.consume("}") {
+		p.mark(table, inline)
+		return table, nil
+	}
+	for {
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipBlank(false)
+		if p.consume("}") {
+			p.mark(table, inline)
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, errors.New("expected , or } in inline table")
+		}
+		p.skipBlank(false)
+	}
+}
+
+// str reads a basic or literal string, either of which may be multi-line.
+func (p *parser) str() (string, error) {
+	quote := p.src[p.pos : p.pos+1]
+	multi := strings.HasPrefix(p.src[p.pos:], strings.Repeat(quote, 3))
+	if multi {
+		quote = strings.Repeat(quote, 3)
+	}
+	p.pos += len(quote)
+	if multi {
+		// A newline right after the opening quotes is not part of the string.
+		if p.consume("\r\n") || p.consume("\n") {
+			p.line++
+		}
+	}
+
+	var s strings.Builder
+	for {
+		switch {
+		case p.eof():
+			return "", errors.New("unterminated string")
+		case strings.HasPrefix(p.src[p.pos:], quote):
+			p.pos += len(quote)
+			return s.String(), nil
+		case p.peek() == '\n' && !multi:
+			return "", errors.New("newline in string")
+		case p.peek() == '\\' && quote[0] == '"':
+			if err := p.escape(&s, multi); err != nil {
+				return "", err
+			}
+		default:
+			if p.peek() == '\n' {
+				p.line++
+			}
+			s.WriteByte(p.peek())
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) escape(s *strings.Builder, multi bool) error {
+	p.pos++
+	if p.eof() {
+		return errors.New("unterminated string")
+	}
+	c := p.peek()
+	p.pos++
+	simple := map[byte]string{'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", '"': "\"", '\\': "\\"}
+	switch {
+	case simple[c] != "":
+		s.WriteString(simple[c])
+	case c == 'u' || c == 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return errors.New("short unicode escape")
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil {
+			return errors.New("bad unicode escape")
+		}
+		s.WriteRune(rune(r))
+		p.pos += n
+	case multi && (c == '\n' || c == ' ' || c == '\t' || c == '\r'):
+		// A backslash ending a line trims the whitespace that follows.
+		p.pos--
+		p.skipBlank(true)
+	default:
+		return errors.New("bad escape \\" + string(c))
+	}
+	return nil
+}
+
+// skipBlank skips whitespace and comments, along with newlines if asked to.
+func (p *parser) skipBlank(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.line++
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// lineEnd checks nothing but a comment follows on the line.
+func (p *parser) lineEnd() error {
+	p.skipBlank(false)
+	if p.eof() || p.peek() == '\n' {
+		return nil
+	}
+	return errors.New("unexpected " + strconv.Quote(p.src[p.pos:p.pos+1]) + " at the end of a line")
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) peek() byte { return p.src[p.pos] }
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package toml_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samsarahq/go/oops"
+	"github.com/szabba/assert"
+
+	"github.com/szabba/unibuild/toml"
+)
+
+const _Document = `# A manifest
+name = "billing" # trailing comment
+"quoted key" = 'C:\literal'
+count = 1_000
+ratio = 0.5
+enabled = true
+released = 1979-05-27 07:32:00
+site.title = "Billing"
+
+commands = [
+  "make site",   # comments in arrays
+  "make publish",
+]
+
+[deps]
+serde = { version = "1.0", features = ["derive"] }
+"tokio" = "1"
+
+[tool.poetry]
+description = """
+Multi-line \
+  text"""
+
+[[bin]]
+name = "cli"
+
+[[bin]]
+name = "server"
+`
+
+func TestParse(t *testing.T) {
+	// when
+	doc, err := toml.Parse(_Document)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := map[string]interface{}{
+		"name":       "billing",
+		"quoted key": `C:\literal`,
+		"count":      int64(1000),
+		"ratio":      0.5,
+		"enabled":    true,
+		"released":   "1979-05-27 07:32:00",
+		"site":       map[string]interface{}{"title": "Billing"},
+		"commands":   []interface{}{"make site", "make publish"},
+		"deps": map[string]interface{}{
+			"serde": map[string]interface{}{"version": "1.0", "features": []interface{}{"derive"}},
+			"tokio": "1",
+		},
+		"tool": map[string]interface{}{
+			"poetry": map[string]interface{}{"description": "Multi-line text"},
+		},
+		"bin": []interface{}{
+			map[string]interface{}{"name": "cli"},
+			map[string]interface{}{"name": "server"},
+		},
+	}
+	for k, v := range want {
+		assert.That(reflect.DeepEqual(doc[k], v), t.Errorf, "%s: got %#v, want %#v", k, doc[k], v)
+	}
+	assert.That(len(doc) == len(want), t.Errorf, "got %d keys, want %d", len(doc), len(want))
+}
+
+func TestUnmarshal(t *testing.T) {
+	// given
+	var into struct {
+		Name     string   `json:"name"`
+		Commands []string `json:"commands"`
+		Bin      []struct {
+			Name string `json:"name"`
+		} `json:"bin"`
+	}
+
+	// when
+	err := toml.Unmarshal([]byte(_Document), &into)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	assert.That(into.Name == "billing", t.Errorf, "got name %q", into.Name)
+	assert.That(len(into.Commands) == 2, t.Errorf, "got commands %q", into.Commands)
+	assert.That(len(into.Bin) == 2 && into.Bin[1].Name == "server", t.Errorf, "got bins %#v", into.Bin)
+}
+
+func TestParseRejectsMalformedDocuments(t *testing.T) {
+	for _, src := range []string{
+		"name = ",
+		"name = \"unterminated",
+		"name = \"a\" \"b\"",
+		"name = 1\nname = 2",
+		"[table",
+		"list = [1, 2",
+		"key value",
+		"a = []\n[a.b]\n",
+		"a = []\n[[a.b]]\n",
+		"a = []\na.b = 1\n",
+		"[a]\nb = 1\n[a]\nc = 2\n",
+		"[a.b]\n[a.b]\n",
+		"a.b = 1\n[a]\n",
+		"[[a]]\n[a]\n",
+		"a = { b = 1 }\n[a]\n",
+		"a = { b = 1 }\na.c = 2\n",
+		"a = [{ b = 1 }]\n[a.c]\n",
+	} {
+		// when
+		_, err := toml.Parse(src)
+
+		// then
+		assert.That(oops.Cause(err) == toml.ErrSyntax, t.Errorf, "%q: got error %v, want %v", src, err, toml.ErrSyntax)
+	}
+}
+
+func TestParseAllowsTablesToBeDefinedAfterTheirSubtables(t *testing.T) {
+	// given
+	src := "[a.b]\nc = 1\n[a]\nd = 2\n[[e]]\n[e.f]\n[[e]]\n[e.f]\n"
+
+	// when
+	doc, err := toml.Parse(src)
+
+	// then
+	assert.That(err == nil, t.Fatalf, "unexpected error: %s", err)
+	want := map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": int64(1)}, "d": int64(2)},
+		"e": []interface{}{
+			map[string]interface{}{"f": map[string]interface{}{}},
+			map[string]interface{}{"f": map[string]interface{}{}},
+		},
+	}
+	assert.That(reflect.DeepEqual(doc, want), t.Errorf, "got %#v, want %#v", doc, want)
+}